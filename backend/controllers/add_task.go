@@ -8,18 +8,20 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"time"
 )
 
 var GlobalJobQueue *JobQueue
 
 // AddTaskHandler godoc
 // @Summary Add a new task
-// @Description Add a new task to Taskwarrior for a specific user
+// @Description Add a new task to Taskwarrior for a specific user. Dates accept ISO 8601 as well as Taskwarrior date expressions such as "tomorrow 9am", "eow", "+3d", "next monday" or "15th".
 // @Tags Tasks
 // @Accept json
 // @Produce json
 // @Param task body models.AddTaskRequestBody true "Task details"
-// @Success 202 {string} string "Task accepted for processing"
+// @Param X-Timezone header string false "IANA timezone in which dates without an offset, such as \"2025-04-01\" or \"tomorrow 9am\", are read (default: the timezone in the user's settings, else UTC)"
+// @Success 202 {object} controllers.JobAcceptedResponse "Task accepted for processing; poll /jobs/{id} for the outcome"
 // @Failure 400 {string} string "Bad request - invalid input or missing required fields"
 // @Failure 405 {string} string "Method not allowed"
 // @Router /add-task [post]
@@ -47,14 +49,7 @@ func AddTaskHandler(w http.ResponseWriter, r *http.Request) {
 					return
 				}
 			} else {
-				taskDeps := make([]utils.TaskDependency, len(existingTasks))
-				for i, task := range existingTasks {
-					taskDeps[i] = utils.TaskDependency{
-						UUID:    task.UUID,
-						Depends: task.Depends,
-						Status:  task.Status,
-					}
-				}
+				taskDeps := models.TaskDependencies(existingTasks)
 
 				if err := utils.ValidateCircularDependencies(requestBody.Depends, "", taskDeps); err != nil {
 					http.Error(w, fmt.Sprintf("Invalid dependencies: %v", err), http.StatusBadRequest)
@@ -63,6 +58,22 @@ func AddTaskHandler(w http.ResponseWriter, r *http.Request) {
 			}
 		}
 
+		loc, err := requestLocation(r, requestBody.UUID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := normalizeDateInputs(time.Now().In(loc),
+			dateField{"due", requestBody.DueDate},
+			dateField{"start", &requestBody.Start},
+			dateField{"entry", &requestBody.EntryDate},
+			dateField{"wait", &requestBody.WaitDate},
+			dateField{"end", &requestBody.End},
+		); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
 		dueDateStr, err := utils.ConvertOptionalISOToTaskwarriorFormat(requestBody.DueDate)
 		if err != nil {
 			http.Error(w, fmt.Sprintf("Invalid due date format: %v", err), http.StatusBadRequest)
@@ -71,7 +82,8 @@ func AddTaskHandler(w http.ResponseWriter, r *http.Request) {
 
 		logStore := models.GetLogStore()
 		job := Job{
-			Name: "Add Task",
+			Name:  "Add Task",
+			Owner: requestBody.UUID,
 			Execute: func() error {
 				logStore.AddLog("INFO", fmt.Sprintf("Adding task: %s", requestBody.Description), requestBody.UUID, "Add Task")
 				err := tw.AddTaskToTaskwarrior(requestBody, dueDateStr)
@@ -83,8 +95,7 @@ func AddTaskHandler(w http.ResponseWriter, r *http.Request) {
 				return nil
 			},
 		}
-		GlobalJobQueue.AddJob(job)
-		w.WriteHeader(http.StatusAccepted)
+		acceptJob(w, GlobalJobQueue.AddJob(job))
 		return
 	}
 	http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
@@ -0,0 +1,128 @@
+package controllers
+
+import (
+	"ccsync_backend/models"
+	"ccsync_backend/utils/tw"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/sessions"
+)
+
+// AddAnnotationHandler godoc
+// @Summary Add an annotation to a task
+// @Description Append a single annotation to a task. Existing annotations and their entry timestamps are left untouched.
+// @Tags Tasks
+// @Accept json
+// @Produce json
+// @Param uuid path string true "Task UUID"
+// @Param annotation body models.AddAnnotationRequestBody true "Annotation text"
+// @Success 202 {object} controllers.JobAcceptedResponse "Annotation accepted for processing; poll /jobs/{id} for the outcome"
+// @Failure 400 {string} string "Bad request - invalid input or missing description"
+// @Failure 405 {string} string "Method not allowed"
+// @Router /tasks/{uuid}/annotations [post]
+func AddAnnotationHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("error reading request body: %v", err), http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	var requestBody models.AddAnnotationRequestBody
+
+	err = json.Unmarshal(body, &requestBody)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("error decoding request body: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	email := requestBody.Email
+	encryptionSecret := requestBody.EncryptionSecret
+	uuid := requestBody.UUID
+	description := strings.TrimSpace(requestBody.Description)
+	taskuuid, _ := splitTaskPath(r.URL.Path)
+
+	if description == "" {
+		http.Error(w, "annotation description is required", http.StatusBadRequest)
+		return
+	}
+
+	logStore := models.GetLogStore()
+	job := Job{
+		Name:  "Add Annotation",
+		Owner: uuid,
+		Execute: func() error {
+			logStore.AddLog("INFO", fmt.Sprintf("Adding annotation to task UUID: %s", taskuuid), uuid, "Add Annotation")
+			err := tw.AddAnnotationInTaskwarrior(email, encryptionSecret, uuid, taskuuid, description)
+			if err != nil {
+				logStore.AddLog("ERROR", fmt.Sprintf("Failed to add annotation to task UUID %s: %v", taskuuid, err), uuid, "Add Annotation")
+				return err
+			}
+			logStore.AddLog("INFO", fmt.Sprintf("Successfully added annotation to task UUID: %s", taskuuid), uuid, "Add Annotation")
+			return nil
+		},
+	}
+	acceptJob(w, GlobalJobQueue.AddJob(job))
+}
+
+// DeleteAnnotationHandler godoc
+// @Summary Delete an annotation from a task
+// @Description Remove a single annotation, identified by its entry timestamp. Other annotations keep their original entry timestamps.
+// @Tags Tasks
+// @Produce json
+// @Param uuid path string true "Task UUID"
+// @Param entry path string true "Annotation entry timestamp (e.g. 20240101T120000Z)"
+// @Param description query string false "Annotation text, to disambiguate annotations added in the same second"
+// @Success 202 {object} controllers.JobAcceptedResponse "Annotation deletion accepted for processing; poll /jobs/{id} for the outcome"
+// @Failure 400 {string} string "Missing entry"
+// @Failure 401 {string} string "Authentication required"
+// @Failure 405 {string} string "Method not allowed"
+// @Router /tasks/{uuid}/annotations/{entry} [delete]
+func DeleteAnnotationHandler(store *sessions.CookieStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		email, encryptionSecret, uuid, ok := sessionCredentials(store, r)
+		if !ok {
+			http.Error(w, "Authentication required", http.StatusUnauthorized)
+			return
+		}
+
+		taskuuid, resource := splitTaskPath(r.URL.Path)
+		if len(resource) < 2 || resource[1] == "" {
+			http.Error(w, "annotation entry is required", http.StatusBadRequest)
+			return
+		}
+		entry := resource[1]
+		description := r.URL.Query().Get("description")
+
+		logStore := models.GetLogStore()
+		job := Job{
+			Name:  "Delete Annotation",
+			Owner: uuid,
+			Execute: func() error {
+				logStore.AddLog("INFO", fmt.Sprintf("Deleting annotation %s from task UUID: %s", entry, taskuuid), uuid, "Delete Annotation")
+				err := tw.DeleteAnnotationInTaskwarrior(email, encryptionSecret, uuid, taskuuid, entry, description)
+				if err != nil {
+					logStore.AddLog("ERROR", fmt.Sprintf("Failed to delete annotation from task UUID %s: %v", taskuuid, err), uuid, "Delete Annotation")
+					return err
+				}
+				logStore.AddLog("INFO", fmt.Sprintf("Successfully deleted annotation from task UUID: %s", taskuuid), uuid, "Delete Annotation")
+				return nil
+			},
+		}
+		acceptJob(w, GlobalJobQueue.AddJob(job))
+	}
+}
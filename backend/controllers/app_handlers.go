@@ -197,11 +197,14 @@ func (a *App) EnableCORS(handler http.Handler) http.Handler {
 			w.Header().Set("Access-Control-Allow-Origin", allowedOrigin)
 		}
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-User-Email, X-Encryption-Secret, X-User-UUID")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-User-Email, X-Encryption-Secret, X-User-UUID, If-None-Match, If-Match, Idempotency-Key, X-Timezone")
+		w.Header().Set("Access-Control-Expose-Headers", "ETag, Location, X-Next-Cursor, X-Total-Count, X-Context, Idempotent-Replayed")
 		w.Header().Set("Access-Control-Allow-Credentials", "true") // to allow credentials
 		w.Header().Add("Vary", "Origin")                           // prevent caching issues with different origins
 
-		if r.Method == "OPTIONS" {
+		// CalDAV clients send OPTIONS to discover the server's capabilities, which
+		// only the CalDAV handler knows
+		if r.Method == "OPTIONS" && !strings.HasPrefix(r.URL.Path, "/caldav") {
 			w.WriteHeader(http.StatusOK)
 			return
 		}
@@ -0,0 +1,102 @@
+package controllers
+
+import (
+	"ccsync_backend/models"
+	"ccsync_backend/utils"
+	"ccsync_backend/utils/tw"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// BatchHandler godoc
+// @Summary Apply several operations at once
+// @Description Apply an ordered list of add, edit, modify, complete, delete and annotate operations in a single Taskwarrior session with a single sync. The batch is all-or-nothing: if any operation fails, none is synced. An add can name its task with ref, and later operations refer to it as "$ref" in taskuuid or depends. The job result lists the outcome of every operation, also when the batch fails.
+// @Tags Tasks
+// @Accept json
+// @Produce json
+// @Param batch body models.BatchRequestBody true "Operations to apply, in order"
+// @Param X-Timezone header string false "IANA timezone in which dates without an offset, such as \"2025-04-01\" or \"tomorrow 9am\", are read (default: the timezone in the user's settings, else UTC)"
+// @Success 202 {object} controllers.JobAcceptedResponse "Batch accepted for processing; poll /jobs/{id} for the per-operation results"
+// @Failure 400 {string} string "Bad request - invalid operations"
+// @Failure 405 {string} string "Method not allowed"
+// @Router /batch [post]
+func BatchHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var requestBody models.BatchRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+		http.Error(w, fmt.Sprintf("error decoding request body: %v", err), http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	if err := models.ValidateBatch(requestBody.Operations); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	loc, err := requestLocation(r, requestBody.UUID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	operations, err := normalizeBatchDates(requestBody.Operations, time.Now().In(loc))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	email := requestBody.Email
+	encryptionSecret := requestBody.EncryptionSecret
+	uuid := requestBody.UUID
+
+	var results []models.BatchOperationResult
+	logStore := models.GetLogStore()
+	job := Job{
+		Name:  "Batch",
+		Owner: uuid,
+		Result: func() interface{} {
+			return results
+		},
+		Execute: func() error {
+			logStore.AddLog("INFO", fmt.Sprintf("Applying batch of %d operations", len(operations)), uuid, "Batch")
+
+			var err error
+			results, err = tw.ApplyBatchInTaskwarrior(email, encryptionSecret, uuid, operations)
+			if err != nil {
+				logStore.AddLog("ERROR", fmt.Sprintf("Batch failed, no operation was applied: %v", err), uuid, "Batch")
+				return err
+			}
+			logStore.AddLog("INFO", fmt.Sprintf("Successfully applied batch of %d operations", len(operations)), uuid, "Batch")
+			return nil
+		},
+	}
+	acceptJob(w, GlobalJobQueue.AddJob(job))
+}
+
+// normalizeBatchDates validates the dates of every operation and resolves them, date
+// expressions included, into UTC Taskwarrior timestamps the way the single-task
+// handlers do, taking dates without an explicit offset in the location of now
+func normalizeBatchDates(operations []models.BatchOperation, now time.Time) ([]models.BatchOperation, error) {
+	normalized := make([]models.BatchOperation, len(operations))
+	for i, op := range operations {
+		fields := []struct {
+			name  string
+			value *string
+		}{{"due", &op.Due}, {"start", &op.Start}, {"entry", &op.Entry}, {"wait", &op.Wait}, {"end", &op.End}}
+
+		for _, field := range fields {
+			resolved, err := utils.NormalizeDateInput(*field.value, now)
+			if err != nil {
+				return nil, fmt.Errorf("operation %d: invalid %s date format: %v", i, field.name, err)
+			}
+			*field.value = resolved
+		}
+		normalized[i] = op
+	}
+	return normalized, nil
+}
@@ -0,0 +1,477 @@
+package controllers
+
+import (
+	"ccsync_backend/models"
+	"ccsync_backend/utils"
+	"ccsync_backend/utils/ical"
+	"ccsync_backend/utils/query"
+	"ccsync_backend/utils/tw"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// caldavRoot is both the principal and the calendar home of the token's user
+	caldavRoot = "/caldav/"
+	// caldavCollection is the calendar holding the user's tasks as VTODOs
+	caldavCollection = caldavRoot + "tasks/"
+
+	caldavMethods = "OPTIONS, GET, HEAD, PUT, DELETE, PROPFIND, REPORT"
+
+	// maxCalDAVBody bounds the size of request bodies
+	maxCalDAVBody = 1 << 20
+
+	// caldavCompletedDays is how long completed tasks stay in the calendar
+	caldavCompletedDays = 30
+)
+
+// CalDAVHandler godoc
+// @Summary CalDAV server for task apps
+// @Description Serve the user's tasks as a CalDAV calendar of VTODOs (RFC 4791) at /caldav/tasks/, for task apps that sync over CalDAV; /.well-known/caldav redirects to /caldav/. Clients authenticate with HTTP Basic, using any user name and a token with the caldav scope from /feeds/tokens as the password. PROPFIND and the calendar-query and calendar-multiget REPORTs list the pending, waiting and recently completed tasks, each at /caldav/tasks/{uuid}.ics with the task's modified timestamp as its ETag. PUT creates or updates a task from a VTODO (summary, due date, priority, status and categories as tags) and DELETE deletes it; both are queued as jobs, whose ID is returned in the X-Job-Id header, and honor If-Match and If-None-Match. Recurring series appear as their instances, without recurrence rules.
+// @Tags CalDAV
+// @Produce text/calendar
+// @Param resource path string true "Task UUID followed by .ics"
+// @Param Authorization header string true "Basic credentials whose password is a caldav token"
+// @Param If-Match header string false "ETag of the task as last read; the request fails if it changed since"
+// @Param If-None-Match header string false "* to only create the task if it does not exist (PUT)"
+// @Success 200 {string} string "VTODO of the task (GET)"
+// @Success 201 "Task creation queued (PUT)"
+// @Success 204 "Task update or deletion queued (PUT, DELETE)"
+// @Failure 400 {string} string "Invalid VTODO"
+// @Failure 401 {string} string "Missing or invalid token"
+// @Failure 404 {string} string "Task not found"
+// @Failure 405 {string} string "Method not allowed"
+// @Failure 412 {string} string "Precondition failed"
+// @Failure 500 {string} string "Failed to fetch tasks at backend"
+// @Router /caldav/tasks/{resource} [get]
+// @Router /caldav/tasks/{resource} [put]
+// @Router /caldav/tasks/{resource} [delete]
+func CalDAVHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("DAV", "1, 3, calendar-access")
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Allow", caldavMethods)
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	credentials, ok := caldavCredentials(r)
+	if !ok {
+		w.Header().Set("WWW-Authenticate", `Basic realm="ccsync"`)
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	loc, err := requestLocation(r, credentials.UUID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	dav := &caldavRequest{credentials: credentials, location: loc}
+
+	switch p := r.URL.Path; {
+	case p == caldavRoot || p+"/" == caldavRoot:
+		if r.Method != "PROPFIND" {
+			http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+			return
+		}
+		dav.propfindRoot(w, r)
+	case p == caldavCollection || p+"/" == caldavCollection:
+		switch r.Method {
+		case "PROPFIND":
+			dav.propfindCollection(w, r)
+		case "REPORT":
+			dav.report(w, r)
+		default:
+			http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		}
+	case strings.HasPrefix(p, caldavCollection) && strings.HasSuffix(p, ".ics") && !strings.Contains(strings.TrimPrefix(p, caldavCollection), "/"):
+		taskUUID := caldavTaskUUID(strings.TrimPrefix(p, caldavCollection))
+		switch r.Method {
+		case http.MethodGet, http.MethodHead:
+			dav.get(w, r, taskUUID)
+		case http.MethodPut:
+			dav.put(w, r, taskUUID)
+		case http.MethodDelete:
+			dav.delete(w, r, taskUUID)
+		case "PROPFIND":
+			dav.propfindResource(w, r, taskUUID)
+		default:
+			http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		}
+	default:
+		http.Error(w, "Not found", http.StatusNotFound)
+	}
+}
+
+// CalDAVDiscoveryHandler redirects clients looking up the CalDAV server (RFC 6764)
+func CalDAVDiscoveryHandler(w http.ResponseWriter, r *http.Request) {
+	http.Redirect(w, r, caldavRoot, http.StatusMovedPermanently)
+}
+
+// caldavCredentials resolves the password of the Basic credentials as a caldav token
+func caldavCredentials(r *http.Request) (models.FeedCredentials, bool) {
+	_, password, ok := r.BasicAuth()
+	if !ok || password == "" {
+		return models.FeedCredentials{}, false
+	}
+	credentials, _, ok := models.GetFeedTokenStore().Resolve(password, models.FeedScopeCalDAV)
+	return credentials, ok
+}
+
+// caldavTaskUUID maps a resource name to the UUID of its task. Clients creating a
+// to-do choose the name; names that are not UUIDs are hashed into one, so the task
+// is listed under its UUID afterwards.
+func caldavTaskUUID(name string) string {
+	name = strings.TrimSuffix(name, ".ics")
+	if parsed, err := uuid.Parse(name); err == nil {
+		return parsed.String()
+	}
+	return uuid.NewSHA1(uuid.NameSpaceURL, []byte(caldavCollection+name)).String()
+}
+
+// caldavTasks keeps the tasks a CalDAV client sees: open tasks, including the instances
+// of recurring series but not their templates, and tasks completed in the last 30 days
+func caldavTasks(tasks []models.Task, now time.Time) []models.Task {
+	cutoff := now.UTC().AddDate(0, 0, -caldavCompletedDays).Format(utils.TaskwarriorDateLayout)
+	visible := []models.Task{}
+	for _, task := range tasks {
+		switch task.Status {
+		case "pending", "waiting":
+			visible = append(visible, task)
+		case "completed":
+			// compact UTC dates sort chronologically as strings
+			if task.End >= cutoff {
+				visible = append(visible, task)
+			}
+		}
+	}
+	return visible
+}
+
+func caldavETag(task models.Task) string {
+	return `"` + task.Modified + `"`
+}
+
+// caldavIfMatch returns the modified timestamp an If-Match header expects
+func caldavIfMatch(r *http.Request) string {
+	return strings.Trim(strings.TrimPrefix(strings.TrimSpace(r.Header.Get("If-Match")), "W/"), `"`)
+}
+
+// caldavRequest is a request authenticated with a caldav token
+type caldavRequest struct {
+	credentials models.FeedCredentials
+	location    *time.Location
+}
+
+func (c *caldavRequest) fetchTasks() ([]models.Task, error) {
+	origin := os.Getenv("CONTAINER_ORIGIN")
+	tasks, err := tw.FetchTasksFromTaskwarrior(c.credentials.Email, c.credentials.EncryptionSecret, origin, c.credentials.UUID)
+	if err != nil || tasks == nil {
+		return nil, fmt.Errorf("failed to fetch tasks at backend")
+	}
+	return tasks, nil
+}
+
+// findTask returns the visible task with the given UUID, and whether the task exists
+// at all
+func (c *caldavRequest) findTask(tasks []models.Task, taskUUID string) (*models.Task, bool) {
+	for _, task := range tasks {
+		if task.UUID != taskUUID {
+			continue
+		}
+		if visible := caldavTasks([]models.Task{task}, time.Now()); len(visible) > 0 {
+			return &visible[0], true
+		}
+		return nil, true
+	}
+	return nil, false
+}
+
+func (c *caldavRequest) principalProperties() []davProperty {
+	privileges := ""
+	for _, privilege := range []string{"read", "write", "write-content", "bind", "unbind"} {
+		privileges += "<privilege><" + privilege + "/></privilege>"
+	}
+	return []davProperty{
+		{Name: propResourceType, Value: "<collection/>"},
+		{Name: propDisplayName, Value: "CCSync"},
+		{Name: propCurrentUserPrincipal, Value: davHref(caldavRoot)},
+		{Name: propPrincipalURL, Value: davHref(caldavRoot)},
+		{Name: propCalendarHomeSet, Value: davHref(caldavRoot)},
+		{Name: propCurrentUserPrivilegeSet, Value: privileges},
+	}
+}
+
+func (c *caldavRequest) collectionProperties(tasks []models.Task) []davProperty {
+	reports := ""
+	for _, report := range []string{"calendar-query", "calendar-multiget"} {
+		reports += "<supported-report><report>" + davElementXML(xml.Name{Space: caldavNamespace, Local: report}, "") + "</report></supported-report>"
+	}
+	properties := c.principalProperties()
+	properties[0].Value = "<collection/>" + davElementXML(xml.Name{Space: caldavNamespace, Local: "calendar"}, "")
+	properties[1].Value = "CCSync tasks"
+	return append(properties,
+		davProperty{Name: propSupportedComponents, Value: `<comp xmlns="` + caldavNamespace + `" name="VTODO"/>`},
+		davProperty{Name: propSupportedReportSet, Value: reports},
+		davProperty{Name: propGetCTag, Value: escapeXML(query.ETag(tasks, "caldav"))},
+	)
+}
+
+func (c *caldavRequest) resourceProperties(task models.Task) []davProperty {
+	return []davProperty{
+		{Name: propResourceType},
+		{Name: propGetETag, Value: escapeXML(caldavETag(task))},
+		{Name: propGetContentType, Value: "text/calendar; charset=utf-8; component=vtodo"},
+		{Name: propCalendarData, Value: escapeXML(c.todo(task))},
+	}
+}
+
+func (c *caldavRequest) todo(task models.Task) string {
+	return ical.Todo(task, ical.Options{Location: c.location, Now: time.Now(), OmitRecurrence: true})
+}
+
+func (c *caldavRequest) resourceResponses(tasks []models.Task) []davResponse {
+	responses := []davResponse{}
+	for _, task := range caldavTasks(tasks, time.Now()) {
+		responses = append(responses, davResponse{
+			Href:       caldavCollection + task.UUID + ".ics",
+			Properties: c.resourceProperties(task),
+		})
+	}
+	return responses
+}
+
+// propfindRoot describes the principal, and with Depth 1 the task collection it holds
+func (c *caldavRequest) propfindRoot(w http.ResponseWriter, r *http.Request) {
+	request, err := readPropfind(r.Body)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid PROPFIND body: %v", err), http.StatusBadRequest)
+		return
+	}
+	responses := []davResponse{{Href: caldavRoot, Properties: c.principalProperties()}}
+	if r.Header.Get("Depth") != "0" {
+		tasks, err := c.fetchTasks()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		responses = append(responses, davResponse{Href: caldavCollection, Properties: c.collectionProperties(tasks)})
+	}
+	writeMultistatus(w, responses, request)
+}
+
+// propfindCollection describes the task collection, and with Depth 1 its tasks
+func (c *caldavRequest) propfindCollection(w http.ResponseWriter, r *http.Request) {
+	request, err := readPropfind(r.Body)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid PROPFIND body: %v", err), http.StatusBadRequest)
+		return
+	}
+	tasks, err := c.fetchTasks()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	responses := []davResponse{{Href: caldavCollection, Properties: c.collectionProperties(tasks)}}
+	if r.Header.Get("Depth") != "0" {
+		responses = append(responses, c.resourceResponses(tasks)...)
+	}
+	writeMultistatus(w, responses, request)
+}
+
+func (c *caldavRequest) propfindResource(w http.ResponseWriter, r *http.Request, taskUUID string) {
+	request, err := readPropfind(r.Body)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid PROPFIND body: %v", err), http.StatusBadRequest)
+		return
+	}
+	tasks, err := c.fetchTasks()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	task, _ := c.findTask(tasks, taskUUID)
+	if task == nil {
+		http.Error(w, "Task not found", http.StatusNotFound)
+		return
+	}
+	writeMultistatus(w, []davResponse{{Href: r.URL.Path, Properties: c.resourceProperties(*task)}}, request)
+}
+
+// report answers calendar-query, listing every task, and calendar-multiget, listing
+// the tasks at the requested hrefs
+func (c *caldavRequest) report(w http.ResponseWriter, r *http.Request) {
+	data, err := io.ReadAll(io.LimitReader(r.Body, maxCalDAVBody))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("error reading request body: %v", err), http.StatusBadRequest)
+		return
+	}
+	var report reportBody
+	if err := xml.Unmarshal(data, &report); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid REPORT body: %v", err), http.StatusBadRequest)
+		return
+	}
+	if report.XMLName.Space != caldavNamespace || (report.XMLName.Local != "calendar-query" && report.XMLName.Local != "calendar-multiget") {
+		http.Error(w, fmt.Sprintf("Unsupported report '%s'", report.XMLName.Local), http.StatusForbidden)
+		return
+	}
+	request := report.Prop.request(report.AllProp != nil)
+
+	tasks, err := c.fetchTasks()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if report.XMLName.Local == "calendar-query" {
+		responses := []davResponse{}
+		if report.Filter.todoComponents() {
+			responses = c.resourceResponses(tasks)
+		}
+		writeMultistatus(w, responses, request)
+		return
+	}
+
+	responses := []davResponse{}
+	for _, href := range report.Hrefs {
+		href = strings.TrimSpace(href)
+		if parsed, err := url.Parse(href); err == nil {
+			href = parsed.Path
+		}
+		task, _ := c.findTask(tasks, caldavTaskUUID(path.Base(href)))
+		if task == nil || path.Dir(href)+"/" != caldavCollection {
+			responses = append(responses, davResponse{Href: href, Status: http.StatusNotFound})
+			continue
+		}
+		responses = append(responses, davResponse{Href: href, Properties: c.resourceProperties(*task)})
+	}
+	writeMultistatus(w, responses, request)
+}
+
+func (c *caldavRequest) get(w http.ResponseWriter, r *http.Request, taskUUID string) {
+	tasks, err := c.fetchTasks()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	task, _ := c.findTask(tasks, taskUUID)
+	if task == nil {
+		http.Error(w, "Task not found", http.StatusNotFound)
+		return
+	}
+
+	etag := caldavETag(*task)
+	w.Header().Set("ETag", etag)
+	if query.MatchesETag(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Write([]byte(c.todo(*task)))
+}
+
+// put saves a VTODO as a task. Preconditions are checked against the tasks as fetched
+// here, so clients learn of conflicts at once, and again by the job.
+func (c *caldavRequest) put(w http.ResponseWriter, r *http.Request, taskUUID string) {
+	data, err := io.ReadAll(io.LimitReader(r.Body, maxCalDAVBody))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("error reading request body: %v", err), http.StatusBadRequest)
+		return
+	}
+	parsed, err := ical.ParseTodo(string(data), c.location)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid VTODO: %v", err), http.StatusBadRequest)
+		return
+	}
+	todo := parsed.Fields()
+
+	tasks, err := c.fetchTasks()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	task, exists := c.findTask(tasks, taskUUID)
+	createOnly := strings.TrimSpace(r.Header.Get("If-None-Match")) == "*"
+	precondition := tw.Precondition{ExpectedModified: caldavIfMatch(r)}
+	switch {
+	case createOnly && exists:
+		http.Error(w, "Task already exists", http.StatusPreconditionFailed)
+		return
+	case precondition.ExpectedModified != "" && (task == nil || task.Modified != precondition.ExpectedModified):
+		http.Error(w, "Task changed since it was read", http.StatusPreconditionFailed)
+		return
+	}
+
+	email := c.credentials.Email
+	encryptionSecret := c.credentials.EncryptionSecret
+	userUUID := c.credentials.UUID
+	logStore := models.GetLogStore()
+	job := Job{
+		Name:      "Save To-do",
+		Owner:     userUUID,
+		Requested: requestedChanges(todo),
+		Execute: func() error {
+			logStore.AddLog("INFO", fmt.Sprintf("Saving to-do as task UUID: %s", taskUUID), userUUID, "Save To-do")
+			err := tw.SaveTodoInTaskwarrior(email, encryptionSecret, userUUID, taskUUID, todo, createOnly, precondition)
+			if err != nil {
+				logStore.AddLog("ERROR", fmt.Sprintf("Failed to save to-do as task UUID %s: %v", taskUUID, err), userUUID, "Save To-do")
+				return err
+			}
+			logStore.AddLog("INFO", fmt.Sprintf("Successfully saved to-do as task UUID: %s", taskUUID), userUUID, "Save To-do")
+			return nil
+		},
+	}
+	w.Header().Set("X-Job-Id", GlobalJobQueue.AddJob(job))
+	if exists {
+		w.WriteHeader(http.StatusNoContent)
+	} else {
+		w.WriteHeader(http.StatusCreated)
+	}
+}
+
+func (c *caldavRequest) delete(w http.ResponseWriter, r *http.Request, taskUUID string) {
+	tasks, err := c.fetchTasks()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	task, _ := c.findTask(tasks, taskUUID)
+	if task == nil {
+		http.Error(w, "Task not found", http.StatusNotFound)
+		return
+	}
+	precondition := tw.Precondition{ExpectedModified: caldavIfMatch(r)}
+	if precondition.ExpectedModified != "" && task.Modified != precondition.ExpectedModified {
+		http.Error(w, "Task changed since it was read", http.StatusPreconditionFailed)
+		return
+	}
+
+	email := c.credentials.Email
+	encryptionSecret := c.credentials.EncryptionSecret
+	userUUID := c.credentials.UUID
+	logStore := models.GetLogStore()
+	job := Job{
+		Name:  "Delete Task",
+		Owner: userUUID,
+		Execute: func() error {
+			logStore.AddLog("INFO", fmt.Sprintf("Deleting task UUID: %s", taskUUID), userUUID, "Delete Task")
+			err := tw.DeleteTaskInTaskwarrior(email, encryptionSecret, userUUID, taskUUID, models.CascadeNone, precondition)
+			if err != nil {
+				logStore.AddLog("ERROR", fmt.Sprintf("Failed to delete task UUID %s: %v", taskUUID, err), userUUID, "Delete Task")
+				return err
+			}
+			logStore.AddLog("INFO", fmt.Sprintf("Successfully deleted task UUID: %s", taskUUID), userUUID, "Delete Task")
+			return nil
+		},
+	}
+	w.Header().Set("X-Job-Id", GlobalJobQueue.AddJob(job))
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,211 @@
+package controllers
+
+import (
+	"encoding/xml"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	davNamespace            = "DAV:"
+	caldavNamespace         = "urn:ietf:params:xml:ns:caldav"
+	calendarServerNamespace = "http://calendarserver.org/ns/"
+)
+
+var (
+	propResourceType            = xml.Name{Space: davNamespace, Local: "resourcetype"}
+	propDisplayName             = xml.Name{Space: davNamespace, Local: "displayname"}
+	propCurrentUserPrincipal    = xml.Name{Space: davNamespace, Local: "current-user-principal"}
+	propPrincipalURL            = xml.Name{Space: davNamespace, Local: "principal-URL"}
+	propCurrentUserPrivilegeSet = xml.Name{Space: davNamespace, Local: "current-user-privilege-set"}
+	propSupportedReportSet      = xml.Name{Space: davNamespace, Local: "supported-report-set"}
+	propGetETag                 = xml.Name{Space: davNamespace, Local: "getetag"}
+	propGetContentType          = xml.Name{Space: davNamespace, Local: "getcontenttype"}
+	propCalendarHomeSet         = xml.Name{Space: caldavNamespace, Local: "calendar-home-set"}
+	propSupportedComponents     = xml.Name{Space: caldavNamespace, Local: "supported-calendar-component-set"}
+	propCalendarData            = xml.Name{Space: caldavNamespace, Local: "calendar-data"}
+	propGetCTag                 = xml.Name{Space: calendarServerNamespace, Local: "getctag"}
+)
+
+// davProperty is a property of a resource, its value already written as XML
+type davProperty struct {
+	Name  xml.Name
+	Value string
+}
+
+// davResponse is the entry of a resource in a multistatus response. Status, when
+// set, replaces the properties, as for an href that does not exist.
+type davResponse struct {
+	Href       string
+	Properties []davProperty
+	Status     int
+}
+
+// davPropRequest lists the properties a PROPFIND or REPORT asks for; All is set for
+// allprop and for requests without a body
+type davPropRequest struct {
+	All   bool
+	Names []xml.Name
+}
+
+// davElement matches any element, keeping its name
+type davElement struct {
+	XMLName xml.Name
+}
+
+type davPropList struct {
+	Names []davElement `xml:",any"`
+}
+
+type propfindBody struct {
+	XMLName xml.Name     `xml:"DAV: propfind"`
+	AllProp *struct{}    `xml:"DAV: allprop"`
+	Prop    *davPropList `xml:"DAV: prop"`
+}
+
+// compFilter is a CalDAV comp-filter, restricting a calendar-query to a component
+type compFilter struct {
+	Name    string       `xml:"name,attr"`
+	Filters []compFilter `xml:"urn:ietf:params:xml:ns:caldav comp-filter"`
+}
+
+// reportBody holds the calendar-query and calendar-multiget reports, told apart by
+// the name of the root element
+type reportBody struct {
+	XMLName xml.Name
+	AllProp *struct{}    `xml:"DAV: allprop"`
+	Prop    *davPropList `xml:"DAV: prop"`
+	Hrefs   []string     `xml:"DAV: href"`
+	Filter  *compFilter  `xml:"urn:ietf:params:xml:ns:caldav filter>comp-filter"`
+}
+
+func (p *davPropList) request(all bool) davPropRequest {
+	if p == nil {
+		return davPropRequest{All: true}
+	}
+	request := davPropRequest{All: all}
+	for _, element := range p.Names {
+		request.Names = append(request.Names, element.XMLName)
+	}
+	return request
+}
+
+// readPropfind parses a PROPFIND body; an empty body asks for every property
+func readPropfind(body io.Reader) (davPropRequest, error) {
+	data, err := io.ReadAll(io.LimitReader(body, maxCalDAVBody))
+	if err != nil {
+		return davPropRequest{}, err
+	}
+	if strings.TrimSpace(string(data)) == "" {
+		return davPropRequest{All: true}, nil
+	}
+	var propfind propfindBody
+	if err := xml.Unmarshal(data, &propfind); err != nil {
+		return davPropRequest{}, err
+	}
+	return propfind.Prop.request(propfind.AllProp != nil), nil
+}
+
+// todoComponents reports whether a calendar-query filter can match VTODOs, the only
+// components this server stores. Other filters, on properties or time ranges, are
+// not applied.
+func (f *compFilter) todoComponents() bool {
+	if f == nil || len(f.Filters) == 0 {
+		return true
+	}
+	for _, filter := range f.Filters {
+		if strings.EqualFold(filter.Name, "VTODO") {
+			return true
+		}
+	}
+	return false
+}
+
+// writeMultistatus answers a PROPFIND or REPORT. Requested properties a resource does
+// not have are listed as not found; with allprop, calendar-data is left out, as CalDAV
+// requires.
+func writeMultistatus(w http.ResponseWriter, responses []davResponse, request davPropRequest) {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<multistatus xmlns="DAV:">`)
+	for _, response := range responses {
+		b.WriteString("<response><href>" + escapeXML(response.Href) + "</href>")
+		if response.Status != 0 {
+			b.WriteString(davStatus(response.Status) + "</response>")
+			continue
+		}
+
+		var found []davProperty
+		var missing []xml.Name
+		if request.All {
+			for _, property := range response.Properties {
+				if property.Name != propCalendarData {
+					found = append(found, property)
+				}
+			}
+		}
+		for _, name := range request.Names {
+			if property, ok := findProperty(response.Properties, name); ok {
+				found = append(found, property)
+			} else {
+				missing = append(missing, name)
+			}
+		}
+
+		if len(found) > 0 {
+			b.WriteString("<propstat><prop>")
+			for _, property := range found {
+				b.WriteString(davElementXML(property.Name, property.Value))
+			}
+			b.WriteString("</prop>" + davStatus(http.StatusOK) + "</propstat>")
+		}
+		if len(missing) > 0 {
+			b.WriteString("<propstat><prop>")
+			for _, name := range missing {
+				b.WriteString(davElementXML(name, ""))
+			}
+			b.WriteString("</prop>" + davStatus(http.StatusNotFound) + "</propstat>")
+		}
+		b.WriteString("</response>")
+	}
+	b.WriteString("</multistatus>")
+
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.WriteHeader(http.StatusMultiStatus)
+	w.Write([]byte(b.String()))
+}
+
+func findProperty(properties []davProperty, name xml.Name) (davProperty, bool) {
+	for _, property := range properties {
+		if property.Name == name {
+			return property, true
+		}
+	}
+	return davProperty{}, false
+}
+
+// davElementXML writes an element declaring its own namespace, so that properties of
+// any namespace can be written without prefixes
+func davElementXML(name xml.Name, value string) string {
+	open := "<" + name.Local + ` xmlns="` + escapeXML(name.Space) + `"`
+	if value == "" {
+		return open + "/>"
+	}
+	return open + ">" + value + "</" + name.Local + ">"
+}
+
+func davHref(href string) string {
+	return davElementXML(xml.Name{Space: davNamespace, Local: "href"}, escapeXML(href))
+}
+
+func davStatus(code int) string {
+	return "<status>HTTP/1.1 " + strconv.Itoa(code) + " " + http.StatusText(code) + "</status>"
+}
+
+func escapeXML(value string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(value))
+	return b.String()
+}
@@ -11,13 +11,14 @@ import (
 
 // CompleteTaskHandler godoc
 // @Summary Complete a task
-// @Description Mark a task as completed in Taskwarrior
+// @Description Mark a task as completed in Taskwarrior. Open tasks depending on it are left as they are unless cascade is set: "strip" removes the dependency from them, "cascade" completes them as well, transitively, and "refuse" fails the job with the list of dependents.
 // @Tags Tasks
 // @Accept json
 // @Produce json
 // @Param task body models.CompleteTaskRequestBody true "Task completion details"
-// @Success 202 {string} string "Task completion accepted for processing"
-// @Failure 400 {string} string "Bad request - invalid input or missing taskuuid"
+// @Param If-Match header string false "Modified timestamp of the task as last read; the job fails with a conflict if the task changed since (alternative to expectedModified in the body). Send the task as read in the body's base field to merge non-overlapping changes instead of failing."
+// @Success 202 {object} controllers.JobAcceptedResponse "Task completion accepted for processing; poll /jobs/{id} for the outcome"
+// @Failure 400 {string} string "Bad request - invalid input, missing taskuuid or invalid cascade mode"
 // @Failure 405 {string} string "Method not allowed"
 // @Router /complete-task [post]
 func CompleteTaskHandler(w http.ResponseWriter, r *http.Request) {
@@ -43,22 +44,36 @@ func CompleteTaskHandler(w http.ResponseWriter, r *http.Request) {
 		encryptionSecret := requestBody.EncryptionSecret
 		uuid := requestBody.UUID
 		taskuuid := requestBody.TaskUUID
+		precondition, mergeResult := newPrecondition(r, requestBody.ExpectedModified, requestBody.Base, func(base models.Task) models.Task {
+			proposed := base
+			proposed.Status = "completed"
+			return proposed
+		})
 
 		if taskuuid == "" {
 			http.Error(w, "taskuuid is required", http.StatusBadRequest)
 			return
 		}
 
-		// if err := tw.CompleteTaskInTaskwarrior(email, encryptionSecret, uuid, taskuuid); err != nil {
+		cascade := requestBody.Cascade
+		if !models.ValidCascade(cascade) {
+			http.Error(w, fmt.Sprintf("Invalid cascade mode '%s': expected strip, cascade or refuse", cascade), http.StatusBadRequest)
+			return
+		}
+
+		// if err := tw.CompleteTaskInTaskwarrior(email, encryptionSecret, uuid, taskuuid, cascade, precondition); err != nil {
 		// http.Error(w, err.Error(), http.StatusInternalServerError)
 		// return
 		// }
 		logStore := models.GetLogStore()
 		job := Job{
-			Name: "Complete Task",
+			Name:      "Complete Task",
+			Owner:     uuid,
+			Requested: requestedChanges(requestBody),
+			Result:    mergeResult,
 			Execute: func() error {
 				logStore.AddLog("INFO", fmt.Sprintf("Completing task UUID: %s", taskuuid), uuid, "Complete Task")
-				err := tw.CompleteTaskInTaskwarrior(email, encryptionSecret, uuid, taskuuid)
+				err := tw.CompleteTaskInTaskwarrior(email, encryptionSecret, uuid, taskuuid, cascade, precondition)
 				if err != nil {
 					logStore.AddLog("ERROR", fmt.Sprintf("Failed to complete task UUID %s: %v", taskuuid, err), uuid, "Complete Task")
 					return err
@@ -67,8 +82,7 @@ func CompleteTaskHandler(w http.ResponseWriter, r *http.Request) {
 				return nil
 			},
 		}
-		GlobalJobQueue.AddJob(job)
-		w.WriteHeader(http.StatusAccepted)
+		acceptJob(w, GlobalJobQueue.AddJob(job))
 		return
 	}
 	http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
@@ -11,13 +11,13 @@ import (
 
 // BulkCompleteTaskHandler godoc
 // @Summary Bulk complete tasks
-// @Description Mark multiple tasks as completed in Taskwarrior
+// @Description Mark multiple tasks as completed in Taskwarrior. cascade applies to the open dependents of each task as for a single completion; a refused task is reported among the failed tasks.
 // @Tags Tasks
 // @Accept json
 // @Produce json
 // @Param task body models.BulkCompleteTaskRequestBody true "Bulk task completion details"
-// @Success 202 {string} string "Bulk task completion accepted for processing"
-// @Failure 400 {string} string "Invalid request - missing or empty taskuuids"
+// @Success 202 {object} controllers.JobAcceptedResponse "Bulk task completion accepted for processing; poll /jobs/{id} for the outcome"
+// @Failure 400 {string} string "Invalid request - missing or empty taskuuids, or invalid cascade mode"
 // @Failure 405 {string} string "Method not allowed"
 // @Router /complete-tasks [post]
 func BulkCompleteTaskHandler(w http.ResponseWriter, r *http.Request) {
@@ -50,14 +50,21 @@ func BulkCompleteTaskHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	cascade := requestBody.Cascade
+	if !models.ValidCascade(cascade) {
+		http.Error(w, fmt.Sprintf("Invalid cascade mode '%s': expected strip, cascade or refuse", cascade), http.StatusBadRequest)
+		return
+	}
+
 	logStore := models.GetLogStore()
 
 	job := Job{
-		Name: "Bulk Complete Tasks",
+		Name:  "Bulk Complete Tasks",
+		Owner: uuid,
 		Execute: func() error {
 			logStore.AddLog("INFO", fmt.Sprintf("[Bulk Complete] Starting %d tasks", len(taskUUIDs)), uuid, "Bulk Complete Task")
 
-			failedTasks, err := tw.CompleteTasksInTaskwarrior(email, encryptionSecret, uuid, taskUUIDs)
+			failedTasks, err := tw.CompleteTasksInTaskwarrior(email, encryptionSecret, uuid, taskUUIDs, cascade)
 
 			for taskUUID, errMsg := range failedTasks {
 				logStore.AddLog("ERROR", fmt.Sprintf("[Bulk Complete] Failed: %s (%s)", taskUUID, errMsg), uuid, "Bulk Complete Task")
@@ -75,6 +82,5 @@ func BulkCompleteTaskHandler(w http.ResponseWriter, r *http.Request) {
 		},
 	}
 
-	GlobalJobQueue.AddJob(job)
-	w.WriteHeader(http.StatusAccepted)
+	acceptJob(w, GlobalJobQueue.AddJob(job))
 }
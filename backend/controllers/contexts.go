@@ -0,0 +1,306 @@
+package controllers
+
+import (
+	"ccsync_backend/models"
+	"ccsync_backend/utils/filter"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/sessions"
+)
+
+// ContextsResponse lists the user's contexts and the active one, empty if none is
+type ContextsResponse struct {
+	Contexts []models.Context `json:"contexts"`
+	Active   string           `json:"active"`
+}
+
+// ImportContextsResponse lists the contexts imported from a taskrc and why other
+// context settings were left out, by taskrc key
+type ImportContextsResponse struct {
+	ContextsResponse
+	Imported []string          `json:"imported"`
+	Ignored  map[string]string `json:"ignored"`
+}
+
+// ContextsHandler godoc
+// @Summary List or save contexts
+// @Description GET lists the user's contexts, named filters such as "work" for project:Work or +work, and the active one, which GET /tasks applies. POST saves a context, replacing any context with the same name.
+// @Tags Contexts
+// @Accept json
+// @Produce json
+// @Param context body models.ContextRequestBody false "Context name and filter (POST)"
+// @Success 200 {object} controllers.ContextsResponse "Contexts after the change"
+// @Failure 400 {string} string "Invalid name or filter, or too many contexts"
+// @Failure 401 {string} string "Authentication required"
+// @Failure 405 {string} string "Method not allowed"
+// @Router /contexts [get]
+// @Router /contexts [post]
+func ContextsHandler(store *sessions.CookieStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		settings := models.GetSettingsStore()
+
+		switch r.Method {
+		case http.MethodGet:
+			_, _, UUID, ok := sessionCredentials(store, r)
+			if !ok {
+				http.Error(w, "Authentication required", http.StatusUnauthorized)
+				return
+			}
+			writeContexts(w, settings.Get(UUID))
+
+		case http.MethodPost:
+			var requestBody models.ContextRequestBody
+			if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+				http.Error(w, fmt.Sprintf("error decoding request body: %v", err), http.StatusBadRequest)
+				return
+			}
+			defer r.Body.Close()
+
+			if requestBody.UUID == "" {
+				http.Error(w, "UUID is required", http.StatusBadRequest)
+				return
+			}
+			if err := models.ValidateContextName(requestBody.Name); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			if _, err := filter.Parse(requestBody.Filter); err != nil {
+				http.Error(w, fmt.Sprintf("Invalid filter: %v", err), http.StatusBadRequest)
+				return
+			}
+
+			full := false
+			updated := settings.Update(requestBody.UUID, func(s *models.UserSettings) {
+				if _, exists := s.Contexts[requestBody.Name]; !exists && len(s.Contexts) >= models.MaxContexts {
+					full = true
+					return
+				}
+				if s.Contexts == nil {
+					s.Contexts = make(map[string]string)
+				}
+				s.Contexts[requestBody.Name] = requestBody.Filter
+			})
+			if full {
+				http.Error(w, fmt.Sprintf("too many contexts (max %d)", models.MaxContexts), http.StatusBadRequest)
+				return
+			}
+			writeContexts(w, updated)
+
+		default:
+			http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+// ContextRoutesHandler dispatches /contexts/active, /contexts/import and /contexts/{name}
+func ContextRoutesHandler(store *sessions.CookieStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := strings.Trim(strings.TrimPrefix(r.URL.Path, "/contexts/"), "/")
+		switch name {
+		case "":
+			http.NotFound(w, r)
+		case "active":
+			ActiveContextHandler(w, r)
+		case "import":
+			ImportContextsHandler(w, r)
+		default:
+			DeleteContextHandler(store)(w, r)
+		}
+	}
+}
+
+// DeleteContextHandler godoc
+// @Summary Delete a context
+// @Description Delete one of the user's contexts. If it was active, no context is active any more.
+// @Tags Contexts
+// @Produce json
+// @Param name path string true "Context name"
+// @Success 200 {object} controllers.ContextsResponse "Contexts after the change"
+// @Failure 401 {string} string "Authentication required"
+// @Failure 404 {string} string "Context not found"
+// @Failure 405 {string} string "Method not allowed"
+// @Router /contexts/{name} [delete]
+func DeleteContextHandler(store *sessions.CookieStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		_, _, UUID, ok := sessionCredentials(store, r)
+		if !ok {
+			http.Error(w, "Authentication required", http.StatusUnauthorized)
+			return
+		}
+
+		name := strings.Trim(strings.TrimPrefix(r.URL.Path, "/contexts/"), "/")
+		found := false
+		updated := models.GetSettingsStore().Update(UUID, func(s *models.UserSettings) {
+			if _, found = s.Contexts[name]; !found {
+				return
+			}
+			delete(s.Contexts, name)
+			if len(s.Contexts) == 0 {
+				s.Contexts = nil
+			}
+			if s.Context == name {
+				s.Context = ""
+			}
+		})
+		if !found {
+			http.Error(w, "Context not found", http.StatusNotFound)
+			return
+		}
+		writeContexts(w, updated)
+	}
+}
+
+// ActiveContextHandler godoc
+// @Summary Set the active context
+// @Description Make one of the user's contexts the active one, applied by GET /tasks on top of its filter parameter. An empty name or "none" clears it.
+// @Tags Contexts
+// @Accept json
+// @Produce json
+// @Param context body models.ActiveContextRequestBody true "Name of the context to activate"
+// @Success 200 {object} controllers.ContextsResponse "Contexts after the change"
+// @Failure 400 {string} string "Invalid request body"
+// @Failure 404 {string} string "Context not found"
+// @Failure 405 {string} string "Method not allowed"
+// @Router /contexts/active [post]
+func ActiveContextHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var requestBody models.ActiveContextRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+		http.Error(w, fmt.Sprintf("error decoding request body: %v", err), http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	if requestBody.UUID == "" {
+		http.Error(w, "UUID is required", http.StatusBadRequest)
+		return
+	}
+
+	name := requestBody.Name
+	if name == models.NoContext {
+		name = ""
+	}
+	found := true
+	updated := models.GetSettingsStore().Update(requestBody.UUID, func(s *models.UserSettings) {
+		if _, found = s.Contexts[name]; found || name == "" {
+			found = true
+			s.Context = name
+		}
+	})
+	if !found {
+		http.Error(w, "Context not found", http.StatusNotFound)
+		return
+	}
+	writeContexts(w, updated)
+}
+
+// ImportContextsHandler godoc
+// @Summary Import contexts from a taskrc
+// @Description Import the contexts defined in a pasted taskrc, as context.<name>=<filter> or context.<name>.read=<filter>, replacing contexts with the same name. A context=<name> line makes the imported context active. Write filters, invalid names and filters this API cannot parse are skipped and listed in ignored.
+// @Tags Contexts
+// @Accept json
+// @Produce json
+// @Param taskrc body models.ImportContextsRequestBody true "taskrc content"
+// @Success 200 {object} controllers.ImportContextsResponse "Imported contexts and ignored settings"
+// @Failure 400 {string} string "Invalid request body"
+// @Failure 405 {string} string "Method not allowed"
+// @Router /contexts/import [post]
+func ImportContextsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var requestBody models.ImportContextsRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+		http.Error(w, fmt.Sprintf("error decoding request body: %v", err), http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	if requestBody.UUID == "" {
+		http.Error(w, "UUID is required", http.StatusBadRequest)
+		return
+	}
+
+	parsed := models.ParseTaskrcContexts(requestBody.Taskrc)
+	valid := make([]models.Context, 0, len(parsed.Contexts))
+	for _, context := range parsed.Contexts {
+		if _, err := filter.Parse(context.Filter); err != nil {
+			parsed.Ignored["context."+context.Name] = fmt.Sprintf("invalid filter: %v", err)
+			continue
+		}
+		valid = append(valid, context)
+	}
+
+	response := ImportContextsResponse{Imported: []string{}, Ignored: parsed.Ignored}
+	updated := models.GetSettingsStore().Update(requestBody.UUID, func(s *models.UserSettings) {
+		for _, context := range valid {
+			if _, exists := s.Contexts[context.Name]; !exists && len(s.Contexts) >= models.MaxContexts {
+				response.Ignored["context."+context.Name] = fmt.Sprintf("too many contexts (max %d)", models.MaxContexts)
+				continue
+			}
+			if s.Contexts == nil {
+				s.Contexts = make(map[string]string)
+			}
+			s.Contexts[context.Name] = context.Filter
+			response.Imported = append(response.Imported, context.Name)
+			if context.Name == parsed.Active {
+				s.Context = context.Name
+			}
+		}
+	})
+
+	response.ContextsResponse = contextsResponse(updated)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// activeContextFilter returns the filter of the context a task listing applies: the
+// one named by the context query parameter ("none" for no context), else the user's
+// active context. The name is empty when no context applies.
+func activeContextFilter(r *http.Request, userUUID string) (string, *filter.Filter, error) {
+	settings := models.GetSettingsStore().Get(userUUID)
+
+	name := settings.Context
+	if r.URL.Query().Has("context") {
+		name = r.URL.Query().Get("context")
+	}
+	if name == "" || name == models.NoContext {
+		return "", nil, nil
+	}
+
+	source, ok := settings.Contexts[name]
+	if !ok {
+		return "", nil, fmt.Errorf("Unknown context '%s'", name)
+	}
+	contextFilter, err := filter.Parse(source)
+	if err != nil {
+		return "", nil, fmt.Errorf("Invalid filter in context '%s': %v", name, err)
+	}
+	return name, contextFilter, nil
+}
+
+func contextsResponse(settings models.UserSettings) ContextsResponse {
+	return ContextsResponse{
+		Contexts: models.SortedContexts(settings.Contexts),
+		Active:   settings.Context,
+	}
+}
+
+func writeContexts(w http.ResponseWriter, settings models.UserSettings) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(contextsResponse(settings))
+}
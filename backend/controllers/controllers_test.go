@@ -2,12 +2,15 @@ package controllers
 
 import (
 	"bytes"
+	"ccsync_backend/models"
+	"ccsync_backend/utils/reports"
 	"encoding/gob"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/gorilla/sessions"
 	"github.com/joho/godotenv"
@@ -37,6 +40,26 @@ func setup() *App {
 	return &App{Config: conf, SessionStore: store}
 }
 
+// testSessionStore stands in for the store of main.go in handlers reading the session
+var testSessionStore = sessions.NewCookieStore([]byte("test-session-key"))
+
+// withSession signs the request in as the given user
+func withSession(req *http.Request, uuid string) *http.Request {
+	gob.Register(map[string]interface{}{})
+	session, _ := testSessionStore.New(req, "session-name")
+	session.Values["user"] = map[string]interface{}{
+		"email":             uuid + "@example.com",
+		"uuid":              uuid,
+		"encryption_secret": "secret",
+	}
+	rr := httptest.NewRecorder()
+	session.Save(req, rr)
+	for _, cookie := range rr.Result().Cookies() {
+		req.AddCookie(cookie)
+	}
+	return req
+}
+
 func Test_OAuthHandler(t *testing.T) {
 	app := setup()
 	req, err := http.NewRequest("GET", "/auth/oauth", nil)
@@ -365,3 +388,515 @@ func Test_AddTaskHandler_InvalidMethod(t *testing.T) {
 	assert.Equal(t, http.StatusMethodNotAllowed, rr.Code)
 	assert.Contains(t, rr.Body.String(), "Invalid request method")
 }
+
+func Test_ApplyTagOps(t *testing.T) {
+	assert.Equal(t, []string{"work", "urgent"}, applyTagOps([]string{"work", "someday"}, []string{"+urgent", "-someday", "work"}))
+	assert.Equal(t, []string{"a"}, applyTagOps(nil, []string{"a", "+a"}))
+}
+
+func Test_ExportDate(t *testing.T) {
+	assert.Equal(t, "20251201T183000Z", exportDate("2025-12-01T18:30:00"))
+	assert.Equal(t, "20251201T000000Z", exportDate("2025-12-01"))
+	assert.Equal(t, "20251201T183000Z", exportDate("20251201T183000Z"))
+	assert.Equal(t, "", exportDate(""))
+}
+
+func Test_NewPrecondition_WithBase(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/complete-task", nil)
+	base := &models.Task{UUID: "t1", Status: "pending", Modified: "20250301T120000Z"}
+
+	precondition, result := newPrecondition(req, "", base, func(base models.Task) models.Task {
+		base.Status = "completed"
+		return base
+	})
+	assert.Equal(t, "20250301T120000Z", precondition.ExpectedModified)
+	assert.Equal(t, "completed", precondition.Proposed.Status)
+	assert.Equal(t, "pending", base.Status)
+	assert.Nil(t, result())
+
+	precondition.OnMerge(models.MergeResult{Applied: []string{"status"}})
+	assert.Equal(t, &models.MergeResult{Applied: []string{"status"}}, result())
+}
+
+func Test_NewPrecondition_IfMatchHeader(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/complete-task", nil)
+	req.Header.Set("If-Match", `"20250301T120000Z"`)
+
+	precondition, result := newPrecondition(req, "", nil, nil)
+	assert.Equal(t, `"20250301T120000Z"`, precondition.ExpectedModified)
+	assert.Nil(t, precondition.Base)
+	assert.Nil(t, result)
+}
+
+func Test_NormalizeBatchDates(t *testing.T) {
+	now := time.Date(2025, 11, 27, 10, 0, 0, 0, time.UTC)
+	operations, err := normalizeBatchDates([]models.BatchOperation{
+		{Op: models.BatchOpAdd, Description: "a", Due: "2025-12-01T18:30:00.000Z", Start: "2025-11-30"},
+		{Op: models.BatchOpEdit, TaskUUID: "t1", Due: "2025-12-01", Wait: "2025-11-30T10:00:00Z"},
+		{Op: models.BatchOpModify, TaskUUID: "t1", Due: "2025-12-01"},
+		{Op: models.BatchOpModify, TaskUUID: "t2", Due: "tomorrow 9am"},
+	}, now)
+	assert.NoError(t, err)
+	assert.Equal(t, "20251201T183000Z", operations[0].Due)
+	assert.Equal(t, "20251130T000000Z", operations[0].Start)
+	assert.Equal(t, "20251201T000000Z", operations[1].Due)
+	assert.Equal(t, "20251130T100000Z", operations[1].Wait)
+	assert.Equal(t, "20251201T000000Z", operations[2].Due)
+	assert.Equal(t, "20251128T090000Z", operations[3].Due)
+
+	// dates without an offset are read in the location of now
+	kolkata, _ := time.LoadLocation("Asia/Kolkata")
+	operations, err = normalizeBatchDates([]models.BatchOperation{{Op: models.BatchOpAdd, Description: "a", Due: "2025-12-01"}}, now.In(kolkata))
+	assert.NoError(t, err)
+	assert.Equal(t, "20251130T183000Z", operations[0].Due)
+
+	_, err = normalizeBatchDates([]models.BatchOperation{{Op: models.BatchOpAdd, Description: "a", Wait: "whenever"}}, now)
+	assert.ErrorContains(t, err, "operation 0: invalid wait date format")
+}
+
+func Test_ValidateBulkPatch(t *testing.T) {
+	empty, high, unknown := "", "H", "urgent"
+	assert.ErrorContains(t, validateBulkPatch(models.BulkTaskPatch{}), "cannot be empty")
+	assert.NoError(t, validateBulkPatch(models.BulkTaskPatch{Project: &empty}))
+	assert.NoError(t, validateBulkPatch(models.BulkTaskPatch{Priority: &high, AddTags: []string{"sprint"}}))
+	assert.ErrorContains(t, validateBulkPatch(models.BulkTaskPatch{Priority: &unknown}), "invalid priority")
+	assert.NoError(t, validateBulkPatch(models.BulkTaskPatch{DueShift: "-1wk"}))
+	assert.ErrorContains(t, validateBulkPatch(models.BulkTaskPatch{DueShift: "soon"}), "invalid dueShift")
+	assert.ErrorContains(t, validateBulkPatch(models.BulkTaskPatch{DueShift: "+0d"}), "no length")
+}
+
+func Test_TrashedTasks(t *testing.T) {
+	trash := trashedTasks([]models.Task{
+		{UUID: "a", Status: "deleted", End: "20250301T120000Z"},
+		{UUID: "b", Status: "pending"},
+		{UUID: "c", Status: "deleted", End: "20250305T080000Z"},
+		{UUID: "d", Status: "completed", End: "20250306T080000Z"},
+	})
+	assert.Len(t, trash, 2)
+	assert.Equal(t, "c", trash[0].UUID)
+	assert.Equal(t, "20250305T080000Z", trash[0].DeletedAt)
+	assert.Equal(t, "a", trash[1].UUID)
+}
+
+func Test_PurgeTrashHandler_RequiresSelection(t *testing.T) {
+	for _, body := range []string{`{}`, `{"taskuuids":["a"],"olderThan":"30d"}`, `{"olderThan":"soon"}`} {
+		req := httptest.NewRequest(http.MethodPost, "/trash/purge", bytes.NewBufferString(body))
+		rr := httptest.NewRecorder()
+		PurgeTrashHandler(rr, req)
+		assert.Equal(t, http.StatusBadRequest, rr.Code, body)
+	}
+}
+
+func Test_BuildDependencyGraph(t *testing.T) {
+	graph := buildDependencyGraph([]models.Task{
+		{UUID: "a", Description: "design", Status: "completed"},
+		{UUID: "b", Description: `build "v2"`, Status: "pending", Depends: []string{"a", "gone"}},
+		{UUID: "c", Description: "ship", Status: "pending", Depends: []string{"b"}},
+		{UUID: "d", Description: "unrelated", Status: "completed"},
+	}, "c")
+
+	uuids := []string{}
+	for _, node := range graph.Nodes {
+		uuids = append(uuids, node.UUID)
+	}
+	assert.Equal(t, []string{"b", "c", "a"}, uuids)
+	assert.Equal(t, []GraphEdge{{Task: "b", DependsOn: "a"}, {Task: "c", DependsOn: "b"}}, graph.Edges)
+	assert.Len(t, graph.Dangling, 1)
+	assert.Equal(t, []string{"b", "c"}, graph.CriticalPath)
+	assert.True(t, graph.Nodes[1].Blocked)
+	assert.True(t, graph.Nodes[0].Blocking)
+
+	dot := graph.DOT()
+	assert.Contains(t, dot, `"b" [label="build \"v2\"", style=bold];`)
+	assert.Contains(t, dot, `"b" -> "c" [style=bold];`)
+	assert.Contains(t, dot, `"a" [label="design", style=dashed];`)
+}
+
+func Test_RequestLocation(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/add-task", nil)
+	loc, err := requestLocation(req, "tz-user")
+	assert.NoError(t, err)
+	assert.Equal(t, time.UTC, loc)
+
+	models.GetSettingsStore().Update("tz-user", func(s *models.UserSettings) { s.Timezone = "America/New_York" })
+	loc, err = requestLocation(req, "tz-user")
+	assert.NoError(t, err)
+	assert.Equal(t, "America/New_York", loc.String(), "the saved timezone applies by default")
+
+	req.Header.Set("X-Timezone", "Asia/Kolkata")
+	loc, err = requestLocation(req, "tz-user")
+	assert.NoError(t, err)
+	assert.Equal(t, "Asia/Kolkata", loc.String())
+
+	req.Header.Set("X-Timezone", "Mars/Olympus")
+	_, err = requestLocation(req, "tz-user")
+	assert.ErrorContains(t, err, "Invalid X-Timezone")
+}
+
+func Test_TimezoneSettingsHandler(t *testing.T) {
+	body := bytes.NewBufferString(`{"UUID": "settings-user", "timezone": "Europe/Berlin"}`)
+	rr := httptest.NewRecorder()
+	TimezoneSettingsHandler(testSessionStore)(rr, httptest.NewRequest(http.MethodPost, "/settings/timezone", body))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"timezone": "Europe/Berlin"}`, rr.Body.String())
+
+	// the user is the session's, whatever the headers say
+	req := httptest.NewRequest(http.MethodGet, "/settings/timezone", nil)
+	req.Header.Set("X-User-UUID", "settings-user")
+	rr = httptest.NewRecorder()
+	TimezoneSettingsHandler(testSessionStore)(rr, req)
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+
+	rr = httptest.NewRecorder()
+	TimezoneSettingsHandler(testSessionStore)(rr, withSession(httptest.NewRequest(http.MethodGet, "/settings/timezone", nil), "settings-user"))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"timezone": "Europe/Berlin"}`, rr.Body.String())
+
+	body = bytes.NewBufferString(`{"UUID": "settings-user", "timezone": "Mars/Olympus"}`)
+	rr = httptest.NewRecorder()
+	TimezoneSettingsHandler(testSessionStore)(rr, httptest.NewRequest(http.MethodPost, "/settings/timezone", body))
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func Test_UrgencySettingsHandler(t *testing.T) {
+	body := bytes.NewBufferString(`{"UUID": "urgency-user", "coefficients": {"due": 20, "user.tag.urgent": 5}}`)
+	rr := httptest.NewRecorder()
+	UrgencySettingsHandler(testSessionStore)(rr, httptest.NewRequest(http.MethodPost, "/settings/urgency", body))
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response UrgencySettingsResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal(t, map[string]float64{"due": 20, "user.tag.urgent": 5}, response.Overrides)
+	assert.Equal(t, 20.0, response.Coefficients["due"])
+	assert.Equal(t, 8.0, response.Coefficients["blocking"])
+	assert.Equal(t, 20.0, userUrgencyCoefficients("urgency-user")["due"])
+
+	// the user is the session's, whatever the headers say
+	req := httptest.NewRequest(http.MethodGet, "/settings/urgency", nil)
+	req.Header.Set("X-User-UUID", "urgency-user")
+	rr = httptest.NewRecorder()
+	UrgencySettingsHandler(testSessionStore)(rr, req)
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+
+	rr = httptest.NewRecorder()
+	UrgencySettingsHandler(testSessionStore)(rr, withSession(httptest.NewRequest(http.MethodGet, "/settings/urgency", nil), "other-user"))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.NotContains(t, rr.Body.String(), "user.tag.urgent")
+
+	rr = httptest.NewRecorder()
+	UrgencySettingsHandler(testSessionStore)(rr, withSession(httptest.NewRequest(http.MethodGet, "/settings/urgency", nil), "urgency-user"))
+	assert.Contains(t, rr.Body.String(), "user.tag.urgent")
+
+	req = httptest.NewRequest(http.MethodGet, "/tasks/t1/urgency", nil)
+	req.Header.Set("X-User-UUID", "urgency-user")
+	rr = httptest.NewRecorder()
+	TaskRoutesHandler(testSessionStore)(rr, req)
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+
+	body = bytes.NewBufferString(`{"UUID": "urgency-user", "coefficients": {"dues": 1}}`)
+	rr = httptest.NewRecorder()
+	UrgencySettingsHandler(testSessionStore)(rr, httptest.NewRequest(http.MethodPost, "/settings/urgency", body))
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	body = bytes.NewBufferString(`{"UUID": "urgency-user", "coefficients": {}}`)
+	rr = httptest.NewRecorder()
+	UrgencySettingsHandler(testSessionStore)(rr, httptest.NewRequest(http.MethodPost, "/settings/urgency", body))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, 12.0, userUrgencyCoefficients("urgency-user")["due"])
+}
+
+func Test_TaskrcSettingsHandler(t *testing.T) {
+	body := bytes.NewBufferString(`{"UUID": "taskrc-user", "settings": {"weekstart": "monday", "uda.estimate.type": "duration"}}`)
+	rr := httptest.NewRecorder()
+	TaskrcSettingsHandler(testSessionStore)(rr, httptest.NewRequest(http.MethodPost, "/settings/taskrc", body))
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	// the user is the session's, whatever the headers say
+	req := httptest.NewRequest(http.MethodGet, "/settings/taskrc", nil)
+	req.Header.Set("X-User-UUID", "taskrc-user")
+	rr = httptest.NewRecorder()
+	TaskrcSettingsHandler(testSessionStore)(rr, req)
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+
+	rr = httptest.NewRecorder()
+	TaskrcSettingsHandler(testSessionStore)(rr, withSession(httptest.NewRequest(http.MethodGet, "/settings/taskrc", nil), "taskrc-user"))
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response TaskrcSettingsResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal(t, map[string]string{"weekstart": "monday", "uda.estimate.type": "duration"}, response.Settings)
+
+	body = bytes.NewBufferString(`{"UUID": "taskrc-user", "settings": {"sync.server.url": "http://example.com"}}`)
+	rr = httptest.NewRecorder()
+	TaskrcSettingsHandler(testSessionStore)(rr, httptest.NewRequest(http.MethodPost, "/settings/taskrc", body))
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Len(t, models.GetSettingsStore().Get("taskrc-user").Taskrc, 2)
+
+	body = bytes.NewBufferString(`{"UUID": "taskrc-user", "settings": {}}`)
+	rr = httptest.NewRecorder()
+	TaskrcSettingsHandler(testSessionStore)(rr, httptest.NewRequest(http.MethodPost, "/settings/taskrc", body))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Nil(t, models.GetSettingsStore().Get("taskrc-user").Taskrc)
+}
+
+func Test_ContextsHandlers(t *testing.T) {
+	post := func(handler http.HandlerFunc, path, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		handler(rr, httptest.NewRequest(http.MethodPost, path, bytes.NewBufferString(body)))
+		return rr
+	}
+
+	rr := post(ContextsHandler(testSessionStore), "/contexts", `{"UUID": "contexts-user", "name": "work", "filter": "project:Work or +work"}`)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, http.StatusBadRequest, post(ContextsHandler(testSessionStore), "/contexts", `{"UUID": "contexts-user", "name": "home", "filter": "(project:Home"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, post(ContextsHandler(testSessionStore), "/contexts", `{"UUID": "contexts-user", "name": "none", "filter": "+home"}`).Code)
+
+	rr = post(ImportContextsHandler, "/contexts/import", `{"UUID": "contexts-user", "taskrc": "context.home=project:Home\ncontext.broken=(+x\ncontext=home"}`)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var imported ImportContextsResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &imported))
+	assert.Equal(t, []string{"home"}, imported.Imported)
+	assert.Contains(t, imported.Ignored, "context.broken")
+	assert.Equal(t, "home", imported.Active)
+	assert.Len(t, imported.Contexts, 2)
+
+	req := httptest.NewRequest(http.MethodGet, "/tasks", nil)
+	name, contextFilter, err := activeContextFilter(req, "contexts-user")
+	assert.NoError(t, err)
+	assert.Equal(t, "home", name)
+	assert.Equal(t, "project:Home", contextFilter.String())
+
+	name, contextFilter, err = activeContextFilter(httptest.NewRequest(http.MethodGet, "/tasks?context=none", nil), "contexts-user")
+	assert.NoError(t, err)
+	assert.Equal(t, "", name)
+	assert.Nil(t, contextFilter)
+
+	_, _, err = activeContextFilter(httptest.NewRequest(http.MethodGet, "/tasks?context=missing", nil), "contexts-user")
+	assert.Error(t, err)
+
+	assert.Equal(t, http.StatusNotFound, post(ActiveContextHandler, "/contexts/active", `{"UUID": "contexts-user", "name": "missing"}`).Code)
+	assert.Equal(t, http.StatusOK, post(ActiveContextHandler, "/contexts/active", `{"UUID": "contexts-user", "name": "work"}`).Code)
+
+	// the user is the session's, whatever the headers say
+	req = httptest.NewRequest(http.MethodDelete, "/contexts/work", nil)
+	req.Header.Set("X-User-UUID", "contexts-user")
+	rr = httptest.NewRecorder()
+	ContextRoutesHandler(testSessionStore)(rr, req)
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+
+	rr = httptest.NewRecorder()
+	ContextRoutesHandler(testSessionStore)(rr, withSession(httptest.NewRequest(http.MethodDelete, "/contexts/work", nil), "other-user"))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	rr = httptest.NewRecorder()
+	ContextsHandler(testSessionStore)(rr, withSession(httptest.NewRequest(http.MethodGet, "/contexts", nil), "contexts-user"))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"active":"work"`)
+
+	rr = httptest.NewRecorder()
+	ContextRoutesHandler(testSessionStore)(rr, withSession(httptest.NewRequest(http.MethodDelete, "/contexts/work", nil), "contexts-user"))
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var contexts ContextsResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &contexts))
+	assert.Equal(t, []models.Context{{Name: "home", Filter: "project:Home"}}, contexts.Contexts)
+	assert.Equal(t, "", contexts.Active)
+}
+
+func Test_ReportBuckets(t *testing.T) {
+	now := time.Date(2025, 3, 14, 10, 0, 0, 0, time.UTC)
+
+	period, buckets, err := reportBuckets(httptest.NewRequest(http.MethodGet, "/reports/history?period=monthly", nil), now, time.UTC)
+	assert.NoError(t, err)
+	assert.Equal(t, reports.Monthly, period)
+	assert.Len(t, buckets, 12)
+	assert.Equal(t, "2024-04", buckets[0].Label)
+	assert.Equal(t, "2025-03", buckets[11].Label)
+
+	_, buckets, err = reportBuckets(httptest.NewRequest(http.MethodGet, "/reports/burndown?from=2025-03-01&to=2025-03-02", nil), now, time.UTC)
+	assert.NoError(t, err)
+	assert.Len(t, buckets, 2)
+
+	_, _, err = reportBuckets(httptest.NewRequest(http.MethodGet, "/reports/burndown?period=hourly", nil), now, time.UTC)
+	assert.Error(t, err)
+}
+
+func Test_FeedTokensHandlers(t *testing.T) {
+	body := bytes.NewBufferString(`{"email": "feed@example.com", "encryptionSecret": "secret", "UUID": "feed-user", "name": "phone"}`)
+	rr := httptest.NewRecorder()
+	FeedTokensHandler(testSessionStore)(rr, httptest.NewRequest(http.MethodPost, "/feeds/tokens", body))
+	assert.Equal(t, http.StatusCreated, rr.Code)
+
+	var created FeedTokenResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &created))
+	assert.Equal(t, "/feeds/ics/"+created.Token+".ics", created.Path)
+	assert.Equal(t, "phone", created.Name)
+
+	rr = httptest.NewRecorder()
+	CalendarFeedHandler(rr, httptest.NewRequest(http.MethodGet, created.Path+"?components=all", nil))
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	// the user is the session's, whatever the headers say
+	req := httptest.NewRequest(http.MethodGet, "/feeds/tokens", nil)
+	req.Header.Set("X-User-UUID", "feed-user")
+	rr = httptest.NewRecorder()
+	FeedTokensHandler(testSessionStore)(rr, req)
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+
+	rr = httptest.NewRecorder()
+	FeedTokensHandler(testSessionStore)(rr, withSession(httptest.NewRequest(http.MethodGet, "/feeds/tokens", nil), "other-user"))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `[]`, rr.Body.String())
+
+	rr = httptest.NewRecorder()
+	RevokeFeedTokenHandler(testSessionStore)(rr, withSession(httptest.NewRequest(http.MethodDelete, "/feeds/tokens/"+created.ID, nil), "other-user"))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	rr = httptest.NewRecorder()
+	FeedTokensHandler(testSessionStore)(rr, withSession(httptest.NewRequest(http.MethodGet, "/feeds/tokens", nil), "feed-user"))
+	var listed []models.FeedToken
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &listed))
+	assert.Len(t, listed, 1)
+
+	rr = httptest.NewRecorder()
+	RevokeFeedTokenHandler(testSessionStore)(rr, withSession(httptest.NewRequest(http.MethodDelete, "/feeds/tokens/"+created.ID, nil), "feed-user"))
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+
+	rr = httptest.NewRecorder()
+	CalendarFeedHandler(rr, httptest.NewRequest(http.MethodGet, created.Path, nil))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	body = bytes.NewBufferString(`{"email": "feed@example.com", "encryptionSecret": "secret", "UUID": "feed-user", "scope": "caldav"}`)
+	rr = httptest.NewRecorder()
+	FeedTokensHandler(testSessionStore)(rr, httptest.NewRequest(http.MethodPost, "/feeds/tokens", body))
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &created))
+	assert.Equal(t, "/caldav/", created.Path)
+	assert.Equal(t, models.FeedScopeCalDAV, created.Scope)
+
+	body = bytes.NewBufferString(`{"email": "feed@example.com", "encryptionSecret": "secret", "UUID": "feed-user", "scope": "admin"}`)
+	rr = httptest.NewRecorder()
+	FeedTokensHandler(testSessionStore)(rr, httptest.NewRequest(http.MethodPost, "/feeds/tokens", body))
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func Test_FeedTasks(t *testing.T) {
+	tasks := []models.Task{
+		{UUID: "a", Project: "Home", Tags: []string{"bills", "monthly"}},
+		{UUID: "b", Project: "Home.Garden", Tags: []string{"bills"}},
+		{UUID: "c", Project: "Homework", Tags: []string{"bills"}},
+		{UUID: "d", Project: "Home"},
+	}
+	selected := feedTasks(tasks, "Home", []string{"bills"})
+	assert.Len(t, selected, 2)
+	assert.Equal(t, "a", selected[0].UUID)
+	assert.Equal(t, "b", selected[1].UUID)
+	assert.Len(t, feedTasks(tasks, "", []string{"bills", "monthly"}), 1)
+}
+
+func Test_CalDAVHandler(t *testing.T) {
+	rr := httptest.NewRecorder()
+	CalDAVHandler(rr, httptest.NewRequest(http.MethodOptions, "/caldav/tasks/", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Header().Get("DAV"), "calendar-access")
+	assert.Contains(t, rr.Header().Get("Allow"), "REPORT")
+
+	rr = httptest.NewRecorder()
+	CalDAVHandler(rr, httptest.NewRequest("PROPFIND", "/caldav/", nil))
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	assert.Equal(t, `Basic realm="ccsync"`, rr.Header().Get("WWW-Authenticate"))
+
+	// ics tokens cannot be used as CalDAV passwords
+	_, icsToken, err := models.GetFeedTokenStore().Create(models.FeedCredentials{UUID: "caldav-user"}, "", models.FeedScopeICS)
+	assert.NoError(t, err)
+	req := httptest.NewRequest("PROPFIND", "/caldav/", nil)
+	req.SetBasicAuth("anyone", icsToken)
+	rr = httptest.NewRecorder()
+	CalDAVHandler(rr, req)
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+
+	_, token, err := models.GetFeedTokenStore().Create(models.FeedCredentials{UUID: "caldav-user"}, "", models.FeedScopeCalDAV)
+	assert.NoError(t, err)
+	body := `<?xml version="1.0"?><D:propfind xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav"><D:prop><D:current-user-principal/><C:calendar-home-set/><D:getlastmodified/></D:prop></D:propfind>`
+	req = httptest.NewRequest("PROPFIND", "/caldav/", bytes.NewBufferString(body))
+	req.Header.Set("Depth", "0")
+	req.SetBasicAuth("anyone", token)
+	rr = httptest.NewRecorder()
+	CalDAVHandler(rr, req)
+	assert.Equal(t, http.StatusMultiStatus, rr.Code)
+	assert.Contains(t, rr.Body.String(), `<calendar-home-set xmlns="urn:ietf:params:xml:ns:caldav"><href xmlns="DAV:">/caldav/</href></calendar-home-set>`)
+	assert.Contains(t, rr.Body.String(), `<getlastmodified xmlns="DAV:"/></prop><status>HTTP/1.1 404 Not Found</status>`)
+
+	req = httptest.NewRequest(http.MethodPut, "/caldav/tasks/x.ics", bytes.NewBufferString("BEGIN:VCALENDAR\r\nEND:VCALENDAR\r\n"))
+	req.SetBasicAuth("anyone", token)
+	rr = httptest.NewRecorder()
+	CalDAVHandler(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func Test_CalDAVTasks(t *testing.T) {
+	now := time.Date(2025, 3, 14, 10, 0, 0, 0, time.UTC)
+	tasks := []models.Task{
+		{UUID: "a", Status: "pending"},
+		{UUID: "b", Status: "recurring"},
+		{UUID: "c", Status: "completed", End: "20250301T000000Z"},
+		{UUID: "d", Status: "completed", End: "20250101T000000Z"},
+		{UUID: "e", Status: "deleted"},
+	}
+	visible := caldavTasks(tasks, now)
+	assert.Len(t, visible, 2)
+	assert.Equal(t, "a", visible[0].UUID)
+	assert.Equal(t, "c", visible[1].UUID)
+
+	assert.Equal(t, "11111111-2222-3333-4444-555555555555", caldavTaskUUID("11111111-2222-3333-4444-555555555555.ics"))
+	assert.Equal(t, caldavTaskUUID("client-name.ics"), caldavTaskUUID("client-name.ics"))
+}
+
+func Test_ImportHandler(t *testing.T) {
+	cases := []struct {
+		body    string
+		message string
+	}{
+		{`{"format": "xml", "data": "<tasks/>"}`, "Invalid format 'xml'"},
+		{`{"format": "csv", "data": "Title\nBuy milk\n"}`, "no column is mapped to description"},
+		{`{"format": "todotxt", "data": "(A)\nBuy milk due:soon\n"}`, "2 entries cannot be imported"},
+		{`{"format": "todotxt", "data": "\n"}`, "no tasks to import"},
+	}
+	for _, c := range cases {
+		rr := httptest.NewRecorder()
+		ImportHandler(rr, httptest.NewRequest(http.MethodPost, "/import", bytes.NewBufferString(c.body)))
+		assert.Equal(t, http.StatusBadRequest, rr.Code, c.body)
+		assert.Contains(t, rr.Body.String(), c.message)
+	}
+
+	rr := httptest.NewRecorder()
+	ImportHandler(rr, httptest.NewRequest(http.MethodGet, "/import", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rr.Code)
+}
+
+func Test_ImportErrorsMessage(t *testing.T) {
+	var errs []models.ImportError
+	for line := 1; line <= 7; line++ {
+		errs = append(errs, models.ImportError{Line: line, Message: "missing description"})
+	}
+	message := importErrorsMessage(errs)
+	assert.Contains(t, message, "7 entries cannot be imported")
+	assert.Contains(t, message, "line 5: missing description; and 2 more")
+	assert.NotContains(t, message, "line 6")
+}
+
+func Test_DeleteAnnotationHandler(t *testing.T) {
+	// the credentials are the session's, whatever the headers say
+	req := httptest.NewRequest(http.MethodDelete, "/tasks/t1/annotations/20240101T120000Z", nil)
+	req.Header.Set("X-User-Email", "owner@example.com")
+	req.Header.Set("X-Encryption-Secret", "secret")
+	req.Header.Set("X-User-UUID", "owner")
+	rr := httptest.NewRecorder()
+	TaskRoutesHandler(testSessionStore)(rr, req)
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+
+	GlobalJobQueue = NewJobQueue()
+	rr = httptest.NewRecorder()
+	TaskRoutesHandler(testSessionStore)(rr, withSession(httptest.NewRequest(http.MethodDelete, "/tasks/t1/annotations/20240101T120000Z", nil), "owner"))
+	assert.Equal(t, http.StatusAccepted, rr.Code)
+}
@@ -11,13 +11,14 @@ import (
 
 // DeleteTaskHandler godoc
 // @Summary Delete a task
-// @Description Delete a task from Taskwarrior
+// @Description Delete a task from Taskwarrior. Open tasks depending on it are left as they are unless cascade is set: "strip" removes the dependency from them, "cascade" deletes them as well, transitively, and "refuse" fails the job with the list of dependents.
 // @Tags Tasks
 // @Accept json
 // @Produce json
 // @Param task body models.DeleteTaskRequestBody true "Task deletion details"
-// @Success 202 {string} string "Task deletion accepted for processing"
-// @Failure 400 {string} string "Bad request - invalid input or missing taskuuid"
+// @Param If-Match header string false "Modified timestamp of the task as last read; the job fails with a conflict if the task changed since (alternative to expectedModified in the body). Send the task as read in the body's base field to merge non-overlapping changes instead of failing."
+// @Success 202 {object} controllers.JobAcceptedResponse "Task deletion accepted for processing; poll /jobs/{id} for the outcome"
+// @Failure 400 {string} string "Bad request - invalid input, missing taskuuid or invalid cascade mode"
 // @Failure 405 {string} string "Method not allowed"
 // @Router /delete-task [post]
 func DeleteTaskHandler(w http.ResponseWriter, r *http.Request) {
@@ -41,18 +42,32 @@ func DeleteTaskHandler(w http.ResponseWriter, r *http.Request) {
 		encryptionSecret := requestBody.EncryptionSecret
 		uuid := requestBody.UUID
 		taskuuid := requestBody.TaskUUID
+		precondition, mergeResult := newPrecondition(r, requestBody.ExpectedModified, requestBody.Base, func(base models.Task) models.Task {
+			proposed := base
+			proposed.Status = "deleted"
+			return proposed
+		})
 
 		if taskuuid == "" {
 			http.Error(w, "taskuuid is required", http.StatusBadRequest)
 			return
 		}
 
+		cascade := requestBody.Cascade
+		if !models.ValidCascade(cascade) {
+			http.Error(w, fmt.Sprintf("Invalid cascade mode '%s': expected strip, cascade or refuse", cascade), http.StatusBadRequest)
+			return
+		}
+
 		logStore := models.GetLogStore()
 		job := Job{
-			Name: "Delete Task",
+			Name:      "Delete Task",
+			Owner:     uuid,
+			Requested: requestedChanges(requestBody),
+			Result:    mergeResult,
 			Execute: func() error {
 				logStore.AddLog("INFO", fmt.Sprintf("Deleting task UUID: %s", taskuuid), uuid, "Delete Task")
-				err := tw.DeleteTaskInTaskwarrior(email, encryptionSecret, uuid, taskuuid)
+				err := tw.DeleteTaskInTaskwarrior(email, encryptionSecret, uuid, taskuuid, cascade, precondition)
 				if err != nil {
 					logStore.AddLog("ERROR", fmt.Sprintf("Failed to delete task UUID %s: %v", taskuuid, err), uuid, "Delete Task")
 					return err
@@ -61,8 +76,7 @@ func DeleteTaskHandler(w http.ResponseWriter, r *http.Request) {
 				return nil
 			},
 		}
-		GlobalJobQueue.AddJob(job)
-		w.WriteHeader(http.StatusAccepted)
+		acceptJob(w, GlobalJobQueue.AddJob(job))
 		return
 	}
 	http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
@@ -11,13 +11,13 @@ import (
 
 // BulkDeleteTaskHandler godoc
 // @Summary Bulk delete tasks
-// @Description Delete multiple tasks in Taskwarrior
+// @Description Delete multiple tasks in Taskwarrior. cascade applies to the open dependents of each task as for a single deletion; a refused task is reported among the failed tasks.
 // @Tags Tasks
 // @Accept json
 // @Produce json
 // @Param task body models.BulkDeleteTaskRequestBody true "Bulk task deletion details"
-// @Success 202 {string} string "Bulk task deletion accepted for processing"
-// @Failure 400 {string} string "Invalid request - missing or empty taskuuids"
+// @Success 202 {object} controllers.JobAcceptedResponse "Bulk task deletion accepted for processing; poll /jobs/{id} for the outcome"
+// @Failure 400 {string} string "Invalid request - missing or empty taskuuids, or invalid cascade mode"
 // @Failure 405 {string} string "Method not allowed"
 // @Router /delete-tasks [post]
 func BulkDeleteTaskHandler(w http.ResponseWriter, r *http.Request) {
@@ -50,14 +50,21 @@ func BulkDeleteTaskHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	cascade := requestBody.Cascade
+	if !models.ValidCascade(cascade) {
+		http.Error(w, fmt.Sprintf("Invalid cascade mode '%s': expected strip, cascade or refuse", cascade), http.StatusBadRequest)
+		return
+	}
+
 	logStore := models.GetLogStore()
 
 	job := Job{
-		Name: "Bulk Delete Tasks",
+		Name:  "Bulk Delete Tasks",
+		Owner: uuid,
 		Execute: func() error {
 			logStore.AddLog("INFO", fmt.Sprintf("[Bulk Delete] Starting %d tasks", len(taskUUIDs)), uuid, "Bulk Delete Task")
 
-			failedTasks, err := tw.DeleteTasksInTaskwarrior(email, encryptionSecret, uuid, taskUUIDs)
+			failedTasks, err := tw.DeleteTasksInTaskwarrior(email, encryptionSecret, uuid, taskUUIDs, cascade)
 
 			for taskUUID, errMsg := range failedTasks {
 				logStore.AddLog("ERROR", fmt.Sprintf("[Bulk Delete] Failed: %s (%s)", taskUUID, errMsg), uuid, "Bulk Delete Task")
@@ -75,6 +82,5 @@ func BulkDeleteTaskHandler(w http.ResponseWriter, r *http.Request) {
 		},
 	}
 
-	GlobalJobQueue.AddJob(job)
-	w.WriteHeader(http.StatusAccepted)
+	acceptJob(w, GlobalJobQueue.AddJob(job))
 }
@@ -9,16 +9,19 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"time"
 )
 
 // EditTaskHandler godoc
 // @Summary Edit a task
-// @Description Edit task description and tags in Taskwarrior
+// @Description Edit task description and tags in Taskwarrior. Dates accept Taskwarrior date expressions as for /add-task.
 // @Tags Tasks
 // @Accept json
 // @Produce json
 // @Param task body models.EditTaskRequestBody true "Task edit details"
-// @Success 202 {string} string "Task edit accepted for processing"
+// @Param X-Timezone header string false "IANA timezone in which dates without an offset, such as \"2025-04-01\" or \"tomorrow 9am\", are read (default: the timezone in the user's settings, else UTC)"
+// @Param If-Match header string false "Modified timestamp of the task as last read; the job fails with a conflict if the task changed since (alternative to expectedModified in the body). Send the task as read in the body's base field to merge non-overlapping changes instead of failing."
+// @Success 202 {object} controllers.JobAcceptedResponse "Task edit accepted for processing; poll /jobs/{id} for the outcome"
 // @Failure 400 {string} string "Bad request - invalid input or missing taskID"
 // @Failure 405 {string} string "Method not allowed"
 // @Router /edit-task [post]
@@ -71,14 +74,7 @@ func EditTaskHandler(w http.ResponseWriter, r *http.Request) {
 				return
 			}
 		} else {
-			taskDeps := make([]utils.TaskDependency, len(existingTasks))
-			for i, task := range existingTasks {
-				taskDeps[i] = utils.TaskDependency{
-					UUID:    task.UUID,
-					Depends: task.Depends,
-					Status:  task.Status,
-				}
-			}
+			taskDeps := models.TaskDependencies(existingTasks)
 
 			if err := utils.ValidateCircularDependencies(depends, taskUUID, taskDeps); err != nil {
 				http.Error(w, fmt.Sprintf("Invalid dependencies: %v", err), http.StatusBadRequest)
@@ -86,39 +82,53 @@ func EditTaskHandler(w http.ResponseWriter, r *http.Request) {
 			}
 		}
 
-		start, err = utils.ConvertISOToTaskwarriorFormat(start)
-		if err != nil {
-			http.Error(w, fmt.Sprintf("Invalid start date format: %v", err), http.StatusBadRequest)
-			return
-		}
-
-		due, err = utils.ConvertISOToTaskwarriorFormat(due)
-		if err != nil {
-			http.Error(w, fmt.Sprintf("Invalid due date format: %v", err), http.StatusBadRequest)
-			return
-		}
-
-		end, err = utils.ConvertISOToTaskwarriorFormat(end)
+		loc, err := requestLocation(r, uuid)
 		if err != nil {
-			http.Error(w, fmt.Sprintf("Invalid end date format: %v", err), http.StatusBadRequest)
+			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
-
-		entry, err = utils.ConvertISOToTaskwarriorFormat(entry)
-		if err != nil {
-			http.Error(w, fmt.Sprintf("Invalid entry date format: %v", err), http.StatusBadRequest)
+		if err := normalizeDateInputs(time.Now().In(loc),
+			dateField{"start", &start},
+			dateField{"due", &due},
+			dateField{"end", &end},
+			dateField{"entry", &entry},
+			dateField{"wait", &wait},
+		); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
 
-		wait, err = utils.ConvertISOToTaskwarriorFormat(wait)
-		if err != nil {
-			http.Error(w, fmt.Sprintf("Invalid wait date format: %v", err), http.StatusBadRequest)
-			return
-		}
+		precondition, mergeResult := newPrecondition(r, requestBody.ExpectedModified, requestBody.Base, func(base models.Task) models.Task {
+			proposed := base
+			if description != "" {
+				proposed.Description = description
+			}
+			if project != "" {
+				proposed.Project = project
+			}
+			for _, field := range []struct {
+				value  string
+				target *string
+			}{{start, &proposed.Start}, {entry, &proposed.Entry}, {wait, &proposed.Wait}, {end, &proposed.End}, {due, &proposed.Due}} {
+				if field.value != "" {
+					*field.target = exportDate(field.value)
+				}
+			}
+			if recur != "" {
+				proposed.Recur = recur
+			}
+			proposed.Tags = applyTagOps(base.Tags, tags)
+			proposed.Depends = depends
+			proposed.Annotations = annotations
+			return proposed
+		})
 
 		logStore := models.GetLogStore()
 		job := Job{
-			Name: "Edit Task",
+			Name:      "Edit Task",
+			Owner:     uuid,
+			Requested: requestedChanges(requestBody),
+			Result:    mergeResult,
 			Execute: func() error {
 				logStore.AddLog("INFO", fmt.Sprintf("Editing task UUID: %s", taskUUID), uuid, "Edit Task")
 
@@ -138,6 +148,7 @@ func EditTaskHandler(w http.ResponseWriter, r *http.Request) {
 					tags,
 					depends,
 					annotations,
+					precondition,
 				)
 				if err != nil {
 					logStore.AddLog("ERROR", fmt.Sprintf("Failed to edit task UUID %s: %v", taskUUID, err), uuid, "Edit Task")
@@ -147,8 +158,7 @@ func EditTaskHandler(w http.ResponseWriter, r *http.Request) {
 				return nil
 			},
 		}
-		GlobalJobQueue.AddJob(job)
-		w.WriteHeader(http.StatusAccepted)
+		acceptJob(w, GlobalJobQueue.AddJob(job))
 
 		return
 	}
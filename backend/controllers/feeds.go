@@ -0,0 +1,239 @@
+package controllers
+
+import (
+	"ccsync_backend/models"
+	"ccsync_backend/utils/ical"
+	"ccsync_backend/utils/tw"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gorilla/sessions"
+)
+
+// maxFeedTokenName bounds the length of a feed token's name
+const maxFeedTokenName = 100
+
+// FeedTokenResponse is a newly created feed token. Token and Path are only returned
+// once: the token cannot be recovered afterwards.
+type FeedTokenResponse struct {
+	models.FeedToken
+	Token string `json:"token"`
+	// Path is the path of the feed, or of the CalDAV server, relative to the API
+	Path string `json:"path"`
+}
+
+// FeedTokensHandler godoc
+// @Summary List or create calendar feed tokens
+// @Description GET lists the user's feed tokens, without the tokens themselves. POST creates a token and returns it with the path it unlocks. Tokens with the ics scope (the default) read the iCalendar feed at /feeds/ics/{token}.ics, which calendar apps can subscribe to without a session. Tokens with the caldav scope are passwords for the CalDAV server at /caldav/, used with HTTP Basic authentication and any user name; they can change tasks. Anyone holding a token has this access until it is revoked. Tokens outlive restarts only when the server keeps its data in CCSYNC_DATA_DIR.
+// @Tags Feeds
+// @Accept json
+// @Produce json
+// @Param token body models.FeedTokenRequestBody false "Name of the new token (POST)"
+// @Success 200 {array} models.FeedToken "Feed tokens (GET)"
+// @Success 201 {object} controllers.FeedTokenResponse "Created token (POST)"
+// @Failure 400 {string} string "Invalid name or scope, or too many tokens"
+// @Failure 401 {string} string "Authentication required"
+// @Failure 405 {string} string "Method not allowed"
+// @Router /feeds/tokens [get]
+// @Router /feeds/tokens [post]
+func FeedTokensHandler(store *sessions.CookieStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tokens := models.GetFeedTokenStore()
+
+		switch r.Method {
+		case http.MethodGet:
+			// tokens unlock the user's tasks, so the user is taken from the session
+			_, _, UUID, ok := sessionCredentials(store, r)
+			if !ok {
+				http.Error(w, "Authentication required", http.StatusUnauthorized)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(tokens.List(UUID))
+
+		case http.MethodPost:
+			var requestBody models.FeedTokenRequestBody
+			if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+				http.Error(w, fmt.Sprintf("error decoding request body: %v", err), http.StatusBadRequest)
+				return
+			}
+			defer r.Body.Close()
+
+			if requestBody.Email == "" || requestBody.EncryptionSecret == "" || requestBody.UUID == "" {
+				http.Error(w, "email, encryptionSecret and UUID are required", http.StatusBadRequest)
+				return
+			}
+			name := strings.TrimSpace(requestBody.Name)
+			if len(name) > maxFeedTokenName {
+				http.Error(w, fmt.Sprintf("name is longer than %d characters", maxFeedTokenName), http.StatusBadRequest)
+				return
+			}
+
+			scope := requestBody.Scope
+			if scope == "" {
+				scope = models.FeedScopeICS
+			}
+			if !models.ValidFeedScope(scope) {
+				http.Error(w, fmt.Sprintf("Invalid scope '%s': expected ics or caldav", scope), http.StatusBadRequest)
+				return
+			}
+
+			token, secret, err := tokens.Create(models.FeedCredentials{
+				Email:            requestBody.Email,
+				EncryptionSecret: requestBody.EncryptionSecret,
+				UUID:             requestBody.UUID,
+			}, name, scope)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			path := "/feeds/ics/" + secret + ".ics"
+			if scope == models.FeedScopeCalDAV {
+				path = caldavRoot
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(FeedTokenResponse{
+				FeedToken: token,
+				Token:     secret,
+				Path:      path,
+			})
+
+		default:
+			http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+// RevokeFeedTokenHandler godoc
+// @Summary Revoke a calendar feed token
+// @Description Revoke one of the user's feed tokens. Calendar apps using it lose access at once.
+// @Tags Feeds
+// @Param id path string true "Token ID"
+// @Success 204 "Token revoked"
+// @Failure 401 {string} string "Authentication required"
+// @Failure 404 {string} string "Token not found"
+// @Failure 405 {string} string "Method not allowed"
+// @Router /feeds/tokens/{id} [delete]
+func RevokeFeedTokenHandler(store *sessions.CookieStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		_, _, UUID, ok := sessionCredentials(store, r)
+		if !ok {
+			http.Error(w, "Authentication required", http.StatusUnauthorized)
+			return
+		}
+
+		id := strings.Trim(strings.TrimPrefix(r.URL.Path, "/feeds/tokens/"), "/")
+		if !models.GetFeedTokenStore().Revoke(UUID, id) {
+			http.Error(w, "Token not found", http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// CalendarFeedHandler godoc
+// @Summary Get the iCalendar feed of a user's tasks
+// @Description Serve the pending and waiting tasks with a due or scheduled date as an iCalendar document, authenticated by a feed token from /feeds/tokens rather than the session. Events are placed on the due date (the scheduled date for tasks without one); to-dos carry both. Dates at midnight in the user's timezone become all-day entries. A recurring series appears once, with a recurrence rule derived from its recur attribute.
+// @Tags Feeds
+// @Produce text/calendar
+// @Param token path string true "Feed token, optionally followed by .ics"
+// @Param project query string false "Only tasks of this project or its subprojects"
+// @Param tag query []string false "Only tasks with this tag; repeat to require several" collectionFormat(multi)
+// @Param components query string false "event (default), todo or both"
+// @Success 200 {string} string "iCalendar document"
+// @Failure 400 {string} string "Invalid parameters"
+// @Failure 404 {string} string "Unknown or revoked token"
+// @Failure 405 {string} string "Method not allowed"
+// @Failure 500 {string} string "Failed to fetch tasks at backend"
+// @Router /feeds/ics/{token} [get]
+func CalendarFeedHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	opts := ical.Options{Now: time.Now()}
+	switch query.Get("components") {
+	case "", "event":
+		opts.Events = true
+	case "todo":
+		opts.Todos = true
+	case "both":
+		opts.Events, opts.Todos = true, true
+	default:
+		http.Error(w, fmt.Sprintf("Invalid components '%s': expected event, todo or both", query.Get("components")), http.StatusBadRequest)
+		return
+	}
+
+	token := strings.TrimSuffix(strings.Trim(strings.TrimPrefix(r.URL.Path, "/feeds/ics/"), "/"), ".ics")
+	credentials, feedToken, ok := models.GetFeedTokenStore().Resolve(token, models.FeedScopeICS)
+	if !ok {
+		http.Error(w, "Feed not found", http.StatusNotFound)
+		return
+	}
+
+	loc, err := requestLocation(r, credentials.UUID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	opts.Location = loc
+	opts.Name = "CCSync"
+	if feedToken.Name != "" {
+		opts.Name += ": " + feedToken.Name
+	}
+
+	origin := os.Getenv("CONTAINER_ORIGIN")
+	tasks, err := tw.FetchTasksFromTaskwarrior(credentials.Email, credentials.EncryptionSecret, origin, credentials.UUID)
+	if err != nil || tasks == nil {
+		http.Error(w, "Failed to fetch tasks at backend", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Cache-Control", "private, no-cache")
+	w.Write([]byte(ical.Build(feedTasks(tasks, query.Get("project"), query["tag"]), opts)))
+}
+
+// feedTasks keeps the tasks of a project (subprojects included) carrying every tag
+func feedTasks(tasks []models.Task, project string, tags []string) []models.Task {
+	selected := []models.Task{}
+	for _, task := range tasks {
+		if project != "" && task.Project != project && !strings.HasPrefix(task.Project, project+".") {
+			continue
+		}
+		if hasAllTags(task, tags) {
+			selected = append(selected, task)
+		}
+	}
+	return selected
+}
+
+func hasAllTags(task models.Task, tags []string) bool {
+	for _, tag := range tags {
+		found := false
+		for _, t := range task.Tags {
+			if t == tag {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
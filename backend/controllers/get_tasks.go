@@ -1,23 +1,47 @@
 package controllers
 
 import (
+	"ccsync_backend/utils/filter"
+	"ccsync_backend/utils/query"
 	"ccsync_backend/utils/tw"
+	"ccsync_backend/utils/urgency"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"os"
+	"strconv"
+	"time"
 )
 
 // TasksHandler godoc
 // @Summary Get all tasks
-// @Description Fetch all tasks from Taskwarrior for a specific user via Headers
+// @Description Fetch tasks from Taskwarrior for a specific user via Headers, optionally filtered, sorted and paginated.
+// @Description Urgencies are computed with the user's urgency coefficients (see /settings/urgency).
+// @Description The user's active context (see /contexts) is applied on top of the filter; the name of the context applied, if any, is returned in the X-Context header.
+// @Description Paginated responses carry the cursor of the next page in the X-Next-Cursor header (absent on the last page) and the number of matching tasks in X-Total-Count.
 // @Tags Tasks
 // @Accept json
 // @Produce json
 // @Param X-User-Email header string true "User email"
 // @Param X-Encryption-Secret header string true "Encryption secret"
 // @Param X-User-UUID header string true "User UUID"
+// @Param X-Timezone header string false "IANA timezone in which dates of the filter are read and, with dates=offset, returned (default: the timezone in the user's settings, else UTC)"
+// @Param dates query string false "utc (default) returns dates in Taskwarrior's compact UTC format, offset in RFC 3339 with the offset of the user's timezone"
+// @Param If-None-Match header string false "ETag of a previous response; answered with 304 if the list is unchanged"
+// @Param filter query string false "Filter expression, e.g. project:Home and (+urgent or due.before:tomorrow) or +OVERDUE"
+// @Param context query string false "Context to apply instead of the active one, or none to apply no context"
+// @Param sort query string false "Comma-separated sort fields: urgency, due, modified, entry, project. Prefix with - for descending or + for ascending (urgency defaults to descending, the rest to ascending). Tasks without the field sort last."
+// @Param limit query int false "Page size (max 500). Enables pagination; without it every task is returned."
+// @Param cursor query string false "Opaque cursor from the X-Next-Cursor header of the previous page"
+// @Param fields query string false "Comma-separated task fields to return, e.g. description,due,tags. The uuid is always included."
+// @Param includeClosed query bool false "Include completed and deleted tasks. Defaults to false when paginating (limit or cursor given) without a status in the filter, and true otherwise."
 // @Success 200 {array} models.Task "List of tasks"
-// @Failure 400 {string} string "Missing required headers"
+// @Header 200 {string} X-Next-Cursor "Cursor for the next page"
+// @Header 200 {int} X-Total-Count "Number of tasks across all pages"
+// @Header 200 {string} X-Context "Name of the context applied"
+// @Header 200 {string} ETag "Fingerprint of the returned list"
+// @Success 304 {string} string "Not modified since the ETag in If-None-Match"
+// @Failure 400 {string} string "Missing required headers, invalid filter, unknown context or invalid list options"
 // @Failure 500 {string} string "Failed to fetch tasks at backend"
 // @Router /tasks [get]
 func TasksHandler(w http.ResponseWriter, r *http.Request) {
@@ -34,14 +58,84 @@ func TasksHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if r.Method == http.MethodGet {
+		// parsed before fetching so that a malformed filter fails fast
+		taskFilter, err := filter.Parse(r.URL.Query().Get("filter"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		contextName, contextFilter, err := activeContextFilter(r, UUID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if contextFilter != nil {
+			taskFilter = filter.And(contextFilter, taskFilter)
+		}
+		opts, err := query.ParseOptions(r.URL.Query(), taskFilter.NamesStatus())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		loc, err := requestLocation(r, UUID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		dates := r.URL.Query().Get("dates")
+		if dates != "" && dates != "utc" && dates != "offset" {
+			http.Error(w, fmt.Sprintf("Invalid dates '%s': expected utc or offset", dates), http.StatusBadRequest)
+			return
+		}
+
 		tasks, err := tw.FetchTasksFromTaskwarrior(email, encryptionSecret, origin, UUID)
 		if err != nil || tasks == nil {
 			http.Error(w, "Failed to fetch tasks at backend", http.StatusInternalServerError)
 			return
 		}
+		urgency.Apply(tasks, userUrgencyCoefficients(UUID), time.Now())
+		tasks = taskFilter.Apply(tasks, time.Now(), loc)
+
+		page, err := query.Apply(tasks, opts)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if dates == "offset" {
+			for i := range page.Tasks {
+				page.Tasks[i] = page.Tasks[i].WithDateOffsets(loc)
+			}
+		}
+
+		// the view is derived from the tasks on this page, so relative filters such as
+		// +OVERDUE still get a fresh tag once time moves tasks in or out of the result
+		etag := query.ETag(page.Tasks, fmt.Sprintf("%s|%s|%d|%s", r.URL.RawQuery, taskFilter, page.Total, page.NextCursor))
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Cache-Control", "private, no-cache")
+		w.Header().Set("X-Total-Count", strconv.Itoa(page.Total))
+		if contextName != "" {
+			w.Header().Set("X-Context", contextName)
+		}
+		if page.NextCursor != "" {
+			w.Header().Set("X-Next-Cursor", page.NextCursor)
+		}
+		if query.MatchesETag(r.Header.Get("If-None-Match"), etag) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
 
 		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(tasks)
+
+		if len(opts.Fields) > 0 {
+			projected, err := query.Project(page.Tasks, opts.Fields)
+			if err != nil {
+				http.Error(w, "Failed to encode tasks", http.StatusInternalServerError)
+				return
+			}
+			json.NewEncoder(w).Encode(projected)
+			return
+		}
+		json.NewEncoder(w).Encode(page.Tasks)
 		return
 	}
 
@@ -0,0 +1,130 @@
+package controllers
+
+import (
+	"ccsync_backend/models"
+	"ccsync_backend/utils/importer"
+	"ccsync_backend/utils/tw"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// maxImportErrors bounds the number of unreadable entries listed when an import is
+// refused
+const maxImportErrors = 5
+
+// ImportHandler godoc
+// @Summary Import tasks from todo.txt, CSV or Taskwarrior JSON
+// @Description Import a list of tasks: todo.txt (one task per line; the first +project is the project, further ones and @contexts become tags, due: and t: set the due and wait dates), CSV (the first row names the columns, mapped to description, project, priority, tags, status, uuid, due, scheduled, wait, entry and end by mapping or else by name) or the output of task export (UDAs kept). Tasks whose UUID exists, or without UUID and with the description of a task that is not deleted, are duplicates and skipped, as are repeats within the import. With dryRun, the tasks are read and checked for duplicates and returned without writing anything. Otherwise the import is refused if any entry cannot be read, and else written as a single job whose result lists the imported UUIDs and the skipped duplicates.
+// @Tags Tasks
+// @Accept json
+// @Produce json
+// @Param import body models.ImportRequestBody true "Format, content and options of the import"
+// @Param X-Timezone header string false "IANA timezone in which dates without an offset are read (default: the timezone in the user's settings, else UTC)"
+// @Success 200 {object} models.ImportPreview "Preview of the import (dryRun)"
+// @Success 202 {object} controllers.JobAcceptedResponse "Import accepted for processing; poll /jobs/{id} for the imported tasks"
+// @Failure 400 {string} string "Invalid format, mapping or content, or too many tasks"
+// @Failure 405 {string} string "Method not allowed"
+// @Failure 500 {string} string "Failed to fetch tasks at backend"
+// @Router /import [post]
+func ImportHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var requestBody models.ImportRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+		http.Error(w, fmt.Sprintf("error decoding request body: %v", err), http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	if !models.ValidImportFormat(requestBody.Format) {
+		http.Error(w, fmt.Sprintf("Invalid format '%s': expected todotxt, csv or json", requestBody.Format), http.StatusBadRequest)
+		return
+	}
+	loc, err := requestLocation(r, requestBody.UUID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	tasks, errs, err := importer.Parse(requestBody.Format, requestBody.Data, importer.Options{
+		Now:     time.Now().In(loc),
+		Mapping: requestBody.Mapping,
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(tasks)+len(errs) > models.MaxImportTasks {
+		http.Error(w, fmt.Sprintf("too many tasks (max %d)", models.MaxImportTasks), http.StatusBadRequest)
+		return
+	}
+
+	email := requestBody.Email
+	encryptionSecret := requestBody.EncryptionSecret
+	uuid := requestBody.UUID
+
+	if requestBody.DryRun {
+		origin := os.Getenv("CONTAINER_ORIGIN")
+		existing, err := tw.FetchTasksFromTaskwarrior(email, encryptionSecret, origin, uuid)
+		if err != nil || existing == nil {
+			http.Error(w, "Failed to fetch tasks at backend", http.StatusInternalServerError)
+			return
+		}
+		importer.MarkDuplicates(tasks, existing)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(importer.Preview(tasks, errs))
+		return
+	}
+
+	if len(errs) > 0 {
+		http.Error(w, importErrorsMessage(errs), http.StatusBadRequest)
+		return
+	}
+	if len(tasks) == 0 {
+		http.Error(w, "no tasks to import", http.StatusBadRequest)
+		return
+	}
+
+	var result models.ImportResult
+	logStore := models.GetLogStore()
+	job := Job{
+		Name:  "Import Tasks",
+		Owner: uuid,
+		Result: func() interface{} {
+			return result
+		},
+		Execute: func() error {
+			logStore.AddLog("INFO", fmt.Sprintf("Importing %d tasks from %s", len(tasks), requestBody.Format), uuid, "Import Tasks")
+
+			var err error
+			result, err = tw.ImportTasksInTaskwarrior(email, encryptionSecret, uuid, tasks)
+			if err != nil {
+				logStore.AddLog("ERROR", fmt.Sprintf("Failed to import tasks: %v", err), uuid, "Import Tasks")
+				return err
+			}
+			logStore.AddLog("INFO", fmt.Sprintf("Successfully imported %d tasks, skipped %d duplicates", len(result.Imported), len(result.Duplicates)), uuid, "Import Tasks")
+			return nil
+		},
+	}
+	acceptJob(w, GlobalJobQueue.AddJob(job))
+}
+
+// importErrorsMessage lists the first entries that could not be read
+func importErrorsMessage(errs []models.ImportError) string {
+	var lines []string
+	for i, importError := range errs {
+		if i == maxImportErrors {
+			lines = append(lines, fmt.Sprintf("and %d more", len(errs)-maxImportErrors))
+			break
+		}
+		lines = append(lines, fmt.Sprintf("line %d: %s", importError.Line, importError.Message))
+	}
+	return fmt.Sprintf("%d entries cannot be imported (use dryRun to preview): %s", len(errs), strings.Join(lines, "; "))
+}
@@ -1,12 +1,25 @@
 package controllers
 
 import (
+	"ccsync_backend/models"
+	"ccsync_backend/utils/tw"
+	"errors"
 	"sync"
+
+	"github.com/google/uuid"
 )
 
 type Job struct {
+	ID      string
 	Name    string
+	Owner   string // UUID of the user allowed to look up the job's outcome
 	Execute func() error
+
+	// Requested describes the change the job applies, reported back on conflicts
+	Requested interface{}
+	// Result, if set, is stored with the job record once the job finishes, unless it
+	// failed with a conflict or was refused for open dependents, which is stored instead
+	Result func() interface{}
 }
 
 type JobQueue struct {
@@ -22,32 +35,65 @@ func NewJobQueue() *JobQueue {
 	return queue
 }
 
-func (q *JobQueue) AddJob(job Job) {
+// AddJob queues the job and returns its ID
+func (q *JobQueue) AddJob(job Job) string {
+	if job.ID == "" {
+		job.ID = uuid.NewString()
+	}
+	models.GetJobStore().Create(job.ID, job.Name, job.Owner)
+
 	q.wg.Add(1)
 	q.jobChannel <- job
 
 	// notify job queued
 	go BroadcastJobStatus(JobStatus{
+		ID:     job.ID,
 		Job:    job.Name,
 		Status: "queued",
 	})
+
+	return job.ID
 }
 
 func (q *JobQueue) processJobs() {
+	jobStore := models.GetJobStore()
 	for job := range q.jobChannel {
+		jobStore.Update(job.ID, "in-progress", "", nil)
 		go BroadcastJobStatus(JobStatus{
+			ID:     job.ID,
 			Job:    job.Name,
 			Status: "in-progress",
 		})
 
 		if err := job.Execute(); err != nil {
+			// conflicts carry both versions of the task, which only the owner may read
+			// through /jobs/{id}; the broadcast goes to every connected client
+			var result interface{}
+			var conflict *tw.ConflictError
+			var refused *tw.DependentsError
+			if errors.As(err, &conflict) {
+				conflict.Requested = job.Requested
+				result = conflict
+			} else if errors.As(err, &refused) {
+				result = refused
+			} else if job.Result != nil {
+				result = job.Result()
+			}
+			jobStore.Update(job.ID, "failure", err.Error(), result)
 			go BroadcastJobStatus(JobStatus{
+				ID:     job.ID,
 				Job:    job.Name,
 				Status: "failure",
 			})
 		} else {
 			// utils.Logger.Infof("Success in executing job %s", job.Name)
+			var result interface{}
+			if job.Result != nil {
+				result = job.Result()
+			}
+			jobStore.Update(job.ID, "success", "", result)
 			go BroadcastJobStatus(JobStatus{
+				ID:     job.ID,
 				Job:    job.Name,
 				Status: "success",
 			})
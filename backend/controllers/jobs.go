@@ -0,0 +1,88 @@
+package controllers
+
+import (
+	"ccsync_backend/models"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/sessions"
+)
+
+// JobAcceptedResponse is returned when a job has been queued
+type JobAcceptedResponse struct {
+	JobID string `json:"jobId"`
+}
+
+// JobHandler godoc
+// @Summary Get a job's outcome
+// @Description Return the status of a queued job of the authenticated user. Failed edits, modifications, completions and deletions rejected by an If-Match / expectedModified precondition carry a conflict result with the current and the requested version of the task. When the request included the base version of the task, a successful job carries the merge result listing applied and kept fields, and a failed one the conflicting fields. Completions and deletions refused because of open dependents (cascade=refuse) carry the task and the list of its dependents.
+// @Tags Jobs
+// @Produce json
+// @Param id path string true "Job ID"
+// @Success 200 {object} models.JobRecord "Job status and result"
+// @Failure 401 {string} string "Authentication required"
+// @Failure 404 {string} string "Job not found"
+// @Failure 405 {string} string "Method not allowed"
+// @Router /jobs/{id} [get]
+func JobHandler(store *sessions.CookieStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		// Results may contain task data, so the owner is taken from the session
+		// rather than from headers the client controls
+		_, _, userUUID, ok := sessionCredentials(store, r)
+		if !ok {
+			http.Error(w, "Authentication required", http.StatusUnauthorized)
+			return
+		}
+
+		jobID := strings.Trim(strings.TrimPrefix(r.URL.Path, "/jobs/"), "/")
+		record, ok := models.GetJobStore().Get(jobID, userUUID)
+		if !ok {
+			http.Error(w, "Job not found", http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(record)
+	}
+}
+
+// acceptJob answers a request whose work was queued as the given job
+func acceptJob(w http.ResponseWriter, jobID string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/jobs/"+jobID)
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(JobAcceptedResponse{JobID: jobID})
+}
+
+// expectedModified returns the precondition of a mutation, given either as
+// expectedModified in the body or as an If-Match header
+func expectedModified(r *http.Request, fromBody string) string {
+	if fromBody != "" {
+		return fromBody
+	}
+	return strings.TrimSpace(r.Header.Get("If-Match"))
+}
+
+// requestedChanges strips the session credentials from a request body so that
+// it can be reported back in a conflict result
+func requestedChanges(requestBody interface{}) map[string]interface{} {
+	data, err := json.Marshal(requestBody)
+	if err != nil {
+		return nil
+	}
+	var changes map[string]interface{}
+	if err := json.Unmarshal(data, &changes); err != nil {
+		return nil
+	}
+	delete(changes, "email")
+	delete(changes, "encryptionSecret")
+	delete(changes, "UUID")
+	delete(changes, "base")
+	return changes
+}
@@ -0,0 +1,81 @@
+package controllers
+
+import (
+	"ccsync_backend/models"
+	"ccsync_backend/utils"
+	"ccsync_backend/utils/tw"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// newPrecondition builds the precondition of a mutation from the If-Match header or
+// expectedModified field. With the base version of the task the client edited, a task
+// changed in the meantime is merged field by field, using propose to derive the
+// client's intended version from the base. The returned function yields the merge
+// outcome, for use as the job's result.
+func newPrecondition(r *http.Request, expected string, base *models.Task, propose func(models.Task) models.Task) (tw.Precondition, func() interface{}) {
+	precondition := tw.Precondition{ExpectedModified: expectedModified(r, expected)}
+	if base == nil {
+		return precondition, nil
+	}
+	if precondition.ExpectedModified == "" {
+		precondition.ExpectedModified = base.Modified
+	}
+
+	proposed := propose(*base)
+	precondition.Base = base
+	precondition.Proposed = &proposed
+
+	var merged *models.MergeResult
+	precondition.OnMerge = func(result models.MergeResult) {
+		merged = &result
+	}
+	return precondition, func() interface{} {
+		if merged == nil {
+			return nil
+		}
+		return merged
+	}
+}
+
+// applyTagOps applies +tag / -tag / tag operations, as sent by edit and modify, to a tag list
+func applyTagOps(tags, ops []string) []string {
+	result := append([]string(nil), tags...)
+	for _, op := range ops {
+		if strings.HasPrefix(op, "-") {
+			name := strings.TrimPrefix(op, "-")
+			kept := result[:0]
+			for _, tag := range result {
+				if tag != name {
+					kept = append(kept, tag)
+				}
+			}
+			result = kept
+			continue
+		}
+
+		name := strings.TrimPrefix(op, "+")
+		found := false
+		for _, tag := range result {
+			if tag == name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			result = append(result, name)
+		}
+	}
+	return result
+}
+
+// exportDate converts a date accepted by Taskwarrior's CLI into the form `task export` reports
+func exportDate(value string) string {
+	for _, layout := range []string{utils.TaskwarriorDateLayout, "2006-01-02T15:04:05", "2006-01-02"} {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			return parsed.UTC().Format(utils.TaskwarriorDateLayout)
+		}
+	}
+	return value
+}
@@ -9,16 +9,19 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"time"
 )
 
 // ModifyTaskHandler godoc
 // @Summary Modify a task
-// @Description Modify task properties including description, project, priority, status, due date, and tags
+// @Description Modify task properties including description, project, priority, status, due date, and tags. Dates accept Taskwarrior date expressions as for /add-task.
 // @Tags Tasks
 // @Accept json
 // @Produce json
 // @Param task body models.ModifyTaskRequestBody true "Task modification details"
-// @Success 202 {string} string "Task modification accepted for processing"
+// @Param X-Timezone header string false "IANA timezone in which dates without an offset, such as \"2025-04-01\" or \"tomorrow 9am\", are read (default: the timezone in the user's settings, else UTC)"
+// @Param If-Match header string false "Modified timestamp of the task as last read; the job fails with a conflict if the task changed since (alternative to expectedModified in the body). Send the task as read in the body's base field to merge non-overlapping changes instead of failing."
+// @Success 202 {object} controllers.JobAcceptedResponse "Task modification accepted for processing; poll /jobs/{id} for the outcome"
 // @Failure 400 {string} string "Bad request - invalid input or missing required fields"
 // @Failure 405 {string} string "Method not allowed"
 // @Router /modify-task [post]
@@ -61,6 +64,16 @@ func ModifyTaskHandler(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
+		loc, err := requestLocation(r, uuid)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := normalizeDateInputs(time.Now().In(loc), dateField{"due", &due}); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
 		// Validate dependencies
 		origin := os.Getenv("CONTAINER_ORIGIN")
 		existingTasks, err := tw.FetchTasksFromTaskwarrior(email, encryptionSecret, origin, uuid)
@@ -70,16 +83,7 @@ func ModifyTaskHandler(w http.ResponseWriter, r *http.Request) {
 				return
 			}
 		} else {
-			taskDeps := make([]utils.TaskDependency, len(existingTasks))
-			for i, task := range existingTasks {
-				taskDeps[i] = utils.TaskDependency{
-					UUID:    task.UUID,
-					Depends: task.Depends,
-					Status:  task.Status,
-				}
-			}
-
-			if err := utils.ValidateCircularDependencies(depends, taskUUID, taskDeps); err != nil {
+			if err := utils.ValidateCircularDependencies(depends, taskUUID, models.TaskDependencies(existingTasks)); err != nil {
 				http.Error(w, fmt.Sprintf("Invalid dependencies: %v", err), http.StatusBadRequest)
 				return
 			}
@@ -90,12 +94,29 @@ func ModifyTaskHandler(w http.ResponseWriter, r *http.Request) {
 		// 	return
 		// }
 
+		precondition, mergeResult := newPrecondition(r, requestBody.ExpectedModified, requestBody.Base, func(base models.Task) models.Task {
+			proposed := base
+			proposed.Description = description
+			proposed.Project = project
+			proposed.Priority = priority
+			proposed.Due = exportDate(due)
+			proposed.Depends = depends
+			if status == "completed" || status == "deleted" {
+				proposed.Status = status
+			}
+			proposed.Tags = applyTagOps(base.Tags, tags)
+			return proposed
+		})
+
 		logStore := models.GetLogStore()
 		job := Job{
-			Name: "Modify Task",
+			Name:      "Modify Task",
+			Owner:     uuid,
+			Requested: requestedChanges(requestBody),
+			Result:    mergeResult,
 			Execute: func() error {
 				logStore.AddLog("INFO", fmt.Sprintf("Modifying task UUID: %s", taskUUID), uuid, "Modify Task")
-				err := tw.ModifyTaskInTaskwarrior(uuid, description, project, priority, status, due, email, encryptionSecret, taskUUID, tags, depends)
+				err := tw.ModifyTaskInTaskwarrior(uuid, description, project, priority, status, due, email, encryptionSecret, taskUUID, tags, depends, precondition)
 				if err != nil {
 					logStore.AddLog("ERROR", fmt.Sprintf("Failed to modify task UUID %s: %v", taskUUID, err), uuid, "Modify Task")
 					return err
@@ -104,8 +125,7 @@ func ModifyTaskHandler(w http.ResponseWriter, r *http.Request) {
 				return nil
 			},
 		}
-		GlobalJobQueue.AddJob(job)
-		w.WriteHeader(http.StatusAccepted)
+		acceptJob(w, GlobalJobQueue.AddJob(job))
 		return
 	}
 	http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
@@ -0,0 +1,126 @@
+package controllers
+
+import (
+	"ccsync_backend/models"
+	"ccsync_backend/utils"
+	"ccsync_backend/utils/filter"
+	"ccsync_backend/utils/tw"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// BulkModifyTaskHandler godoc
+// @Summary Bulk modify tasks
+// @Description Apply one patch to many tasks: set the project or priority, add or remove tags, and shift due dates by a signed duration such as "+2d". The tasks are given by UUID or selected with a filter expression, as for GET /tasks. A filter only selects pending and waiting tasks, unless it names a status itself (status:completed, +DELETED, ...), so completed and deleted tasks and recurring templates are left alone. The job result lists the modified tasks and the reason for each task that could not be modified.
+// @Tags Tasks
+// @Accept json
+// @Produce json
+// @Param task body models.BulkModifyTaskRequestBody true "Tasks to modify and the patch to apply"
+// @Success 202 {object} controllers.JobAcceptedResponse "Bulk modification accepted for processing; poll /jobs/{id} for per-task results"
+// @Failure 400 {string} string "Invalid request - missing tasks, empty patch, invalid filter or invalid values"
+// @Failure 405 {string} string "Method not allowed"
+// @Router /modify-tasks [post]
+func BulkModifyTaskHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var requestBody models.BulkModifyTaskRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+		http.Error(w, fmt.Sprintf("error decoding request body: %v", err), http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	email := requestBody.Email
+	encryptionSecret := requestBody.EncryptionSecret
+	uuid := requestBody.UUID
+	taskUUIDs := requestBody.TaskUUIDs
+	patch := requestBody.Patch
+
+	if len(taskUUIDs) == 0 && strings.TrimSpace(requestBody.Filter) == "" {
+		http.Error(w, "taskuuids or filter is required", http.StatusBadRequest)
+		return
+	}
+	if len(taskUUIDs) > 0 && requestBody.Filter != "" {
+		http.Error(w, "taskuuids and filter cannot be combined", http.StatusBadRequest)
+		return
+	}
+	if err := validateBulkPatch(patch); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	loc, err := requestLocation(r, uuid)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var taskFilter *filter.Filter
+	if requestBody.Filter != "" {
+		parsed, err := filter.Parse(requestBody.Filter)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Invalid filter: %v", err), http.StatusBadRequest)
+			return
+		}
+		taskFilter = parsed
+	}
+
+	var result models.BulkModifyResult
+	logStore := models.GetLogStore()
+	job := Job{
+		Name:  "Bulk Modify Tasks",
+		Owner: uuid,
+		Result: func() interface{} {
+			return result
+		},
+		Execute: func() error {
+			logStore.AddLog("INFO", "[Bulk Modify] Starting", uuid, "Bulk Modify Task")
+
+			var err error
+			result, err = tw.ModifyTasksInTaskwarrior(email, encryptionSecret, uuid, taskUUIDs, taskFilter, loc, patch)
+
+			for taskUUID, errMsg := range result.Failed {
+				logStore.AddLog("ERROR", fmt.Sprintf("[Bulk Modify] Failed: %s (%s)", taskUUID, errMsg), uuid, "Bulk Modify Task")
+			}
+
+			if err != nil {
+				logStore.AddLog("ERROR", fmt.Sprintf("[Bulk Modify] Sync error: %v", err), uuid, "Bulk Modify Task")
+				return err
+			}
+
+			logStore.AddLog("INFO", fmt.Sprintf("[Bulk Modify] Finished: %d succeeded, %d failed", len(result.Modified), len(result.Failed)), uuid, "Bulk Modify Task")
+
+			return nil
+		},
+	}
+	acceptJob(w, GlobalJobQueue.AddJob(job))
+}
+
+// validateBulkPatch rejects empty patches and values Taskwarrior would refuse for every task
+func validateBulkPatch(patch models.BulkTaskPatch) error {
+	if patch.Project == nil && patch.Priority == nil && len(patch.AddTags) == 0 && len(patch.RemoveTags) == 0 && patch.DueShift == "" {
+		return fmt.Errorf("patch is required and cannot be empty")
+	}
+	if patch.Priority != nil {
+		switch *patch.Priority {
+		case "", "H", "M", "L":
+		default:
+			return fmt.Errorf("invalid priority '%s': expected H, M, L or empty", *patch.Priority)
+		}
+	}
+	if patch.DueShift != "" {
+		period, _, err := utils.ParseSignedPeriod(patch.DueShift)
+		if err != nil {
+			return fmt.Errorf("invalid dueShift: %v", err)
+		}
+		if period.IsZero() {
+			return fmt.Errorf("invalid dueShift: '%s' has no length", patch.DueShift)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,364 @@
+package controllers
+
+import (
+	"ccsync_backend/models"
+	"ccsync_backend/utils"
+	"ccsync_backend/utils/tw"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+)
+
+// RecurrenceResponse describes a recurring series: its template, the instances
+// generated so far and a preview of the due dates still to come
+type RecurrenceResponse struct {
+	Template  models.Task   `json:"template"`
+	Instances []models.Task `json:"instances"`
+	NextDue   []string      `json:"nextDue"`
+}
+
+// RecurrenceHandler godoc
+// @Summary Get a recurring series
+// @Description Return the recurrence template (status:recurring) of a task, its generated instances and the next generated due dates. The UUID may be the template or any of its instances.
+// @Tags Recurrence
+// @Accept json
+// @Produce json
+// @Param uuid path string true "Template or instance UUID"
+// @Param count query int false "Number of upcoming due dates to preview (default: 5, max: 50)"
+// @Param X-User-Email header string true "User email"
+// @Param X-Encryption-Secret header string true "Encryption secret"
+// @Param X-User-UUID header string true "User UUID"
+// @Success 200 {object} controllers.RecurrenceResponse "Recurring series"
+// @Failure 400 {string} string "Missing required headers, invalid count or task is not recurring"
+// @Failure 404 {string} string "Task not found"
+// @Failure 405 {string} string "Method not allowed"
+// @Failure 500 {string} string "Failed to fetch tasks at backend"
+// @Router /tasks/{uuid}/recurrence [get]
+func RecurrenceHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	email := r.Header.Get("X-User-Email")
+	encryptionSecret := r.Header.Get("X-Encryption-Secret")
+	UUID := r.Header.Get("X-User-UUID")
+	taskUUID, _ := splitTaskPath(r.URL.Path)
+
+	if email == "" || encryptionSecret == "" || UUID == "" {
+		http.Error(w, "Missing required security headers", http.StatusBadRequest)
+		return
+	}
+
+	const maxPreview = 50
+	count := 5
+	if countParam := r.URL.Query().Get("count"); countParam != "" {
+		parsed, err := strconv.Atoi(countParam)
+		if err != nil || parsed < 0 {
+			http.Error(w, "Invalid 'count' parameter", http.StatusBadRequest)
+			return
+		}
+		count = parsed
+	}
+	if count > maxPreview {
+		count = maxPreview
+	}
+
+	origin := os.Getenv("CONTAINER_ORIGIN")
+	tasks, err := tw.FetchTasksFromTaskwarrior(email, encryptionSecret, origin, UUID)
+	if err != nil || tasks == nil {
+		http.Error(w, "Failed to fetch tasks at backend", http.StatusInternalServerError)
+		return
+	}
+
+	template, status, err := findRecurrenceTemplate(tasks, taskUUID)
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	response := RecurrenceResponse{
+		Template:  *template,
+		Instances: []models.Task{},
+		NextDue:   []string{},
+	}
+	for _, task := range tasks {
+		if task.Parent == template.UUID {
+			response.Instances = append(response.Instances, task)
+		}
+	}
+
+	if due, err := time.Parse(utils.TaskwarriorDateLayout, template.Due); err == nil {
+		var until *time.Time
+		if parsedUntil, err := time.Parse(utils.TaskwarriorDateLayout, template.Until); err == nil {
+			until = &parsedUntil
+		}
+		nextDue, err := utils.NextRecurrences(due, template.Recur, len(template.Mask), count, until)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Unsupported recurrence '%s': %v", template.Recur, err), http.StatusBadRequest)
+			return
+		}
+		for _, date := range nextDue {
+			response.NextDue = append(response.NextDue, date.UTC().Format(utils.TaskwarriorDateLayout))
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// UpdateRecurrenceHandler godoc
+// @Summary Change a recurring series
+// @Description Change the recurrence period and/or end date on the template of a series. The change applies to all pending and future instances. Send until as an empty string to remove the end date.
+// @Tags Recurrence
+// @Accept json
+// @Produce json
+// @Param uuid path string true "Template or instance UUID"
+// @Param recurrence body models.UpdateRecurrenceRequestBody true "New recurrence settings"
+// @Param X-Timezone header string false "IANA timezone in which dates without an offset, such as \"2025-04-01\" or \"tomorrow 9am\", are read (default: the timezone in the user's settings, else UTC)"
+// @Success 202 {object} controllers.JobAcceptedResponse "Recurrence update accepted for processing; poll /jobs/{id} for the outcome"
+// @Failure 400 {string} string "Bad request - invalid input or task is not recurring"
+// @Failure 404 {string} string "Task not found"
+// @Failure 405 {string} string "Method not allowed"
+// @Router /tasks/{uuid}/recurrence [post]
+func UpdateRecurrenceHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("error reading request body: %v", err), http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	var requestBody models.UpdateRecurrenceRequestBody
+	if err := json.Unmarshal(body, &requestBody); err != nil {
+		http.Error(w, fmt.Sprintf("error decoding request body: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	email := requestBody.Email
+	encryptionSecret := requestBody.EncryptionSecret
+	uuid := requestBody.UUID
+	recur := requestBody.Recur
+	until := requestBody.Until
+	taskUUID, _ := splitTaskPath(r.URL.Path)
+
+	if recur == "" && until == nil {
+		http.Error(w, "recur or until is required", http.StatusBadRequest)
+		return
+	}
+	if recur != "" {
+		if _, err := utils.ParsePeriod(recur); err != nil {
+			http.Error(w, fmt.Sprintf("Invalid recur: %v", err), http.StatusBadRequest)
+			return
+		}
+	}
+	if until != nil {
+		loc, err := requestLocation(r, uuid)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := normalizeDateInputs(time.Now().In(loc), dateField{"until", until}); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		converted, err := utils.ConvertISOToTaskwarriorFormat(*until)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Invalid until date format: %v", err), http.StatusBadRequest)
+			return
+		}
+		until = &converted
+	}
+
+	template, status, err := fetchRecurrenceTemplate(email, encryptionSecret, uuid, taskUUID)
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	templateUUID := template.UUID
+
+	logStore := models.GetLogStore()
+	job := Job{
+		Name:  "Update Recurrence",
+		Owner: uuid,
+		Execute: func() error {
+			logStore.AddLog("INFO", fmt.Sprintf("Updating recurrence of template UUID: %s", templateUUID), uuid, "Update Recurrence")
+			err := tw.UpdateRecurrenceInTaskwarrior(email, encryptionSecret, uuid, templateUUID, recur, until)
+			if err != nil {
+				logStore.AddLog("ERROR", fmt.Sprintf("Failed to update recurrence of template UUID %s: %v", templateUUID, err), uuid, "Update Recurrence")
+				return err
+			}
+			logStore.AddLog("INFO", fmt.Sprintf("Successfully updated recurrence of template UUID: %s", templateUUID), uuid, "Update Recurrence")
+			return nil
+		},
+	}
+	acceptJob(w, GlobalJobQueue.AddJob(job))
+}
+
+// SkipRecurrenceHandler godoc
+// @Summary Skip the next instance of a recurring series
+// @Description Delete the upcoming pending instance of a series without affecting later instances
+// @Tags Recurrence
+// @Accept json
+// @Produce json
+// @Param uuid path string true "Template or instance UUID"
+// @Param recurrence body models.SkipRecurrenceRequestBody true "Credentials (injected from the session)"
+// @Success 202 {object} controllers.JobAcceptedResponse "Skip accepted for processing; poll /jobs/{id} for the outcome"
+// @Failure 400 {string} string "Bad request - invalid input or task is not recurring"
+// @Failure 404 {string} string "Task not found"
+// @Failure 405 {string} string "Method not allowed"
+// @Router /tasks/{uuid}/recurrence/skip [post]
+func SkipRecurrenceHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("error reading request body: %v", err), http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	var requestBody models.SkipRecurrenceRequestBody
+	if err := json.Unmarshal(body, &requestBody); err != nil {
+		http.Error(w, fmt.Sprintf("error decoding request body: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	email := requestBody.Email
+	encryptionSecret := requestBody.EncryptionSecret
+	uuid := requestBody.UUID
+	taskUUID, _ := splitTaskPath(r.URL.Path)
+
+	template, status, err := fetchRecurrenceTemplate(email, encryptionSecret, uuid, taskUUID)
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	templateUUID := template.UUID
+
+	logStore := models.GetLogStore()
+	job := Job{
+		Name:  "Skip Recurrence",
+		Owner: uuid,
+		Execute: func() error {
+			logStore.AddLog("INFO", fmt.Sprintf("Skipping next instance of template UUID: %s", templateUUID), uuid, "Skip Recurrence")
+			skipped, err := tw.SkipRecurrenceInTaskwarrior(email, encryptionSecret, uuid, templateUUID)
+			if err != nil {
+				logStore.AddLog("ERROR", fmt.Sprintf("Failed to skip instance of template UUID %s: %v", templateUUID, err), uuid, "Skip Recurrence")
+				return err
+			}
+			logStore.AddLog("INFO", fmt.Sprintf("Successfully skipped instance %s of template UUID: %s", skipped, templateUUID), uuid, "Skip Recurrence")
+			return nil
+		},
+	}
+	acceptJob(w, GlobalJobQueue.AddJob(job))
+}
+
+// StopRecurrenceHandler godoc
+// @Summary Stop a recurring series
+// @Description Delete the template of a series so no further instances are generated. Pending instances are kept unless deletePending is set.
+// @Tags Recurrence
+// @Accept json
+// @Produce json
+// @Param uuid path string true "Template or instance UUID"
+// @Param recurrence body models.StopRecurrenceRequestBody true "Stop options"
+// @Success 202 {object} controllers.JobAcceptedResponse "Stop accepted for processing; poll /jobs/{id} for the outcome"
+// @Failure 400 {string} string "Bad request - invalid input or task is not recurring"
+// @Failure 404 {string} string "Task not found"
+// @Failure 405 {string} string "Method not allowed"
+// @Router /tasks/{uuid}/recurrence/stop [post]
+func StopRecurrenceHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("error reading request body: %v", err), http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	var requestBody models.StopRecurrenceRequestBody
+	if err := json.Unmarshal(body, &requestBody); err != nil {
+		http.Error(w, fmt.Sprintf("error decoding request body: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	email := requestBody.Email
+	encryptionSecret := requestBody.EncryptionSecret
+	uuid := requestBody.UUID
+	deletePending := requestBody.DeletePending
+	taskUUID, _ := splitTaskPath(r.URL.Path)
+
+	template, status, err := fetchRecurrenceTemplate(email, encryptionSecret, uuid, taskUUID)
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	templateUUID := template.UUID
+
+	logStore := models.GetLogStore()
+	job := Job{
+		Name:  "Stop Recurrence",
+		Owner: uuid,
+		Execute: func() error {
+			logStore.AddLog("INFO", fmt.Sprintf("Stopping recurrence of template UUID: %s", templateUUID), uuid, "Stop Recurrence")
+			err := tw.StopRecurrenceInTaskwarrior(email, encryptionSecret, uuid, templateUUID, deletePending)
+			if err != nil {
+				logStore.AddLog("ERROR", fmt.Sprintf("Failed to stop recurrence of template UUID %s: %v", templateUUID, err), uuid, "Stop Recurrence")
+				return err
+			}
+			logStore.AddLog("INFO", fmt.Sprintf("Successfully stopped recurrence of template UUID: %s", templateUUID), uuid, "Stop Recurrence")
+			return nil
+		},
+	}
+	acceptJob(w, GlobalJobQueue.AddJob(job))
+}
+
+// fetchRecurrenceTemplate syncs the user's tasks and resolves the template of the series taskUUID belongs to
+func fetchRecurrenceTemplate(email, encryptionSecret, uuid, taskUUID string) (*models.Task, int, error) {
+	origin := os.Getenv("CONTAINER_ORIGIN")
+	tasks, err := tw.FetchTasksFromTaskwarrior(email, encryptionSecret, origin, uuid)
+	if err != nil || tasks == nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("Failed to fetch tasks at backend")
+	}
+	return findRecurrenceTemplate(tasks, taskUUID)
+}
+
+// findRecurrenceTemplate returns the template itself, or the parent of a recurring instance,
+// along with the HTTP status to report when there is none
+func findRecurrenceTemplate(tasks []models.Task, taskUUID string) (*models.Task, int, error) {
+	byUUID := make(map[string]*models.Task, len(tasks))
+	for i := range tasks {
+		byUUID[tasks[i].UUID] = &tasks[i]
+	}
+
+	task, ok := byUUID[taskUUID]
+	if !ok {
+		return nil, http.StatusNotFound, fmt.Errorf("Task not found")
+	}
+	if task.Status == "recurring" {
+		return task, http.StatusOK, nil
+	}
+	if task.Parent != "" {
+		if template, ok := byUUID[task.Parent]; ok {
+			return template, http.StatusOK, nil
+		}
+		return nil, http.StatusNotFound, fmt.Errorf("Recurrence template %s not found", task.Parent)
+	}
+	return nil, http.StatusBadRequest, fmt.Errorf("Task %s is not recurring", taskUUID)
+}
@@ -0,0 +1,123 @@
+package controllers
+
+import (
+	"ccsync_backend/models"
+	"ccsync_backend/utils/tw"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// ReopenTaskHandler godoc
+// @Summary Reopen a completed task
+// @Description Move a completed task back to pending and clear its end date. The job fails if the task's dependencies would form a cycle once it is pending again.
+// @Tags Tasks
+// @Accept json
+// @Produce json
+// @Param uuid path string true "Task UUID"
+// @Param task body models.ReopenTaskRequestBody true "Credentials (injected from the session)"
+// @Success 202 {object} controllers.JobAcceptedResponse "Task reopen accepted for processing; poll /jobs/{id} for the outcome"
+// @Failure 400 {string} string "Bad request - invalid input"
+// @Failure 405 {string} string "Method not allowed"
+// @Router /tasks/{uuid}/reopen [post]
+func ReopenTaskHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var requestBody models.ReopenTaskRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+		http.Error(w, fmt.Sprintf("error decoding request body: %v", err), http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	email := requestBody.Email
+	encryptionSecret := requestBody.EncryptionSecret
+	uuid := requestBody.UUID
+	taskuuid, _ := splitTaskPath(r.URL.Path)
+
+	logStore := models.GetLogStore()
+	job := Job{
+		Name:  "Reopen Task",
+		Owner: uuid,
+		Execute: func() error {
+			logStore.AddLog("INFO", fmt.Sprintf("Reopening task UUID: %s", taskuuid), uuid, "Reopen Task")
+			err := tw.ReopenTaskInTaskwarrior(email, encryptionSecret, uuid, taskuuid)
+			if err != nil {
+				logStore.AddLog("ERROR", fmt.Sprintf("Failed to reopen task UUID %s: %v", taskuuid, err), uuid, "Reopen Task")
+				return err
+			}
+			logStore.AddLog("INFO", fmt.Sprintf("Successfully reopened task UUID: %s", taskuuid), uuid, "Reopen Task")
+			return nil
+		},
+	}
+	acceptJob(w, GlobalJobQueue.AddJob(job))
+}
+
+// BulkReopenTaskHandler godoc
+// @Summary Bulk reopen tasks
+// @Description Move multiple completed tasks back to pending. Tasks that are not completed, or whose dependencies would form a cycle once pending again, are left unchanged and listed with the reason in the job result.
+// @Tags Tasks
+// @Accept json
+// @Produce json
+// @Param task body models.BulkReopenTaskRequestBody true "Bulk task reopen details"
+// @Success 202 {object} controllers.JobAcceptedResponse "Bulk task reopen accepted for processing; poll /jobs/{id} for per-task failures"
+// @Failure 400 {string} string "Invalid request - missing or empty taskuuids"
+// @Failure 405 {string} string "Method not allowed"
+// @Router /reopen-tasks [post]
+func BulkReopenTaskHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var requestBody models.BulkReopenTaskRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+		http.Error(w, fmt.Sprintf("error decoding request body: %v", err), http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	email := requestBody.Email
+	encryptionSecret := requestBody.EncryptionSecret
+	uuid := requestBody.UUID
+	taskUUIDs := requestBody.TaskUUIDs
+
+	if len(taskUUIDs) == 0 {
+		http.Error(w, "taskuuids is required and cannot be empty", http.StatusBadRequest)
+		return
+	}
+
+	var failedTasks map[string]string
+	logStore := models.GetLogStore()
+	job := Job{
+		Name:  "Bulk Reopen Tasks",
+		Owner: uuid,
+		Result: func() interface{} {
+			return map[string]interface{}{"failed": failedTasks}
+		},
+		Execute: func() error {
+			logStore.AddLog("INFO", fmt.Sprintf("[Bulk Reopen] Starting %d tasks", len(taskUUIDs)), uuid, "Bulk Reopen Task")
+
+			var err error
+			failedTasks, err = tw.ReopenTasksInTaskwarrior(email, encryptionSecret, uuid, taskUUIDs)
+
+			for taskUUID, errMsg := range failedTasks {
+				logStore.AddLog("ERROR", fmt.Sprintf("[Bulk Reopen] Failed: %s (%s)", taskUUID, errMsg), uuid, "Bulk Reopen Task")
+			}
+
+			if err != nil {
+				logStore.AddLog("ERROR", fmt.Sprintf("[Bulk Reopen] Sync error: %v", err), uuid, "Bulk Reopen Task")
+				return err
+			}
+
+			successCount := len(taskUUIDs) - len(failedTasks)
+			logStore.AddLog("INFO", fmt.Sprintf("[Bulk Reopen] Finished: %d succeeded, %d failed", successCount, len(failedTasks)), uuid, "Bulk Reopen Task")
+
+			return nil
+		},
+	}
+	acceptJob(w, GlobalJobQueue.AddJob(job))
+}
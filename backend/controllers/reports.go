@@ -0,0 +1,231 @@
+package controllers
+
+import (
+	"ccsync_backend/models"
+	"ccsync_backend/utils/filter"
+	"ccsync_backend/utils/reports"
+	"ccsync_backend/utils/tw"
+	"ccsync_backend/utils/urgency"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+)
+
+// defaultReportPeriods is how many periods a series report covers when no 'from' is given
+var defaultReportPeriods = map[reports.Period]int{
+	reports.Daily:   7,
+	reports.Weekly:  8,
+	reports.Monthly: 12,
+	reports.Yearly:  5,
+}
+
+// SummaryReportHandler godoc
+// @Summary Get project summary report
+// @Description Count pending (including waiting) and completed tasks per project, with the percentage complete and the average age of the pending tasks, like task summary. Projects include their subprojects; tasks without a project are grouped under (none).
+// @Tags Reports
+// @Produce json
+// @Param filter query string false "Filter expression selecting the tasks to report on"
+// @Param all query bool false "Include projects without pending tasks"
+// @Param X-User-Email header string true "User email"
+// @Param X-Encryption-Secret header string true "Encryption secret"
+// @Param X-User-UUID header string true "User UUID"
+// @Param X-Timezone header string false "IANA timezone in which dates of the filter are read (default: the timezone in the user's settings, else UTC)"
+// @Success 200 {object} reports.Summary "Project summary"
+// @Failure 400 {string} string "Missing required headers or invalid parameters"
+// @Failure 405 {string} string "Method not allowed"
+// @Failure 500 {string} string "Failed to fetch tasks at backend"
+// @Router /reports/summary [get]
+func SummaryReportHandler(w http.ResponseWriter, r *http.Request) {
+	all, err := optionalBool(r, "all")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	tasks, _, now, ok := reportTasks(w, r)
+	if !ok {
+		return
+	}
+	writeReport(w, reports.BuildSummary(tasks, now, all))
+}
+
+// BurndownReportHandler godoc
+// @Summary Get burndown report
+// @Description Count, at the end of each period, the tasks that were pending, started and done, like task burndown. Periods are computed in the user's timezone; the current period reflects the tasks as they are now.
+// @Tags Reports
+// @Produce json
+// @Param period query string false "daily (default), weekly (starting on Monday), monthly or yearly"
+// @Param from query string false "A day in the first period, YYYY-MM-DD (default: 7 days, 8 weeks, 12 months or 5 years before to)"
+// @Param to query string false "A day in the last period, YYYY-MM-DD (default: today)"
+// @Param filter query string false "Filter expression selecting the tasks to report on"
+// @Param X-User-Email header string true "User email"
+// @Param X-Encryption-Secret header string true "Encryption secret"
+// @Param X-User-UUID header string true "User UUID"
+// @Param X-Timezone header string false "IANA timezone of the periods (default: the timezone in the user's settings, else UTC)"
+// @Success 200 {object} reports.Burndown "Burndown series"
+// @Failure 400 {string} string "Missing required headers or invalid parameters"
+// @Failure 405 {string} string "Method not allowed"
+// @Failure 500 {string} string "Failed to fetch tasks at backend"
+// @Router /reports/burndown [get]
+func BurndownReportHandler(w http.ResponseWriter, r *http.Request) {
+	tasks, loc, now, ok := reportTasks(w, r)
+	if !ok {
+		return
+	}
+	period, buckets, err := reportBuckets(r, now, loc)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	writeReport(w, reports.BuildBurndown(tasks, buckets, period, now))
+}
+
+// HistoryReportHandler godoc
+// @Summary Get history report
+// @Description Count the tasks added, completed and deleted in each period, like task history. Periods are computed in the user's timezone.
+// @Tags Reports
+// @Produce json
+// @Param period query string false "daily (default), weekly (starting on Monday), monthly or yearly"
+// @Param from query string false "A day in the first period, YYYY-MM-DD (default: 7 days, 8 weeks, 12 months or 5 years before to)"
+// @Param to query string false "A day in the last period, YYYY-MM-DD (default: today)"
+// @Param filter query string false "Filter expression selecting the tasks to report on"
+// @Param X-User-Email header string true "User email"
+// @Param X-Encryption-Secret header string true "Encryption secret"
+// @Param X-User-UUID header string true "User UUID"
+// @Param X-Timezone header string false "IANA timezone of the periods (default: the timezone in the user's settings, else UTC)"
+// @Success 200 {object} reports.History "History series"
+// @Failure 400 {string} string "Missing required headers or invalid parameters"
+// @Failure 405 {string} string "Method not allowed"
+// @Failure 500 {string} string "Failed to fetch tasks at backend"
+// @Router /reports/history [get]
+func HistoryReportHandler(w http.ResponseWriter, r *http.Request) {
+	tasks, loc, now, ok := reportTasks(w, r)
+	if !ok {
+		return
+	}
+	period, buckets, err := reportBuckets(r, now, loc)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	writeReport(w, reports.BuildHistory(tasks, buckets, period))
+}
+
+// TagsReportHandler godoc
+// @Summary Get tag report
+// @Description Count the tasks carrying each tag, most used first, like task tags
+// @Tags Reports
+// @Produce json
+// @Param filter query string false "Filter expression selecting the tasks to report on"
+// @Param includeClosed query bool false "Count completed and deleted tasks too (default: false)"
+// @Param X-User-Email header string true "User email"
+// @Param X-Encryption-Secret header string true "Encryption secret"
+// @Param X-User-UUID header string true "User UUID"
+// @Param X-Timezone header string false "IANA timezone in which dates of the filter are read (default: the timezone in the user's settings, else UTC)"
+// @Success 200 {array} reports.TagCount "Tag counts"
+// @Failure 400 {string} string "Missing required headers or invalid parameters"
+// @Failure 405 {string} string "Method not allowed"
+// @Failure 500 {string} string "Failed to fetch tasks at backend"
+// @Router /reports/tags [get]
+func TagsReportHandler(w http.ResponseWriter, r *http.Request) {
+	includeClosed, err := optionalBool(r, "includeClosed")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	tasks, _, _, ok := reportTasks(w, r)
+	if !ok {
+		return
+	}
+	if !includeClosed {
+		open := []models.Task{}
+		for _, task := range tasks {
+			if task.Status == "pending" || task.Status == "waiting" {
+				open = append(open, task)
+			}
+		}
+		tasks = open
+	}
+	writeReport(w, reports.BuildTagCounts(tasks))
+}
+
+// reportTasks fetches the user's tasks for a report, narrowed down by the filter query
+// parameter, along with the user's location and the reference time. On failure it
+// writes the error response and returns false.
+func reportTasks(w http.ResponseWriter, r *http.Request) ([]models.Task, *time.Location, time.Time, bool) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return nil, nil, time.Time{}, false
+	}
+
+	email := r.Header.Get("X-User-Email")
+	encryptionSecret := r.Header.Get("X-Encryption-Secret")
+	UUID := r.Header.Get("X-User-UUID")
+
+	if email == "" || encryptionSecret == "" || UUID == "" {
+		http.Error(w, "Missing required security headers", http.StatusBadRequest)
+		return nil, nil, time.Time{}, false
+	}
+
+	taskFilter, err := filter.Parse(r.URL.Query().Get("filter"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, nil, time.Time{}, false
+	}
+	loc, err := requestLocation(r, UUID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, nil, time.Time{}, false
+	}
+
+	origin := os.Getenv("CONTAINER_ORIGIN")
+	tasks, err := tw.FetchTasksFromTaskwarrior(email, encryptionSecret, origin, UUID)
+	if err != nil || tasks == nil {
+		http.Error(w, "Failed to fetch tasks at backend", http.StatusInternalServerError)
+		return nil, nil, time.Time{}, false
+	}
+
+	now := time.Now()
+	urgency.Apply(tasks, userUrgencyCoefficients(UUID), now)
+	return taskFilter.Apply(tasks, now, loc), loc, now, true
+}
+
+// reportBuckets reads the period, from and to query parameters of a series report
+func reportBuckets(r *http.Request, now time.Time, loc *time.Location) (reports.Period, []reports.Bucket, error) {
+	period, err := reports.ParsePeriod(r.URL.Query().Get("period"))
+	if err != nil {
+		return "", nil, err
+	}
+
+	fromParam := r.URL.Query().Get("from")
+	from, to, err := parseReportRange(fromParam, r.URL.Query().Get("to"), now.In(loc), loc)
+	if err != nil {
+		return "", nil, err
+	}
+	if fromParam == "" {
+		last := period.Start(to.Add(-time.Nanosecond), loc)
+		from = period.Add(last, 1-defaultReportPeriods[period])
+	}
+
+	buckets, err := reports.Buckets(from, to, period, loc)
+	return period, buckets, err
+}
+
+func optionalBool(r *http.Request, name string) (bool, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return false, nil
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("Invalid '%s' parameter, expected true or false", name)
+	}
+	return parsed, nil
+}
+
+func writeReport(w http.ResponseWriter, report interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
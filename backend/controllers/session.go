@@ -0,0 +1,24 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gorilla/sessions"
+)
+
+// sessionCredentials returns the credentials of the user the request's session belongs
+// to. Unlike the X-User-* headers, the client cannot set them to another user's.
+func sessionCredentials(store *sessions.CookieStore, r *http.Request) (email, encryptionSecret, uuid string, ok bool) {
+	session, err := store.Get(r, "session-name")
+	if err != nil {
+		return "", "", "", false
+	}
+	userInfo, isUser := session.Values["user"].(map[string]interface{})
+	if !isUser || userInfo == nil {
+		return "", "", "", false
+	}
+	email, _ = userInfo["email"].(string)
+	encryptionSecret, _ = userInfo["encryption_secret"].(string)
+	uuid, _ = userInfo["uuid"].(string)
+	return email, encryptionSecret, uuid, uuid != ""
+}
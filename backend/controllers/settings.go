@@ -0,0 +1,142 @@
+package controllers
+
+import (
+	"ccsync_backend/models"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/sessions"
+)
+
+// TimezoneSettingsHandler godoc
+// @Summary Get or set the user's timezone
+// @Description GET returns the timezone in which the user's dates are read and day boundaries computed, POST sets it. Dates sent without an explicit offset, such as "2025-04-01" or "tomorrow 9am", are taken in this timezone and stored in Taskwarrior as UTC. An X-Timezone header on a request overrides it for that request. An empty timezone resets it to UTC.
+// @Tags Settings
+// @Accept json
+// @Produce json
+// @Param settings body models.TimezoneSettingsRequestBody false "New timezone (POST)"
+// @Success 200 {object} models.UserSettings "Current settings"
+// @Failure 400 {string} string "Unknown timezone"
+// @Failure 401 {string} string "Authentication required"
+// @Failure 405 {string} string "Method not allowed"
+// @Router /settings/timezone [get]
+// @Router /settings/timezone [post]
+func TimezoneSettingsHandler(store *sessions.CookieStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		settings := models.GetSettingsStore()
+
+		switch r.Method {
+		case http.MethodGet:
+			_, _, UUID, ok := sessionCredentials(store, r)
+			if !ok {
+				http.Error(w, "Authentication required", http.StatusUnauthorized)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(settings.Get(UUID))
+
+		case http.MethodPost:
+			var requestBody models.TimezoneSettingsRequestBody
+			if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+				http.Error(w, fmt.Sprintf("error decoding request body: %v", err), http.StatusBadRequest)
+				return
+			}
+			defer r.Body.Close()
+
+			if requestBody.UUID == "" {
+				http.Error(w, "UUID is required", http.StatusBadRequest)
+				return
+			}
+			timezone := requestBody.Timezone
+			if timezone != "" {
+				loc, err := time.LoadLocation(timezone)
+				if err != nil {
+					http.Error(w, fmt.Sprintf("Unknown timezone '%s'", timezone), http.StatusBadRequest)
+					return
+				}
+				timezone = loc.String()
+			}
+
+			updated := settings.Update(requestBody.UUID, func(s *models.UserSettings) {
+				s.Timezone = timezone
+			})
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(updated)
+
+		default:
+			http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+// TaskrcSettingsResponse lists the rc settings saved for the user
+type TaskrcSettingsResponse struct {
+	Settings map[string]string `json:"settings"`
+}
+
+// TaskrcSettingsHandler godoc
+// @Summary Get or set the user's Taskwarrior settings
+// @Description GET returns the rc settings saved for the user, POST replaces them. They are applied to every Taskwarrior replica ccsync creates for the user. Only these keys are accepted: default.project, default.due, default.scheduled, dateformat, dateformat.report, dateformat.annotation, weekstart, displayweeknumber, due, recurrence, recurrence.limit, recurrence.indicator, search.case.sensitive, regex, and the type, label, values, default and indicator of user-defined attributes (uda.<name>.<attribute>). Send an empty object to clear them.
+// @Tags Settings
+// @Accept json
+// @Produce json
+// @Param settings body models.TaskrcSettingsRequestBody false "rc settings (POST)"
+// @Success 200 {object} controllers.TaskrcSettingsResponse "Saved settings"
+// @Failure 400 {string} string "A setting that is not allowed or invalid"
+// @Failure 401 {string} string "Authentication required"
+// @Failure 405 {string} string "Method not allowed"
+// @Router /settings/taskrc [get]
+// @Router /settings/taskrc [post]
+func TaskrcSettingsHandler(store *sessions.CookieStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		settings := models.GetSettingsStore()
+
+		var taskrc map[string]string
+		switch r.Method {
+		case http.MethodGet:
+			_, _, UUID, ok := sessionCredentials(store, r)
+			if !ok {
+				http.Error(w, "Authentication required", http.StatusUnauthorized)
+				return
+			}
+			taskrc = settings.Get(UUID).Taskrc
+
+		case http.MethodPost:
+			var requestBody models.TaskrcSettingsRequestBody
+			if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+				http.Error(w, fmt.Sprintf("error decoding request body: %v", err), http.StatusBadRequest)
+				return
+			}
+			defer r.Body.Close()
+
+			if requestBody.UUID == "" {
+				http.Error(w, "UUID is required", http.StatusBadRequest)
+				return
+			}
+			if err := models.ValidateTaskrcSettings(requestBody.Settings); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			taskrc = settings.Update(requestBody.UUID, func(s *models.UserSettings) {
+				s.Taskrc = nil
+				if len(requestBody.Settings) > 0 {
+					s.Taskrc = requestBody.Settings
+				}
+			}).Taskrc
+
+		default:
+			http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		if taskrc == nil {
+			taskrc = map[string]string{}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(TaskrcSettingsResponse{Settings: taskrc})
+	}
+}
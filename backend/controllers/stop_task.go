@@ -0,0 +1,65 @@
+package controllers
+
+import (
+	"ccsync_backend/models"
+	"ccsync_backend/utils/tw"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// StopTaskHandler godoc
+// @Summary Stop a task
+// @Description Stop an active task, closing its current work interval
+// @Tags Tasks
+// @Accept json
+// @Produce json
+// @Param uuid path string true "Task UUID"
+// @Param task body models.StopTaskRequestBody true "Credentials (injected from the session)"
+// @Success 202 {object} controllers.JobAcceptedResponse "Task stop accepted for processing; poll /jobs/{id} for the outcome"
+// @Failure 400 {string} string "Bad request - invalid input"
+// @Failure 405 {string} string "Method not allowed"
+// @Router /tasks/{uuid}/stop [post]
+func StopTaskHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodPost {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("error reading request body: %v", err), http.StatusBadRequest)
+			return
+		}
+		defer r.Body.Close()
+
+		var requestBody models.StopTaskRequestBody
+
+		err = json.Unmarshal(body, &requestBody)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("error decoding request body: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		email := requestBody.Email
+		encryptionSecret := requestBody.EncryptionSecret
+		uuid := requestBody.UUID
+		taskuuid, _ := splitTaskPath(r.URL.Path)
+
+		logStore := models.GetLogStore()
+		job := Job{
+			Name:  "Stop Task",
+			Owner: uuid,
+			Execute: func() error {
+				logStore.AddLog("INFO", fmt.Sprintf("Stopping task UUID: %s", taskuuid), uuid, "Stop Task")
+				err := tw.StopTaskInTaskwarrior(email, encryptionSecret, uuid, taskuuid)
+				if err != nil {
+					logStore.AddLog("ERROR", fmt.Sprintf("Failed to stop task UUID %s: %v", taskuuid, err), uuid, "Stop Task")
+					return err
+				}
+				logStore.AddLog("INFO", fmt.Sprintf("Successfully stopped task UUID: %s", taskuuid), uuid, "Stop Task")
+				return nil
+			},
+		}
+		acceptJob(w, GlobalJobQueue.AddJob(job))
+		return
+	}
+	http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+}
@@ -0,0 +1,63 @@
+package controllers
+
+import (
+	"ccsync_backend/utils/query"
+	"ccsync_backend/utils/tw"
+	"encoding/json"
+	"net/http"
+	"os"
+)
+
+// TaskChangesHandler godoc
+// @Summary Get task changes since a cursor
+// @Description Return the tasks created, modified or deleted since a cursor, along with the cursor to pass next time. Without a cursor every task is returned as created. Tasks purged from the replica are not reported.
+// @Tags Tasks
+// @Accept json
+// @Produce json
+// @Param since query string false "Cursor returned by the previous call"
+// @Param X-User-Email header string true "User email"
+// @Param X-Encryption-Secret header string true "Encryption secret"
+// @Param X-User-UUID header string true "User UUID"
+// @Success 200 {object} query.Changes "Changed tasks and the next cursor"
+// @Failure 400 {string} string "Missing required headers or invalid cursor"
+// @Failure 405 {string} string "Method not allowed"
+// @Failure 500 {string} string "Failed to fetch tasks at backend"
+// @Router /tasks/changes [get]
+func TaskChangesHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	email := r.Header.Get("X-User-Email")
+	encryptionSecret := r.Header.Get("X-Encryption-Secret")
+	UUID := r.Header.Get("X-User-UUID")
+
+	if email == "" || encryptionSecret == "" || UUID == "" {
+		http.Error(w, "Missing required security headers", http.StatusBadRequest)
+		return
+	}
+
+	since := r.URL.Query().Get("since")
+	// validate the cursor before paying for a sync
+	if _, err := query.ChangesSince(nil, since); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	origin := os.Getenv("CONTAINER_ORIGIN")
+	tasks, err := tw.FetchTasksFromTaskwarrior(email, encryptionSecret, origin, UUID)
+	if err != nil || tasks == nil {
+		http.Error(w, "Failed to fetch tasks at backend", http.StatusInternalServerError)
+		return
+	}
+
+	changes, err := query.ChangesSince(tasks, since)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(changes)
+}
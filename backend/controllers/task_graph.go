@@ -0,0 +1,198 @@
+package controllers
+
+import (
+	"ccsync_backend/models"
+	"ccsync_backend/utils"
+	"ccsync_backend/utils/tw"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// GraphNode is a task in the dependency graph
+type GraphNode struct {
+	UUID        string `json:"uuid"`
+	Description string `json:"description"`
+	Status      string `json:"status"`
+	Blocked     bool   `json:"blocked"`  // waits on an open dependency
+	Blocking    bool   `json:"blocking"` // an open task waits on it
+}
+
+// GraphEdge records that Task depends on DependsOn
+type GraphEdge struct {
+	Task      string `json:"task"`
+	DependsOn string `json:"dependsOn"`
+}
+
+// DependencyGraphResponse is the dependency graph of the user's open tasks
+type DependencyGraphResponse struct {
+	Nodes        []GraphNode                `json:"nodes"`
+	Edges        []GraphEdge                `json:"edges"`
+	Dangling     []utils.DanglingDependency `json:"dangling"`
+	CriticalPath []string                   `json:"criticalPath,omitempty"`
+}
+
+// TaskGraphHandler godoc
+// @Summary Get the task dependency graph
+// @Description Return the dependency graph of the open tasks and of the tasks they depend on, with blocked/blocking flags, depends entries pointing at deleted or unknown tasks, and optionally the critical path to a task: the longest chain of open dependencies that must be finished one after another before it. With format=dot the graph is returned in Graphviz DOT, edges pointing from a dependency to the task waiting on it.
+// @Tags Tasks
+// @Produce json
+// @Produce plain
+// @Param X-User-Email header string true "User email"
+// @Param X-Encryption-Secret header string true "Encryption secret"
+// @Param X-User-UUID header string true "User UUID"
+// @Param format query string false "json (default) or dot"
+// @Param target query string false "UUID of the task to compute the critical path to"
+// @Success 200 {object} controllers.DependencyGraphResponse "Dependency graph"
+// @Failure 400 {string} string "Missing required headers or invalid format"
+// @Failure 404 {string} string "Target task not found"
+// @Failure 405 {string} string "Method not allowed"
+// @Failure 500 {string} string "Failed to fetch tasks at backend"
+// @Router /tasks/graph [get]
+func TaskGraphHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	email := r.Header.Get("X-User-Email")
+	encryptionSecret := r.Header.Get("X-Encryption-Secret")
+	UUID := r.Header.Get("X-User-UUID")
+
+	if email == "" || encryptionSecret == "" || UUID == "" {
+		http.Error(w, "Missing required security headers", http.StatusBadRequest)
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "json" && format != "dot" {
+		http.Error(w, fmt.Sprintf("Invalid format '%s': expected json or dot", format), http.StatusBadRequest)
+		return
+	}
+
+	origin := os.Getenv("CONTAINER_ORIGIN")
+	tasks, err := tw.FetchTasksFromTaskwarrior(email, encryptionSecret, origin, UUID)
+	if err != nil || tasks == nil {
+		http.Error(w, "Failed to fetch tasks at backend", http.StatusInternalServerError)
+		return
+	}
+
+	target := r.URL.Query().Get("target")
+	if target != "" {
+		found := false
+		for _, task := range tasks {
+			if task.UUID == target {
+				found = true
+				break
+			}
+		}
+		if !found {
+			http.Error(w, "Target task not found", http.StatusNotFound)
+			return
+		}
+	}
+
+	graph := buildDependencyGraph(tasks, target)
+
+	if format == "dot" {
+		w.Header().Set("Content-Type", "text/vnd.graphviz; charset=utf-8")
+		w.Write([]byte(graph.DOT()))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(graph)
+}
+
+// buildDependencyGraph lays out the open tasks and the existing tasks they depend on,
+// with the critical path to the target task if one is given
+func buildDependencyGraph(tasks []models.Task, target string) DependencyGraphResponse {
+	graph := utils.NewDependencyGraph(models.TaskDependencies(tasks))
+	byUUID := make(map[string]models.Task, len(tasks))
+	for _, task := range tasks {
+		byUUID[task.UUID] = task
+	}
+
+	response := DependencyGraphResponse{Nodes: []GraphNode{}, Edges: []GraphEdge{}, Dangling: graph.Dangling()}
+	included := make(map[string]bool)
+	addNode := func(task models.Task) {
+		if included[task.UUID] {
+			return
+		}
+		included[task.UUID] = true
+		response.Nodes = append(response.Nodes, GraphNode{
+			UUID:        task.UUID,
+			Description: task.Description,
+			Status:      task.Status,
+			Blocked:     graph.Blocked(task.UUID),
+			Blocking:    graph.Blocking(task.UUID),
+		})
+	}
+
+	for _, task := range tasks {
+		if !graph.IsOpen(task.UUID) {
+			continue
+		}
+		addNode(task)
+		for _, dep := range task.Depends {
+			depTask, ok := byUUID[dep]
+			if !ok || depTask.Status == "deleted" {
+				continue // reported as dangling
+			}
+			response.Edges = append(response.Edges, GraphEdge{Task: task.UUID, DependsOn: dep})
+		}
+	}
+	for _, edge := range response.Edges {
+		addNode(byUUID[edge.DependsOn])
+	}
+
+	if target != "" {
+		response.CriticalPath = graph.CriticalPath(target)
+	}
+
+	return response
+}
+
+// DOT renders the graph in Graphviz DOT. Blocked tasks are drawn in red, closed
+// tasks dashed and the critical path in bold.
+func (g DependencyGraphResponse) DOT() string {
+	onPath := make(map[string]bool)
+	pathEdges := make(map[GraphEdge]bool)
+	for i, uuid := range g.CriticalPath {
+		onPath[uuid] = true
+		if i > 0 {
+			pathEdges[GraphEdge{Task: uuid, DependsOn: g.CriticalPath[i-1]}] = true
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("digraph tasks {\n\trankdir=LR;\n\tnode [shape=box];\n")
+	for _, node := range g.Nodes {
+		attrs := []string{fmt.Sprintf("label=%s", dotQuote(node.Description))}
+		if node.Blocked {
+			attrs = append(attrs, "color=red")
+		}
+		if node.Status != "pending" && node.Status != "waiting" {
+			attrs = append(attrs, "style=dashed")
+		} else if onPath[node.UUID] {
+			attrs = append(attrs, "style=bold")
+		}
+		fmt.Fprintf(&b, "\t%s [%s];\n", dotQuote(node.UUID), strings.Join(attrs, ", "))
+	}
+	for _, edge := range g.Edges {
+		attrs := ""
+		if pathEdges[edge] {
+			attrs = " [style=bold]"
+		}
+		fmt.Fprintf(&b, "\t%s -> %s%s;\n", dotQuote(edge.DependsOn), dotQuote(edge.Task), attrs)
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+func dotQuote(value string) string {
+	escaped := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+	return `"` + escaped + `"`
+}
@@ -0,0 +1,67 @@
+package controllers
+
+import (
+	"ccsync_backend/models"
+	"ccsync_backend/utils/tw"
+	"encoding/json"
+	"net/http"
+	"os"
+)
+
+// TaskHistoryHandler godoc
+// @Summary Get task change history
+// @Description Return the journaled attribute changes of a task in chronological order, attributed to the ccsync operation that made them or to an external client picked up during sync
+// @Tags Tasks
+// @Accept json
+// @Produce json
+// @Param uuid path string true "Task UUID"
+// @Param X-User-Email header string true "User email"
+// @Param X-Encryption-Secret header string true "Encryption secret"
+// @Param X-User-UUID header string true "User UUID"
+// @Success 200 {array} models.HistoryEntry "Task change history"
+// @Failure 400 {string} string "Missing required headers"
+// @Failure 404 {string} string "Task not found"
+// @Failure 405 {string} string "Method not allowed"
+// @Failure 500 {string} string "Failed to fetch tasks at backend"
+// @Router /tasks/{uuid}/history [get]
+func TaskHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	email := r.Header.Get("X-User-Email")
+	encryptionSecret := r.Header.Get("X-Encryption-Secret")
+	UUID := r.Header.Get("X-User-UUID")
+	taskUUID, _ := splitTaskPath(r.URL.Path)
+
+	if email == "" || encryptionSecret == "" || UUID == "" {
+		http.Error(w, "Missing required security headers", http.StatusBadRequest)
+		return
+	}
+
+	// Fetching syncs the replica, which journals any changes made by other clients since the last look
+	origin := os.Getenv("CONTAINER_ORIGIN")
+	tasks, err := tw.FetchTasksFromTaskwarrior(email, encryptionSecret, origin, UUID)
+	if err != nil || tasks == nil {
+		http.Error(w, "Failed to fetch tasks at backend", http.StatusInternalServerError)
+		return
+	}
+
+	found := false
+	for _, task := range tasks {
+		if task.UUID == taskUUID {
+			found = true
+			break
+		}
+	}
+	if !found {
+		http.Error(w, "Task not found", http.StatusNotFound)
+		return
+	}
+
+	history := models.GetHistoryStore().GetTaskHistory(UUID, taskUUID)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(history)
+}
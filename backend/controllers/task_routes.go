@@ -0,0 +1,82 @@
+package controllers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/sessions"
+)
+
+// TaskRoutesHandler dispatches /tasks/changes, /tasks/graph and the per-task sub-resources mounted under /tasks/{uuid}/
+func TaskRoutesHandler(store *sessions.CookieStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		taskUUID, resource := splitTaskPath(r.URL.Path)
+		if taskUUID == "changes" && len(resource) == 0 {
+			TaskChangesHandler(w, r)
+			return
+		}
+		if taskUUID == "graph" && len(resource) == 0 {
+			TaskGraphHandler(w, r)
+			return
+		}
+		if taskUUID == "" || len(resource) == 0 {
+			http.NotFound(w, r)
+			return
+		}
+
+		switch resource[0] {
+		case "history":
+			TaskHistoryHandler(w, r)
+		case "urgency":
+			TaskUrgencyHandler(store)(w, r)
+		case "start":
+			StartTaskHandler(w, r)
+		case "stop":
+			StopTaskHandler(w, r)
+		case "restore":
+			RestoreTaskHandler(w, r)
+		case "reopen":
+			ReopenTaskHandler(w, r)
+		case "recurrence":
+			recurrenceRoutes(w, r, resource[1:])
+		case "annotations":
+			if len(resource) > 1 {
+				DeleteAnnotationHandler(store)(w, r)
+			} else {
+				AddAnnotationHandler(w, r)
+			}
+		default:
+			http.NotFound(w, r)
+		}
+	}
+}
+
+func recurrenceRoutes(w http.ResponseWriter, r *http.Request, resource []string) {
+	if len(resource) == 0 {
+		if r.Method == http.MethodPost {
+			UpdateRecurrenceHandler(w, r)
+		} else {
+			RecurrenceHandler(w, r)
+		}
+		return
+	}
+
+	switch resource[0] {
+	case "skip":
+		SkipRecurrenceHandler(w, r)
+	case "stop":
+		StopRecurrenceHandler(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// splitTaskPath splits /tasks/{uuid}/{resource...} into the task UUID and the remaining segments
+func splitTaskPath(path string) (string, []string) {
+	trimmed := strings.Trim(strings.TrimPrefix(path, "/tasks/"), "/")
+	if trimmed == "" {
+		return "", nil
+	}
+	parts := strings.Split(trimmed, "/")
+	return parts[0], parts[1:]
+}
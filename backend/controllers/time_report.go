@@ -0,0 +1,95 @@
+package controllers
+
+import (
+	"ccsync_backend/utils/reports"
+	"ccsync_backend/utils/tw"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+)
+
+// TimeReportHandler godoc
+// @Summary Get tracked time report
+// @Description Aggregate the work intervals recorded by starting and stopping tasks by day, project and tag for a date range
+// @Tags Reports
+// @Accept json
+// @Produce json
+// @Param from query string false "First day of the range, YYYY-MM-DD (default: 6 days before to)"
+// @Param to query string false "Last day of the range, inclusive, YYYY-MM-DD (default: today)"
+// @Param X-User-Email header string true "User email"
+// @Param X-Encryption-Secret header string true "Encryption secret"
+// @Param X-User-UUID header string true "User UUID"
+// @Param X-Timezone header string false "IANA timezone of the days and of from and to (default: the timezone in the user's settings, else UTC)"
+// @Success 200 {object} reports.TimeReport "Tracked time report"
+// @Failure 400 {string} string "Missing required headers, invalid date range or unknown timezone"
+// @Failure 405 {string} string "Method not allowed"
+// @Failure 500 {string} string "Failed to fetch tasks at backend"
+// @Router /reports/time [get]
+func TimeReportHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	email := r.Header.Get("X-User-Email")
+	encryptionSecret := r.Header.Get("X-Encryption-Secret")
+	UUID := r.Header.Get("X-User-UUID")
+
+	if email == "" || encryptionSecret == "" || UUID == "" {
+		http.Error(w, "Missing required security headers", http.StatusBadRequest)
+		return
+	}
+
+	loc, err := requestLocation(r, UUID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	now := time.Now().In(loc)
+	from, to, err := parseReportRange(r.URL.Query().Get("from"), r.URL.Query().Get("to"), now, loc)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	origin := os.Getenv("CONTAINER_ORIGIN")
+	tasks, err := tw.FetchTasksFromTaskwarrior(email, encryptionSecret, origin, UUID)
+	if err != nil || tasks == nil {
+		http.Error(w, "Failed to fetch tasks at backend", http.StatusInternalServerError)
+		return
+	}
+
+	report := reports.BuildTimeReport(tasks, from, to, now, loc)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// parseReportRange turns inclusive YYYY-MM-DD bounds into a half-open [from, to) range,
+// defaulting to the last seven days
+func parseReportRange(fromParam, toParam string, now time.Time, loc *time.Location) (time.Time, time.Time, error) {
+	to := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, loc)
+	if toParam != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", toParam, loc)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("Invalid 'to' parameter, expected YYYY-MM-DD")
+		}
+		to = parsed.AddDate(0, 0, 1)
+	}
+
+	from := to.AddDate(0, 0, -7)
+	if fromParam != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", fromParam, loc)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("Invalid 'from' parameter, expected YYYY-MM-DD")
+		}
+		from = parsed
+	}
+
+	if !from.Before(to) {
+		return time.Time{}, time.Time{}, fmt.Errorf("Invalid range: 'from' must not be after 'to'")
+	}
+	return from, to, nil
+}
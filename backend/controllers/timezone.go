@@ -0,0 +1,50 @@
+package controllers
+
+import (
+	"ccsync_backend/models"
+	"ccsync_backend/utils"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// requestLocation returns the timezone in which the dates of a request are read: the
+// one named by the X-Timezone header (an IANA name such as Europe/Berlin) if given,
+// else the timezone saved in the user's settings, else UTC
+func requestLocation(r *http.Request, userUUID string) (*time.Location, error) {
+	if name := r.Header.Get("X-Timezone"); name != "" {
+		loc, err := time.LoadLocation(name)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid X-Timezone '%s'", name)
+		}
+		return loc, nil
+	}
+	if name := models.GetSettingsStore().Get(userUUID).Timezone; name != "" {
+		if loc, err := time.LoadLocation(name); err == nil {
+			return loc, nil
+		}
+	}
+	return time.UTC, nil
+}
+
+// dateField is a date of a request body, named for error messages
+type dateField struct {
+	name  string
+	value *string
+}
+
+// normalizeDateInputs resolves the date expressions among the fields in place, so that
+// they are evaluated at request time rather than when the job runs
+func normalizeDateInputs(now time.Time, fields ...dateField) error {
+	for _, field := range fields {
+		if field.value == nil {
+			continue
+		}
+		normalized, err := utils.NormalizeDateInput(*field.value, now)
+		if err != nil {
+			return fmt.Errorf("Invalid %s date format: %v", field.name, err)
+		}
+		*field.value = normalized
+	}
+	return nil
+}
@@ -0,0 +1,190 @@
+package controllers
+
+import (
+	"ccsync_backend/models"
+	"ccsync_backend/utils"
+	"ccsync_backend/utils/tw"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"time"
+)
+
+// TrashHandler godoc
+// @Summary List deleted tasks
+// @Description Return the user's deleted tasks with the time they were deleted, most recently deleted first
+// @Tags Tasks
+// @Produce json
+// @Param X-User-Email header string true "User email"
+// @Param X-Encryption-Secret header string true "Encryption secret"
+// @Param X-User-UUID header string true "User UUID"
+// @Success 200 {array} models.TrashedTask "Deleted tasks"
+// @Failure 400 {string} string "Missing required headers"
+// @Failure 405 {string} string "Method not allowed"
+// @Failure 500 {string} string "Failed to fetch tasks at backend"
+// @Router /trash [get]
+func TrashHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	email := r.Header.Get("X-User-Email")
+	encryptionSecret := r.Header.Get("X-Encryption-Secret")
+	UUID := r.Header.Get("X-User-UUID")
+
+	if email == "" || encryptionSecret == "" || UUID == "" {
+		http.Error(w, "Missing required security headers", http.StatusBadRequest)
+		return
+	}
+
+	origin := os.Getenv("CONTAINER_ORIGIN")
+	tasks, err := tw.FetchTasksFromTaskwarrior(email, encryptionSecret, origin, UUID)
+	if err != nil || tasks == nil {
+		http.Error(w, "Failed to fetch tasks at backend", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(trashedTasks(tasks))
+}
+
+// trashedTasks picks the deleted tasks, most recently deleted first. Taskwarrior
+// records the deletion time as the task's end date.
+func trashedTasks(tasks []models.Task) []models.TrashedTask {
+	trash := []models.TrashedTask{}
+	for _, task := range tasks {
+		if task.Status == "deleted" {
+			trash = append(trash, models.TrashedTask{Task: task, DeletedAt: task.End})
+		}
+	}
+	sort.SliceStable(trash, func(i, j int) bool {
+		return trash[i].DeletedAt > trash[j].DeletedAt
+	})
+	return trash
+}
+
+// RestoreTaskHandler godoc
+// @Summary Restore a deleted task
+// @Description Bring a deleted task back to pending, clearing the end date set when it was deleted
+// @Tags Tasks
+// @Accept json
+// @Produce json
+// @Param uuid path string true "Task UUID"
+// @Param task body models.RestoreTaskRequestBody true "Credentials (injected from the session)"
+// @Success 202 {object} controllers.JobAcceptedResponse "Task restore accepted for processing; poll /jobs/{id} for the outcome"
+// @Failure 400 {string} string "Bad request - invalid input"
+// @Failure 405 {string} string "Method not allowed"
+// @Router /tasks/{uuid}/restore [post]
+func RestoreTaskHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var requestBody models.RestoreTaskRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+		http.Error(w, fmt.Sprintf("error decoding request body: %v", err), http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	email := requestBody.Email
+	encryptionSecret := requestBody.EncryptionSecret
+	uuid := requestBody.UUID
+	taskuuid, _ := splitTaskPath(r.URL.Path)
+
+	logStore := models.GetLogStore()
+	job := Job{
+		Name:  "Restore Task",
+		Owner: uuid,
+		Execute: func() error {
+			logStore.AddLog("INFO", fmt.Sprintf("Restoring task UUID: %s", taskuuid), uuid, "Restore Task")
+			err := tw.RestoreTaskInTaskwarrior(email, encryptionSecret, uuid, taskuuid)
+			if err != nil {
+				logStore.AddLog("ERROR", fmt.Sprintf("Failed to restore task UUID %s: %v", taskuuid, err), uuid, "Restore Task")
+				return err
+			}
+			logStore.AddLog("INFO", fmt.Sprintf("Successfully restored task UUID: %s", taskuuid), uuid, "Restore Task")
+			return nil
+		},
+	}
+	acceptJob(w, GlobalJobQueue.AddJob(job))
+}
+
+// PurgeTrashHandler godoc
+// @Summary Permanently remove deleted tasks
+// @Description Purge deleted tasks for good: the given ones, or every task deleted longer ago than olderThan, a duration such as "30d". Tasks that are not deleted are never purged. The job result lists the purged tasks and the reason for each task that was not.
+// @Tags Tasks
+// @Accept json
+// @Produce json
+// @Param task body models.PurgeTasksRequestBody true "Tasks to purge, or the minimum age of deletions to purge"
+// @Success 202 {object} controllers.JobAcceptedResponse "Purge accepted for processing; poll /jobs/{id} for per-task results"
+// @Failure 400 {string} string "Invalid request - missing taskuuids or olderThan, or invalid olderThan"
+// @Failure 405 {string} string "Method not allowed"
+// @Router /trash/purge [post]
+func PurgeTrashHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var requestBody models.PurgeTasksRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+		http.Error(w, fmt.Sprintf("error decoding request body: %v", err), http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	email := requestBody.Email
+	encryptionSecret := requestBody.EncryptionSecret
+	uuid := requestBody.UUID
+	taskUUIDs := requestBody.TaskUUIDs
+
+	if (len(taskUUIDs) == 0) == (requestBody.OlderThan == "") {
+		http.Error(w, "exactly one of taskuuids or olderThan is required", http.StatusBadRequest)
+		return
+	}
+
+	var deletedBefore time.Time
+	if requestBody.OlderThan != "" {
+		period, err := utils.ParsePeriod(requestBody.OlderThan)
+		if err != nil || period.IsZero() {
+			http.Error(w, fmt.Sprintf("Invalid olderThan duration '%s'", requestBody.OlderThan), http.StatusBadRequest)
+			return
+		}
+		deletedBefore = period.AddTo(time.Now().UTC(), -1)
+	}
+
+	var result models.PurgeResult
+	logStore := models.GetLogStore()
+	job := Job{
+		Name:  "Purge Tasks",
+		Owner: uuid,
+		Result: func() interface{} {
+			return result
+		},
+		Execute: func() error {
+			logStore.AddLog("INFO", "[Purge] Starting", uuid, "Purge Tasks")
+
+			var err error
+			result, err = tw.PurgeTasksInTaskwarrior(email, encryptionSecret, uuid, taskUUIDs, deletedBefore)
+
+			for taskUUID, errMsg := range result.Failed {
+				logStore.AddLog("ERROR", fmt.Sprintf("[Purge] Failed: %s (%s)", taskUUID, errMsg), uuid, "Purge Tasks")
+			}
+
+			if err != nil {
+				logStore.AddLog("ERROR", fmt.Sprintf("[Purge] Sync error: %v", err), uuid, "Purge Tasks")
+				return err
+			}
+
+			logStore.AddLog("INFO", fmt.Sprintf("[Purge] Finished: %d purged, %d failed", len(result.Purged), len(result.Failed)), uuid, "Purge Tasks")
+
+			return nil
+		},
+	}
+	acceptJob(w, GlobalJobQueue.AddJob(job))
+}
@@ -0,0 +1,139 @@
+package controllers
+
+import (
+	"ccsync_backend/models"
+	"ccsync_backend/utils/tw"
+	"ccsync_backend/utils/urgency"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/gorilla/sessions"
+)
+
+// UrgencySettingsResponse lists the user's urgency coefficient overrides and the
+// coefficients in effect once they are applied to Taskwarrior's defaults
+type UrgencySettingsResponse struct {
+	Overrides    map[string]float64   `json:"overrides"`
+	Coefficients urgency.Coefficients `json:"coefficients"`
+}
+
+// UrgencySettingsHandler godoc
+// @Summary Get or set the user's urgency coefficients
+// @Description GET returns the user's urgency coefficient overrides and the resulting coefficients, POST replaces the overrides. Coefficients are named as in .taskrc without the urgency. prefix and .coefficient suffix: the built-in terms due, blocking, blocked, scheduled, active, age, annotations, tags, project and waiting, user.tag.<tag>, user.project.<project> (matching subprojects too), uda.<name> for tasks with any value of a user-defined attribute and uda.<name>.<value> for a given value, e.g. uda.priority.H. Send an empty object to go back to the defaults.
+// @Tags Settings
+// @Accept json
+// @Produce json
+// @Param settings body models.UrgencySettingsRequestBody false "Coefficient overrides (POST)"
+// @Success 200 {object} controllers.UrgencySettingsResponse "Overrides and effective coefficients"
+// @Failure 400 {string} string "Invalid coefficients"
+// @Failure 401 {string} string "Authentication required"
+// @Failure 405 {string} string "Method not allowed"
+// @Router /settings/urgency [get]
+// @Router /settings/urgency [post]
+func UrgencySettingsHandler(store *sessions.CookieStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		settings := models.GetSettingsStore()
+
+		var overrides map[string]float64
+		switch r.Method {
+		case http.MethodGet:
+			_, _, UUID, ok := sessionCredentials(store, r)
+			if !ok {
+				http.Error(w, "Authentication required", http.StatusUnauthorized)
+				return
+			}
+			overrides = settings.Get(UUID).Urgency
+
+		case http.MethodPost:
+			var requestBody models.UrgencySettingsRequestBody
+			if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+				http.Error(w, fmt.Sprintf("error decoding request body: %v", err), http.StatusBadRequest)
+				return
+			}
+			defer r.Body.Close()
+
+			if requestBody.UUID == "" {
+				http.Error(w, "UUID is required", http.StatusBadRequest)
+				return
+			}
+			if err := urgency.Validate(requestBody.Coefficients); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			overrides = settings.Update(requestBody.UUID, func(s *models.UserSettings) {
+				s.Urgency = nil
+				if len(requestBody.Coefficients) > 0 {
+					s.Urgency = requestBody.Coefficients
+				}
+			}).Urgency
+
+		default:
+			http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		if overrides == nil {
+			overrides = map[string]float64{}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(UrgencySettingsResponse{
+			Overrides:    overrides,
+			Coefficients: urgency.WithOverrides(overrides),
+		})
+	}
+}
+
+// TaskUrgencyHandler godoc
+// @Summary Explain the urgency of a task
+// @Description Break the urgency of a task down into the terms of the urgency formula, computed with the user's coefficients: for each term, how much it applies (factor, between 0 and 1), its coefficient and the resulting contribution. Terms that do not apply are left out.
+// @Tags Tasks
+// @Produce json
+// @Param uuid path string true "Task UUID"
+// @Success 200 {object} urgency.Breakdown "Urgency breakdown"
+// @Failure 401 {string} string "Authentication required"
+// @Failure 404 {string} string "Task not found"
+// @Failure 405 {string} string "Method not allowed"
+// @Failure 500 {string} string "Failed to fetch tasks at backend"
+// @Router /tasks/{uuid}/urgency [get]
+func TaskUrgencyHandler(store *sessions.CookieStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		email, encryptionSecret, UUID, ok := sessionCredentials(store, r)
+		if !ok {
+			http.Error(w, "Authentication required", http.StatusUnauthorized)
+			return
+		}
+
+		taskUUID, _ := splitTaskPath(r.URL.Path)
+
+		origin := os.Getenv("CONTAINER_ORIGIN")
+		tasks, err := tw.FetchTasksFromTaskwarrior(email, encryptionSecret, origin, UUID)
+		if err != nil || tasks == nil {
+			http.Error(w, "Failed to fetch tasks at backend", http.StatusInternalServerError)
+			return
+		}
+
+		calculator := urgency.NewCalculator(tasks, userUrgencyCoefficients(UUID), time.Now())
+		for _, task := range tasks {
+			if task.UUID == taskUUID {
+				w.Header().Set("Content-Type", "application/json")
+				json.NewEncoder(w).Encode(calculator.Explain(task))
+				return
+			}
+		}
+		http.Error(w, "Task not found", http.StatusNotFound)
+	}
+}
+
+// userUrgencyCoefficients returns the urgency coefficients in effect for a user
+func userUrgencyCoefficients(userUUID string) urgency.Coefficients {
+	return urgency.WithOverrides(models.GetSettingsStore().Get(userUUID).Urgency)
+}
@@ -22,6 +22,7 @@ func getEnv() string {
 }
 
 type JobStatus struct {
+	ID     string `json:"id,omitempty"`
 	Job    string `json:"job"`
 	Status string `json:"status"`
 }
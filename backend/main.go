@@ -45,6 +45,27 @@ import (
 // @tag.name Auth
 // @tag.description Authentication and authorization endpoints
 
+// @tag.name Recurrence
+// @tag.description Management of recurring task series
+
+// @tag.name Reports
+// @tag.description Reports computed from the user's tasks
+
+// @tag.name Jobs
+// @tag.description Outcome of queued task operations
+
+// @tag.name Settings
+// @tag.description Per-user preferences
+
+// @tag.name Contexts
+// @tag.description Named filters applied to task listings
+
+// @tag.name Feeds
+// @tag.description Calendar feeds of the user's tasks, read with revocable tokens
+
+// @tag.name CalDAV
+// @tag.description CalDAV server syncing the user's tasks with task apps
+
 func main() {
 	if os.Getenv("ENV") != "production" {
 		_ = godotenv.Load()
@@ -103,9 +124,14 @@ func main() {
 	// This prevents credential manipulation and allows frontend to not store sensitive secrets
 	authHandler := middleware.AuthMiddleware(store)
 
-	// Helper to compose rate limiting + auth middleware
+	// Retries of mutating requests sending the same Idempotency-Key within a day
+	// get the first response replayed instead of queuing the job again
+	idempotencyStore := middleware.NewIdempotencyStore(24 * time.Hour)
+	idempotentHandler := middleware.IdempotencyMiddleware(idempotencyStore, store)
+
+	// Helper to compose rate limiting + auth + idempotency middleware
 	authenticatedHandler := func(h http.Handler) http.Handler {
-		return rateLimitedHandler(authHandler(h))
+		return rateLimitedHandler(authHandler(idempotentHandler(h)))
 	}
 
 	// Auth endpoints (no auth middleware - these handle authentication)
@@ -116,6 +142,7 @@ func main() {
 
 	// Task endpoints - require authentication, credentials injected from session
 	mux.Handle("/tasks", authenticatedHandler(http.HandlerFunc(controllers.TasksHandler)))
+	mux.Handle("/tasks/", authenticatedHandler(controllers.TaskRoutesHandler(store)))
 	mux.Handle("/add-task", authenticatedHandler(http.HandlerFunc(controllers.AddTaskHandler)))
 	mux.Handle("/edit-task", authenticatedHandler(http.HandlerFunc(controllers.EditTaskHandler)))
 	mux.Handle("/modify-task", authenticatedHandler(http.HandlerFunc(controllers.ModifyTaskHandler)))
@@ -124,6 +151,30 @@ func main() {
 	mux.Handle("/sync/logs", rateLimitedHandler(controllers.SyncLogsHandler(store)))
 	mux.Handle("/complete-tasks", authenticatedHandler(http.HandlerFunc(controllers.BulkCompleteTaskHandler)))
 	mux.Handle("/delete-tasks", authenticatedHandler(http.HandlerFunc(controllers.BulkDeleteTaskHandler)))
+	mux.Handle("/reopen-tasks", authenticatedHandler(http.HandlerFunc(controllers.BulkReopenTaskHandler)))
+	mux.Handle("/modify-tasks", authenticatedHandler(http.HandlerFunc(controllers.BulkModifyTaskHandler)))
+	mux.Handle("/batch", authenticatedHandler(http.HandlerFunc(controllers.BatchHandler)))
+	mux.Handle("/import", authenticatedHandler(http.HandlerFunc(controllers.ImportHandler)))
+	mux.Handle("/trash", authenticatedHandler(http.HandlerFunc(controllers.TrashHandler)))
+	mux.Handle("/trash/purge", authenticatedHandler(http.HandlerFunc(controllers.PurgeTrashHandler)))
+	mux.Handle("/jobs/", rateLimitedHandler(controllers.JobHandler(store)))
+	mux.Handle("/reports/time", authenticatedHandler(http.HandlerFunc(controllers.TimeReportHandler)))
+	mux.Handle("/reports/summary", authenticatedHandler(http.HandlerFunc(controllers.SummaryReportHandler)))
+	mux.Handle("/reports/burndown", authenticatedHandler(http.HandlerFunc(controllers.BurndownReportHandler)))
+	mux.Handle("/reports/history", authenticatedHandler(http.HandlerFunc(controllers.HistoryReportHandler)))
+	mux.Handle("/reports/tags", authenticatedHandler(http.HandlerFunc(controllers.TagsReportHandler)))
+	mux.Handle("/settings/timezone", authenticatedHandler(controllers.TimezoneSettingsHandler(store)))
+	mux.Handle("/settings/urgency", authenticatedHandler(controllers.UrgencySettingsHandler(store)))
+	mux.Handle("/settings/taskrc", authenticatedHandler(controllers.TaskrcSettingsHandler(store)))
+	mux.Handle("/contexts", authenticatedHandler(controllers.ContextsHandler(store)))
+	mux.Handle("/contexts/", authenticatedHandler(controllers.ContextRoutesHandler(store)))
+	mux.Handle("/feeds/tokens", authenticatedHandler(controllers.FeedTokensHandler(store)))
+	mux.Handle("/feeds/tokens/", authenticatedHandler(controllers.RevokeFeedTokenHandler(store)))
+	// calendar apps authenticate with the feed token in the URL, not the session
+	mux.Handle("/feeds/ics/", rateLimitedHandler(http.HandlerFunc(controllers.CalendarFeedHandler)))
+	// CalDAV clients authenticate with a caldav token as their Basic password
+	mux.Handle("/caldav/", rateLimitedHandler(http.HandlerFunc(controllers.CalDAVHandler)))
+	mux.Handle("/.well-known/caldav", rateLimitedHandler(http.HandlerFunc(controllers.CalDAVDiscoveryHandler)))
 
 	mux.HandleFunc("/health", controllers.HealthCheckHandler)
 
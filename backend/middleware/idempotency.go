@@ -0,0 +1,236 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/sessions"
+)
+
+// IdempotencyKeyHeader carries a client-chosen key identifying one logical mutation.
+// Retries sending the same key get the response of the first request instead of
+// running the mutation again.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+const maxIdempotencyKeyLength = 255
+
+// headers of the first response that are replayed along with its status and body
+var replayedHeaders = []string{"Content-Type", "Location", "ETag"}
+
+type IdempotencyStore struct {
+	sync.Mutex
+	records     map[string]*idempotencyRecord
+	retention   time.Duration
+	cleanupTick time.Duration
+}
+
+type idempotencyRecord struct {
+	fingerprint string
+	done        bool
+	status      int
+	header      http.Header
+	body        []byte
+	createdAt   time.Time
+}
+
+func NewIdempotencyStore(retention time.Duration) *IdempotencyStore {
+	store := &IdempotencyStore{
+		records:     make(map[string]*idempotencyRecord),
+		retention:   retention,
+		cleanupTick: time.Minute,
+	}
+
+	go store.startCleanup()
+	return store
+}
+
+func (s *IdempotencyStore) startCleanup() {
+	ticker := time.NewTicker(s.cleanupTick)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		s.cleanup()
+	}
+}
+
+func (s *IdempotencyStore) cleanup() {
+	s.Lock()
+	defer s.Unlock()
+
+	now := time.Now()
+	for key, record := range s.records {
+		if now.Sub(record.createdAt) > s.retention {
+			delete(s.records, key)
+		}
+	}
+}
+
+// begin claims the key for a request with the given fingerprint. It returns the
+// record of an earlier request with the same key, or nil if the caller now owns the key.
+func (s *IdempotencyStore) begin(key, fingerprint string) *idempotencyRecord {
+	s.Lock()
+	defer s.Unlock()
+
+	if record, ok := s.records[key]; ok && time.Since(record.createdAt) <= s.retention {
+		copied := *record
+		return &copied
+	}
+	s.records[key] = &idempotencyRecord{fingerprint: fingerprint, createdAt: time.Now()}
+	return nil
+}
+
+// finish stores the response of the request owning the key
+func (s *IdempotencyStore) finish(key string, status int, header http.Header, body []byte) {
+	s.Lock()
+	defer s.Unlock()
+
+	if record, ok := s.records[key]; ok {
+		record.done = true
+		record.status = status
+		record.header = header
+		record.body = body
+	}
+}
+
+// release gives up the key so that the request can be retried
+func (s *IdempotencyStore) release(key string) {
+	s.Lock()
+	defer s.Unlock()
+
+	delete(s.records, key)
+}
+
+// responseRecorder passes the response through while keeping a copy of it
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+// IdempotencyMiddleware makes mutating requests carrying an Idempotency-Key safe to
+// retry. Keys are scoped to the session user, method and path; the first response
+// for a key is stored for the store's retention window and replayed for retries
+// with the same body, so the handler, and the job it queues, runs once. It must run
+// after AuthMiddleware, which makes the bodies of retries identical.
+func IdempotencyMiddleware(store *IdempotencyStore, sessionStore *sessions.CookieStore) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := strings.TrimSpace(r.Header.Get(IdempotencyKeyHeader))
+			if key == "" || !isMutating(r.Method) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > maxIdempotencyKeyLength {
+				http.Error(w, "Idempotency-Key is too long", http.StatusBadRequest)
+				return
+			}
+
+			userUUID := ""
+			if session, err := sessionStore.Get(r, "session-name"); err == nil {
+				if userInfo, ok := session.Values["user"].(map[string]interface{}); ok {
+					userUUID, _ = userInfo["uuid"].(string)
+				}
+			}
+			if userUUID == "" {
+				http.Error(w, "Authentication required", http.StatusUnauthorized)
+				return
+			}
+
+			var bodyBytes []byte
+			if r.Body != nil {
+				var err error
+				bodyBytes, err = io.ReadAll(r.Body)
+				if err != nil {
+					http.Error(w, "Failed to read request body", http.StatusBadRequest)
+					return
+				}
+				r.Body = io.NopCloser(bytes.NewBuffer(bodyBytes))
+			}
+
+			scopedKey := strings.Join([]string{userUUID, r.Method, r.URL.Path, key}, "\n")
+			fingerprint := requestFingerprint(r, bodyBytes)
+
+			if previous := store.begin(scopedKey, fingerprint); previous != nil {
+				switch {
+				case previous.fingerprint != fingerprint:
+					http.Error(w, "Idempotency-Key was already used for a different request", http.StatusUnprocessableEntity)
+				case !previous.done:
+					http.Error(w, "A request with this Idempotency-Key is still being processed", http.StatusConflict)
+				default:
+					for name, values := range previous.header {
+						w.Header()[name] = values
+					}
+					w.Header().Set("Idempotent-Replayed", "true")
+					w.WriteHeader(previous.status)
+					w.Write(previous.body)
+				}
+				return
+			}
+
+			defer func() {
+				if p := recover(); p != nil {
+					store.release(scopedKey)
+					panic(p)
+				}
+			}()
+
+			recorder := &responseRecorder{ResponseWriter: w}
+			next.ServeHTTP(recorder, r)
+
+			if recorder.status == 0 {
+				recorder.status = http.StatusOK
+			}
+			// server errors are not stored, so that a retry can succeed
+			if recorder.status >= http.StatusInternalServerError {
+				store.release(scopedKey)
+				return
+			}
+			header := http.Header{}
+			for _, name := range replayedHeaders {
+				if values := recorder.Header().Values(name); len(values) > 0 {
+					header[name] = values
+				}
+			}
+			store.finish(scopedKey, recorder.status, header, recorder.body.Bytes())
+		})
+	}
+}
+
+func isMutating(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// requestFingerprint identifies the request a key was first used with: its query
+// and body, as the method and path are part of the key's scope already
+func requestFingerprint(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(r.URL.RawQuery))
+	hash.Write([]byte{0})
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
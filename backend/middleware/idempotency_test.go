@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"encoding/gob"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/sessions"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func init() {
+	gob.Register(map[string]interface{}{})
+}
+
+func newTestIdempotencyStore() *IdempotencyStore {
+	return &IdempotencyStore{records: make(map[string]*idempotencyRecord), retention: time.Hour}
+}
+
+// sessionCookie logs a user in on a fresh session and returns its cookie
+func sessionCookie(t *testing.T, store *sessions.CookieStore, userUUID string) *http.Cookie {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+	session, err := store.Get(req, "session-name")
+	require.NoError(t, err)
+	session.Values["user"] = map[string]interface{}{"uuid": userUUID}
+	require.NoError(t, session.Save(req, rec))
+	return rec.Result().Cookies()[0]
+}
+
+func TestIdempotencyMiddleware(t *testing.T) {
+	sessionStore := sessions.NewCookieStore([]byte("test-session-key"))
+	store := newTestIdempotencyStore()
+
+	calls := 0
+	handler := IdempotencyMiddleware(store, sessionStore)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Location", "/jobs/job-1")
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte(`{"jobId":"job-1"}`))
+	}))
+
+	send := func(userUUID, key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/add-task", strings.NewReader(body))
+		req.AddCookie(sessionCookie(t, sessionStore, userUUID))
+		if key != "" {
+			req.Header.Set(IdempotencyKeyHeader, key)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	first := send("user-a", "key-1", `{"description":"milk"}`)
+	assert.Equal(t, http.StatusAccepted, first.Code)
+
+	replay := send("user-a", "key-1", `{"description":"milk"}`)
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, http.StatusAccepted, replay.Code)
+	assert.Equal(t, `{"jobId":"job-1"}`, replay.Body.String())
+	assert.Equal(t, "/jobs/job-1", replay.Header().Get("Location"))
+	assert.Equal(t, "true", replay.Header().Get("Idempotent-Replayed"))
+
+	mismatch := send("user-a", "key-1", `{"description":"eggs"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, mismatch.Code)
+	assert.Equal(t, 1, calls)
+
+	// the same key from another user, or no key at all, is a new request
+	send("user-b", "key-1", `{"description":"milk"}`)
+	send("user-a", "", `{"description":"milk"}`)
+	assert.Equal(t, 3, calls)
+}
+
+func TestIdempotencyStore_InFlightAndRetention(t *testing.T) {
+	store := newTestIdempotencyStore()
+
+	assert.Nil(t, store.begin("k", "f"))
+	previous := store.begin("k", "f")
+	require.NotNil(t, previous)
+	assert.False(t, previous.done)
+
+	store.release("k")
+	assert.Nil(t, store.begin("k", "f"))
+
+	store.finish("k", http.StatusAccepted, nil, nil)
+	store.records["k"].createdAt = time.Now().Add(-2 * time.Hour)
+	store.cleanup()
+	assert.Empty(t, store.records)
+}
@@ -0,0 +1,139 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	BatchOpAdd      = "add"
+	BatchOpEdit     = "edit"
+	BatchOpModify   = "modify"
+	BatchOpComplete = "complete"
+	BatchOpDelete   = "delete"
+	BatchOpAnnotate = "annotate"
+)
+
+const (
+	// BatchStatusApplied marks an operation that was applied and synced
+	BatchStatusApplied = "applied"
+	// BatchStatusFailed marks the operation that made the batch fail
+	BatchStatusFailed = "failed"
+	// BatchStatusRolledBack marks an operation that succeeded but was discarded with the rest of a failed batch
+	BatchStatusRolledBack = "rolled-back"
+	// BatchStatusSkipped marks an operation that was not attempted because an earlier one failed
+	BatchStatusSkipped = "skipped"
+)
+
+// MaxBatchOperations caps the number of operations in a single batch
+const MaxBatchOperations = 100
+
+// BatchRefPrefix marks a reference to a task created earlier in the same batch,
+// e.g. "$setup" for the task added by the operation with ref "setup"
+const BatchRefPrefix = "$"
+
+// BatchOperation is one step of a batch. The fields used depend on the operation and
+// mean the same as in the corresponding single-task request: add takes the fields of
+// /add-task, edit those of /edit-task, modify those of /modify-task, annotate the
+// annotation text in description. An add may name the task it creates in ref, so that
+// later operations can use "$ref" as their taskuuid or in depends.
+type BatchOperation struct {
+	Op               string       `json:"op"`
+	Ref              string       `json:"ref,omitempty"`
+	TaskUUID         string       `json:"taskuuid,omitempty"`
+	Description      string       `json:"description,omitempty"`
+	Project          string       `json:"project,omitempty"`
+	Priority         string       `json:"priority,omitempty"`
+	Status           string       `json:"status,omitempty"`
+	Due              string       `json:"due,omitempty"`
+	Start            string       `json:"start,omitempty"`
+	Entry            string       `json:"entry,omitempty"`
+	Wait             string       `json:"wait,omitempty"`
+	End              string       `json:"end,omitempty"`
+	Recur            string       `json:"recur,omitempty"`
+	Tags             []string     `json:"tags,omitempty"`
+	Depends          []string     `json:"depends,omitempty"`
+	Annotations      []Annotation `json:"annotations,omitempty"`
+	ExpectedModified string       `json:"expectedModified,omitempty"`
+}
+
+// BatchOperationResult reports the outcome of one operation of a batch
+type BatchOperationResult struct {
+	Index    int    `json:"index"`
+	Op       string `json:"op"`
+	Ref      string `json:"ref,omitempty"`
+	TaskUUID string `json:"taskuuid,omitempty"`
+	Status   string `json:"status"` // applied, failed, rolled-back, skipped
+	Error    string `json:"error,omitempty"`
+}
+
+// IsBatchRef reports whether value refers to a task created earlier in the batch
+func IsBatchRef(value string) bool {
+	return strings.HasPrefix(value, BatchRefPrefix)
+}
+
+// ValidateBatch checks the shape of a batch before anything is applied: known
+// operations with their required fields, unique refs, and references only to
+// tasks added by earlier operations
+func ValidateBatch(operations []BatchOperation) error {
+	if len(operations) == 0 {
+		return fmt.Errorf("operations are required")
+	}
+	if len(operations) > MaxBatchOperations {
+		return fmt.Errorf("a batch holds at most %d operations", MaxBatchOperations)
+	}
+
+	refs := make(map[string]bool)
+	checkRef := func(i int, value string) error {
+		if IsBatchRef(value) && !refs[strings.TrimPrefix(value, BatchRefPrefix)] {
+			return fmt.Errorf("operation %d: %s does not refer to a task added by an earlier operation", i, value)
+		}
+		return nil
+	}
+
+	for i, op := range operations {
+		switch op.Op {
+		case BatchOpAdd:
+			if op.Description == "" {
+				return fmt.Errorf("operation %d: description is required", i)
+			}
+		case BatchOpEdit, BatchOpModify, BatchOpComplete, BatchOpDelete:
+			if op.TaskUUID == "" {
+				return fmt.Errorf("operation %d: taskuuid is required", i)
+			}
+		case BatchOpAnnotate:
+			if op.TaskUUID == "" {
+				return fmt.Errorf("operation %d: taskuuid is required", i)
+			}
+			if strings.TrimSpace(op.Description) == "" {
+				return fmt.Errorf("operation %d: description is required", i)
+			}
+		default:
+			return fmt.Errorf("operation %d: unknown operation %q", i, op.Op)
+		}
+
+		if op.Ref != "" && op.Op != BatchOpAdd {
+			return fmt.Errorf("operation %d: only add operations can define a ref", i)
+		}
+		if err := checkRef(i, op.TaskUUID); err != nil {
+			return err
+		}
+		for _, dep := range op.Depends {
+			if err := checkRef(i, dep); err != nil {
+				return err
+			}
+		}
+
+		if op.Ref != "" {
+			if IsBatchRef(op.Ref) {
+				return fmt.Errorf("operation %d: ref must be given without the %s prefix", i, BatchRefPrefix)
+			}
+			if refs[op.Ref] {
+				return fmt.Errorf("operation %d: ref %q is already defined", i, op.Ref)
+			}
+			refs[op.Ref] = true
+		}
+	}
+
+	return nil
+}
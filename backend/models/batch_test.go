@@ -0,0 +1,43 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateBatch_Refs(t *testing.T) {
+	err := ValidateBatch([]BatchOperation{
+		{Op: BatchOpAdd, Ref: "setup", Description: "set up"},
+		{Op: BatchOpAdd, Description: "docs", Depends: []string{"$setup"}},
+		{Op: BatchOpComplete, TaskUUID: "$setup"},
+	})
+	assert.NoError(t, err)
+
+	err = ValidateBatch([]BatchOperation{
+		{Op: BatchOpComplete, TaskUUID: "$setup"},
+		{Op: BatchOpAdd, Ref: "setup", Description: "set up"},
+	})
+	assert.ErrorContains(t, err, "operation 0")
+
+	err = ValidateBatch([]BatchOperation{
+		{Op: BatchOpAdd, Ref: "setup", Description: "set up", Depends: []string{"$setup"}},
+	})
+	assert.Error(t, err)
+
+	err = ValidateBatch([]BatchOperation{
+		{Op: BatchOpAdd, Ref: "a", Description: "one"},
+		{Op: BatchOpAdd, Ref: "a", Description: "two"},
+	})
+	assert.ErrorContains(t, err, "already defined")
+}
+
+func TestValidateBatch_Operations(t *testing.T) {
+	assert.Error(t, ValidateBatch(nil))
+	assert.Error(t, ValidateBatch(make([]BatchOperation, MaxBatchOperations+1)))
+	assert.ErrorContains(t, ValidateBatch([]BatchOperation{{Op: "rename", TaskUUID: "t1"}}), "unknown operation")
+	assert.ErrorContains(t, ValidateBatch([]BatchOperation{{Op: BatchOpAdd}}), "description is required")
+	assert.ErrorContains(t, ValidateBatch([]BatchOperation{{Op: BatchOpEdit}}), "taskuuid is required")
+	assert.ErrorContains(t, ValidateBatch([]BatchOperation{{Op: BatchOpAnnotate, TaskUUID: "t1", Description: " "}}), "description is required")
+	assert.ErrorContains(t, ValidateBatch([]BatchOperation{{Op: BatchOpDelete, TaskUUID: "t1", Ref: "x"}}), "only add operations")
+}
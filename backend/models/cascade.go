@@ -0,0 +1,22 @@
+package models
+
+// Cascade modes for completing or deleting a task other open tasks depend on
+const (
+	// CascadeNone leaves the dependents as they are
+	CascadeNone = ""
+	// CascadeStrip removes the dependency from the dependents
+	CascadeStrip = "strip"
+	// CascadeDependents completes or deletes the dependents too, transitively
+	CascadeDependents = "cascade"
+	// CascadeRefuse fails without changes when the task has open dependents
+	CascadeRefuse = "refuse"
+)
+
+// ValidCascade reports whether mode is a known cascade mode
+func ValidCascade(mode string) bool {
+	switch mode {
+	case CascadeNone, CascadeStrip, CascadeDependents, CascadeRefuse:
+		return true
+	}
+	return false
+}
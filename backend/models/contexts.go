@@ -0,0 +1,109 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// MaxContexts is the number of contexts a user can save
+const MaxContexts = 100
+
+// NoContext names the absence of a context, as in `task context none`
+const NoContext = "none"
+
+// Context is a named filter, like a Taskwarrior context
+type Context struct {
+	Name   string `json:"name"`
+	Filter string `json:"filter"`
+}
+
+var contextNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]{0,63}$`)
+
+// reservedContextNames clash with the routes under /contexts or with NoContext
+var reservedContextNames = map[string]bool{NoContext: true, "active": true, "import": true}
+
+// ValidateContextName checks that a context name can be used in a URL and a taskrc key
+func ValidateContextName(name string) error {
+	if !contextNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid context name '%s': use up to 64 letters, digits, '-' or '_'", name)
+	}
+	if reservedContextNames[strings.ToLower(name)] {
+		return fmt.Errorf("context name '%s' is reserved", name)
+	}
+	return nil
+}
+
+// SortedContexts lists contexts by name
+func SortedContexts(contexts map[string]string) []Context {
+	sorted := make([]Context, 0, len(contexts))
+	for name, filter := range contexts {
+		sorted = append(sorted, Context{Name: name, Filter: filter})
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+	return sorted
+}
+
+// TaskrcContexts are the context definitions found in a taskrc
+type TaskrcContexts struct {
+	Contexts []Context
+	// Active is the context selected with context=<name>, if any
+	Active string
+	// Ignored explains, by key, the context settings that cannot be imported
+	Ignored map[string]string
+}
+
+// ParseTaskrcContexts extracts the contexts defined in a taskrc, both as
+// context.<name>=<filter> and, since Taskwarrior 2.6, context.<name>.read=<filter>.
+// Write filters have no equivalent here and are reported as ignored, as are lines
+// with invalid names. Filters are not validated.
+func ParseTaskrcContexts(taskrc string) TaskrcContexts {
+	result := TaskrcContexts{Ignored: make(map[string]string)}
+	filters := make(map[string]string)
+
+	for _, line := range strings.Split(taskrc, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, found := strings.Cut(line, "=")
+		if !found {
+			continue
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+
+		if key == "context" {
+			result.Active = value
+			continue
+		}
+		if !strings.HasPrefix(key, "context.") {
+			continue
+		}
+
+		name := strings.TrimPrefix(key, "context.")
+		switch {
+		case strings.HasSuffix(name, ".write"):
+			result.Ignored[key] = "write filters are not supported"
+			continue
+		case strings.HasSuffix(name, ".read"):
+			name = strings.TrimSuffix(name, ".read")
+		default:
+			// the legacy form is superseded by an explicit read filter
+			if _, ok := filters[name]; ok {
+				continue
+			}
+		}
+		if err := ValidateContextName(name); err != nil {
+			result.Ignored[key] = err.Error()
+			continue
+		}
+		filters[name] = value
+	}
+
+	result.Contexts = SortedContexts(filters)
+	if _, ok := filters[result.Active]; !ok {
+		result.Active = ""
+	}
+	return result
+}
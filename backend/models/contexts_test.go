@@ -0,0 +1,40 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateContextName(t *testing.T) {
+	assert.NoError(t, ValidateContextName("work"))
+	assert.NoError(t, ValidateContextName("Deep_work-2"))
+	for _, name := range []string{"", "none", "Active", "import", "two words", "a.b", "-work"} {
+		assert.Error(t, ValidateContextName(name), "%q", name)
+	}
+}
+
+func TestParseTaskrcContexts(t *testing.T) {
+	taskrc := `
+# contexts
+context.work=project:Work or +work
+context.home.read = project:Home
+context.home.write=project:Home
+context.home=+home
+context.a.b=+ab
+context=home
+weekstart=monday
+`
+	parsed := ParseTaskrcContexts(taskrc)
+
+	assert.Equal(t, []Context{
+		{Name: "home", Filter: "project:Home"},
+		{Name: "work", Filter: "project:Work or +work"},
+	}, parsed.Contexts)
+	assert.Equal(t, "home", parsed.Active)
+	assert.Contains(t, parsed.Ignored, "context.home.write")
+	assert.Contains(t, parsed.Ignored, "context.a.b")
+	assert.Len(t, parsed.Ignored, 2)
+
+	assert.Equal(t, "", ParseTaskrcContexts("context=missing\ncontext.work=+work").Active)
+}
@@ -0,0 +1,234 @@
+package models
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MaxFeedTokens is the number of feed tokens a user can hold
+const MaxFeedTokens = 20
+
+// feedTokensFile is the file in CCSYNC_DATA_DIR the feed tokens are saved to
+const feedTokensFile = "feed_tokens.json"
+
+// feedTokenUseInterval is how stale the saved last use of a token may get: calendar apps
+// poll feeds often, and saving every use would rewrite the file each time
+const feedTokenUseInterval = time.Hour
+
+const (
+	// FeedScopeICS tokens can read the iCalendar feed
+	FeedScopeICS = "ics"
+	// FeedScopeCalDAV tokens are passwords for the CalDAV server, which can change tasks
+	FeedScopeCalDAV = "caldav"
+)
+
+// ValidFeedScope reports whether a token scope is known
+func ValidFeedScope(scope string) bool {
+	return scope == FeedScopeICS || scope == FeedScopeCalDAV
+}
+
+// FeedToken describes a token giving a calendar app access to a user's tasks, either
+// to the feed or to the CalDAV server depending on its scope. The token itself is only
+// returned when it is created.
+type FeedToken struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Scope      string     `json:"scope"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+}
+
+// FeedCredentials are what serving a feed needs to read the user's tasks
+type FeedCredentials struct {
+	Email            string
+	EncryptionSecret string
+	UUID             string
+}
+
+type feedTokenRecord struct {
+	FeedToken
+	UserUUID string `json:"userUUID"`
+	// Credentials are sealed with a key derived from the token, so that they cannot be
+	// read from the store, or its file, without it
+	Credentials []byte `json:"credentials"`
+}
+
+// FeedTokenStore keeps feed tokens indexed by the SHA-256 hash of the token, so that
+// the tokens themselves are never stored. With a path they are saved there on every
+// change, and survive restarts.
+type FeedTokenStore struct {
+	mu     sync.Mutex
+	tokens map[string]*feedTokenRecord
+	path   string
+}
+
+var (
+	// GlobalFeedTokenStore is the global instance of the feed token store
+	GlobalFeedTokenStore *FeedTokenStore
+	feedTokensOnce       sync.Once
+)
+
+// GetFeedTokenStore returns the singleton instance of FeedTokenStore
+func GetFeedTokenStore() *FeedTokenStore {
+	feedTokensOnce.Do(func() {
+		GlobalFeedTokenStore = &FeedTokenStore{
+			tokens: make(map[string]*feedTokenRecord),
+			path:   dataPath(feedTokensFile),
+		}
+		loadData(GlobalFeedTokenStore.path, &GlobalFeedTokenStore.tokens)
+		if GlobalFeedTokenStore.tokens == nil {
+			GlobalFeedTokenStore.tokens = make(map[string]*feedTokenRecord)
+		}
+	})
+	return GlobalFeedTokenStore
+}
+
+// Create issues a new token for the user and returns it along with its description
+func (fs *FeedTokenStore) Create(credentials FeedCredentials, name, scope string) (FeedToken, string, error) {
+	secret := make([]byte, 32)
+	id := make([]byte, 8)
+	if _, err := rand.Read(secret); err != nil {
+		return FeedToken{}, "", err
+	}
+	if _, err := rand.Read(id); err != nil {
+		return FeedToken{}, "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(secret)
+	sealed, err := sealFeedCredentials(token, credentials)
+	if err != nil {
+		return FeedToken{}, "", err
+	}
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	if len(fs.list(credentials.UUID)) >= MaxFeedTokens {
+		return FeedToken{}, "", fmt.Errorf("too many feed tokens (max %d)", MaxFeedTokens)
+	}
+	record := &feedTokenRecord{
+		FeedToken: FeedToken{
+			ID:        hex.EncodeToString(id),
+			Name:      name,
+			Scope:     scope,
+			CreatedAt: time.Now().UTC(),
+		},
+		UserUUID:    credentials.UUID,
+		Credentials: sealed,
+	}
+	fs.tokens[hashFeedToken(token)] = record
+	saveData(fs.path, fs.tokens)
+	return record.FeedToken, token, nil
+}
+
+// List returns the user's tokens, oldest first
+func (fs *FeedTokenStore) List(userUUID string) []FeedToken {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	return fs.list(userUUID)
+}
+
+func (fs *FeedTokenStore) list(userUUID string) []FeedToken {
+	tokens := []FeedToken{}
+	for _, record := range fs.tokens {
+		if record.UserUUID == userUUID {
+			tokens = append(tokens, record.FeedToken)
+		}
+	}
+	sort.Slice(tokens, func(i, j int) bool { return tokens[i].CreatedAt.Before(tokens[j].CreatedAt) })
+	return tokens
+}
+
+// Revoke deletes one of the user's tokens, reporting whether it existed
+func (fs *FeedTokenStore) Revoke(userUUID, id string) bool {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	for hash, record := range fs.tokens {
+		if record.ID == id && record.UserUUID == userUUID {
+			delete(fs.tokens, hash)
+			saveData(fs.path, fs.tokens)
+			return true
+		}
+	}
+	return false
+}
+
+// Resolve returns the credentials and description of a token with the given scope,
+// recording its use
+func (fs *FeedTokenStore) Resolve(token, scope string) (FeedCredentials, FeedToken, bool) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	record, ok := fs.tokens[hashFeedToken(token)]
+	if !ok || record.Scope != scope {
+		return FeedCredentials{}, FeedToken{}, false
+	}
+	credentials, err := openFeedCredentials(token, record.Credentials)
+	if err != nil {
+		return FeedCredentials{}, FeedToken{}, false
+	}
+	now := time.Now().UTC()
+	stale := record.LastUsedAt == nil || now.Sub(*record.LastUsedAt) >= feedTokenUseInterval
+	record.LastUsedAt = &now
+	if stale {
+		saveData(fs.path, fs.tokens)
+	}
+	return credentials, record.FeedToken, true
+}
+
+func hashFeedToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// feedCredentialsCipher derives the key sealing the credentials of a token from the
+// token itself, distinct from the hash the token is looked up by
+func feedCredentialsCipher(token string) (cipher.AEAD, error) {
+	key := sha256.Sum256([]byte("ccsync feed credentials:" + token))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func sealFeedCredentials(token string, credentials FeedCredentials) ([]byte, error) {
+	aead, err := feedCredentialsCipher(token)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := json.Marshal(credentials)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func openFeedCredentials(token string, sealed []byte) (FeedCredentials, error) {
+	var credentials FeedCredentials
+	aead, err := feedCredentialsCipher(token)
+	if err != nil {
+		return credentials, err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return credentials, fmt.Errorf("sealed credentials too short")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return credentials, err
+	}
+	err = json.Unmarshal(plaintext, &credentials)
+	return credentials, err
+}
@@ -0,0 +1,69 @@
+package models
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFeedTokenStore(t *testing.T) {
+	fs := &FeedTokenStore{tokens: make(map[string]*feedTokenRecord)}
+	credentials := FeedCredentials{Email: "a@example.com", EncryptionSecret: "secret", UUID: "user-a"}
+
+	created, token, err := fs.Create(credentials, "phone", FeedScopeICS)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, token)
+	assert.NotContains(t, fs.tokens, token, "tokens are stored hashed")
+
+	_, _, ok := fs.Resolve(token, FeedScopeCalDAV)
+	assert.False(t, ok, "tokens only work for their scope")
+	resolved, feedToken, ok := fs.Resolve(token, FeedScopeICS)
+	assert.True(t, ok)
+	assert.Equal(t, credentials, resolved)
+	assert.Equal(t, "phone", feedToken.Name)
+
+	listed := fs.List("user-a")
+	assert.Len(t, listed, 1)
+	assert.Equal(t, created.ID, listed[0].ID)
+	assert.NotNil(t, listed[0].LastUsedAt)
+	assert.Empty(t, fs.List("user-b"))
+
+	assert.False(t, fs.Revoke("user-b", created.ID))
+	assert.True(t, fs.Revoke("user-a", created.ID))
+	_, _, ok = fs.Resolve(token, FeedScopeICS)
+	assert.False(t, ok)
+
+	for i := 0; i < MaxFeedTokens; i++ {
+		_, _, err = fs.Create(credentials, "", FeedScopeCalDAV)
+		assert.NoError(t, err)
+	}
+	_, _, err = fs.Create(credentials, "", FeedScopeCalDAV)
+	assert.Error(t, err)
+}
+
+func TestFeedTokenStore_Persisted(t *testing.T) {
+	path := filepath.Join(t.TempDir(), feedTokensFile)
+	fs := &FeedTokenStore{tokens: make(map[string]*feedTokenRecord), path: path}
+	credentials := FeedCredentials{Email: "a@example.com", EncryptionSecret: "secret", UUID: "user-a"}
+	created, token, err := fs.Create(credentials, "phone", FeedScopeCalDAV)
+	assert.NoError(t, err)
+
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), token)
+	assert.NotContains(t, string(data), "secret", "credentials are saved sealed")
+
+	restarted := &FeedTokenStore{tokens: make(map[string]*feedTokenRecord), path: path}
+	loadData(restarted.path, &restarted.tokens)
+	assert.Equal(t, created.ID, restarted.List("user-a")[0].ID)
+	resolved, _, ok := restarted.Resolve(token, FeedScopeCalDAV)
+	assert.True(t, ok)
+	assert.Equal(t, credentials, resolved)
+
+	assert.True(t, restarted.Revoke("user-a", created.ID))
+	restarted = &FeedTokenStore{tokens: make(map[string]*feedTokenRecord), path: path}
+	loadData(restarted.path, &restarted.tokens)
+	assert.Empty(t, restarted.List("user-a"))
+}
@@ -0,0 +1,233 @@
+package models
+
+import (
+	"ccsync_backend/utils"
+	"crypto/sha256"
+	"encoding/hex"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// HistorySourceCCSync marks changes made by a ccsync job
+	HistorySourceCCSync = "ccsync"
+	// HistorySourceExternal marks changes pulled in from another Taskwarrior client during sync
+	HistorySourceExternal = "external"
+)
+
+// FieldChange describes a single attribute transition on a task
+type FieldChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+// HistoryEntry is one journaled change to a task, grouped by the operation that produced it
+type HistoryEntry struct {
+	Timestamp string        `json:"timestamp"`
+	Source    string        `json:"source"`    // ccsync, external
+	Operation string        `json:"operation"` // e.g. "Edit Task", or "sync" for external changes
+	Changes   []FieldChange `json:"changes"`
+}
+
+// historyDir is the directory in CCSYNC_DATA_DIR the journals are saved in, one file
+// per user
+const historyDir = "history"
+
+// userHistory holds the tracked attributes of the last tasks seen for a user and the
+// journal built from them
+type userHistory struct {
+	Snapshot map[string]map[string]string `json:"snapshot"`
+	Entries  map[string][]HistoryEntry    `json:"entries"`
+}
+
+// HistoryStore journals task changes by diffing successive exports of a user's replica,
+// with a max of 100 entries per task. With a directory, the journal of a user is saved
+// there whenever it changes, and read back the first time the user is seen after a
+// restart.
+type HistoryStore struct {
+	mu         sync.Mutex
+	users      map[string]*userHistory
+	maxPerTask int
+	dir        string
+}
+
+var (
+	// GlobalHistoryStore is the global instance of the history store
+	GlobalHistoryStore *HistoryStore
+	historyOnce        sync.Once
+)
+
+// GetHistoryStore returns the singleton instance of HistoryStore
+func GetHistoryStore() *HistoryStore {
+	historyOnce.Do(func() {
+		GlobalHistoryStore = &HistoryStore{
+			users:      make(map[string]*userHistory),
+			maxPerTask: 100,
+			dir:        dataPath(historyDir),
+		}
+	})
+	return GlobalHistoryStore
+}
+
+// RecordSnapshot compares the exported tasks with the previous snapshot for the user
+// and journals every changed attribute under the given source and operation.
+// The first snapshot seen for a user only establishes a baseline.
+func (hs *HistoryStore) RecordSnapshot(userUUID string, tasks []Task, source, operation string) {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+
+	current := make(map[string]map[string]string, len(tasks))
+	for _, task := range tasks {
+		current[task.UUID] = historyFields(task)
+	}
+
+	history := hs.user(userUUID)
+	if history == nil {
+		hs.users[userUUID] = &userHistory{
+			Snapshot: current,
+			Entries:  make(map[string][]HistoryEntry),
+		}
+		hs.save(userUUID)
+		return
+	}
+
+	changed := len(current) != len(history.Snapshot)
+	for _, task := range tasks {
+		changes := diffFields(history.Snapshot[task.UUID], current[task.UUID])
+		if len(changes) == 0 {
+			continue
+		}
+		changed = true
+
+		entry := HistoryEntry{
+			Timestamp: changeTime(task),
+			Source:    source,
+			Operation: operation,
+			Changes:   changes,
+		}
+
+		entries := append(history.Entries[task.UUID], entry)
+		if len(entries) > hs.maxPerTask {
+			entries = entries[len(entries)-hs.maxPerTask:]
+		}
+		history.Entries[task.UUID] = entries
+	}
+
+	history.Snapshot = current
+	if changed {
+		hs.save(userUUID)
+	}
+}
+
+// GetTaskHistory returns the journaled changes of a task in chronological order
+func (hs *HistoryStore) GetTaskHistory(userUUID, taskUUID string) []HistoryEntry {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+
+	result := []HistoryEntry{}
+	if history := hs.user(userUUID); history != nil {
+		result = append(result, history.Entries[taskUUID]...)
+	}
+	return result
+}
+
+// user returns the journal of a user, read from its file the first time, or nil if the
+// user has none yet
+func (hs *HistoryStore) user(userUUID string) *userHistory {
+	if history, ok := hs.users[userUUID]; ok {
+		return history
+	}
+	var history *userHistory
+	loadData(hs.path(userUUID), &history)
+	if history == nil || history.Snapshot == nil {
+		return nil
+	}
+	if history.Entries == nil {
+		history.Entries = make(map[string][]HistoryEntry)
+	}
+	hs.users[userUUID] = history
+	return history
+}
+
+func (hs *HistoryStore) save(userUUID string) {
+	saveData(hs.path(userUUID), hs.users[userUUID])
+}
+
+// path names the file of a user's journal by a hash of the user UUID, which comes from
+// the client
+func (hs *HistoryStore) path(userUUID string) string {
+	if hs.dir == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(userUUID))
+	return filepath.Join(hs.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// changeTime uses the task's modified timestamp when available, since it reflects
+// when the change was made rather than when ccsync noticed it
+func changeTime(task Task) string {
+	if task.Modified != "" {
+		if parsed, err := time.Parse(utils.TaskwarriorDateLayout, task.Modified); err == nil {
+			return parsed.Format(time.RFC3339)
+		}
+	}
+	return time.Now().UTC().Format(time.RFC3339)
+}
+
+// DiffTasks lists the tracked attributes that differ between two versions of a task
+func DiffTasks(before, after Task) []FieldChange {
+	return diffFields(historyFields(before), historyFields(after))
+}
+
+// diffFields compares the tracked attributes of two versions of a task; a nil map
+// stands for a task that did not exist
+func diffFields(oldFields, newFields map[string]string) []FieldChange {
+	var changes []FieldChange
+	for _, field := range historyFieldOrder {
+		if oldFields[field] != newFields[field] {
+			changes = append(changes, FieldChange{
+				Field: field,
+				Old:   oldFields[field],
+				New:   newFields[field],
+			})
+		}
+	}
+	return changes
+}
+
+var historyFieldOrder = []string{
+	"description", "status", "project", "priority", "tags", "due", "wait",
+	"start", "end", "recur", "depends", "annotations",
+}
+
+func historyFields(task Task) map[string]string {
+	annotations := make([]string, 0, len(task.Annotations))
+	for _, annotation := range task.Annotations {
+		annotations = append(annotations, annotation.Description)
+	}
+
+	return map[string]string{
+		"description": task.Description,
+		"status":      task.Status,
+		"project":     task.Project,
+		"priority":    task.Priority,
+		"tags":        sortedJoin(task.Tags),
+		"due":         task.Due,
+		"wait":        task.Wait,
+		"start":       task.Start,
+		"end":         task.End,
+		"recur":       task.Recur,
+		"depends":     sortedJoin(task.Depends),
+		"annotations": strings.Join(annotations, "; "),
+	}
+}
+
+func sortedJoin(values []string) string {
+	sorted := append([]string(nil), values...)
+	sort.Strings(sorted)
+	return strings.Join(sorted, ",")
+}
@@ -0,0 +1,118 @@
+package models
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestHistoryStore() *HistoryStore {
+	return &HistoryStore{
+		users:      make(map[string]*userHistory),
+		maxPerTask: 100,
+	}
+}
+
+func Test_DiffTasks_NoChanges(t *testing.T) {
+	task := Task{UUID: "a", Description: "write docs", Tags: []string{"b", "a"}}
+	reordered := Task{UUID: "a", Description: "write docs", Tags: []string{"a", "b"}}
+
+	assert.Empty(t, DiffTasks(task, reordered))
+}
+
+func Test_DiffTasks_ChangedFields(t *testing.T) {
+	before := Task{UUID: "a", Description: "write docs", Due: "20250101T000000Z", Priority: "L"}
+	after := Task{UUID: "a", Description: "write docs", Due: "20250105T000000Z", Priority: "H"}
+
+	changes := DiffTasks(before, after)
+	assert.Equal(t, []FieldChange{
+		{Field: "priority", Old: "L", New: "H"},
+		{Field: "due", Old: "20250101T000000Z", New: "20250105T000000Z"},
+	}, changes)
+}
+
+func Test_RecordSnapshot_FirstSnapshotIsBaseline(t *testing.T) {
+	store := newTestHistoryStore()
+	store.RecordSnapshot("user", []Task{{UUID: "a", Description: "one"}}, HistorySourceExternal, "sync")
+
+	assert.Empty(t, store.GetTaskHistory("user", "a"))
+}
+
+func Test_RecordSnapshot_AttributesChanges(t *testing.T) {
+	store := newTestHistoryStore()
+	store.RecordSnapshot("user", []Task{{UUID: "a", Description: "one", Due: "20250101T000000Z"}}, HistorySourceExternal, "sync")
+	store.RecordSnapshot("user", []Task{{UUID: "a", Description: "one", Due: "20250102T000000Z", Modified: "20250101T120000Z"}}, HistorySourceExternal, "sync")
+	store.RecordSnapshot("user", []Task{{UUID: "a", Description: "one", Due: "20250103T000000Z"}}, HistorySourceCCSync, "Edit Task")
+
+	history := store.GetTaskHistory("user", "a")
+	assert.Len(t, history, 2)
+	assert.Equal(t, HistorySourceExternal, history[0].Source)
+	assert.Equal(t, "2025-01-01T12:00:00Z", history[0].Timestamp)
+	assert.Equal(t, []FieldChange{{Field: "due", Old: "20250101T000000Z", New: "20250102T000000Z"}}, history[0].Changes)
+	assert.Equal(t, HistorySourceCCSync, history[1].Source)
+	assert.Equal(t, "Edit Task", history[1].Operation)
+}
+
+func Test_RecordSnapshot_NewTask(t *testing.T) {
+	store := newTestHistoryStore()
+	store.RecordSnapshot("user", []Task{}, HistorySourceExternal, "sync")
+	store.RecordSnapshot("user", []Task{{UUID: "b", Description: "new", Status: "pending"}}, HistorySourceCCSync, "Add Task")
+
+	history := store.GetTaskHistory("user", "b")
+	assert.Len(t, history, 1)
+	assert.Contains(t, history[0].Changes, FieldChange{Field: "description", Old: "", New: "new"})
+	assert.Contains(t, history[0].Changes, FieldChange{Field: "status", Old: "", New: "pending"})
+}
+
+func Test_RecordSnapshot_Persisted(t *testing.T) {
+	dir := t.TempDir()
+	store := newTestHistoryStore()
+	store.dir = dir
+	store.RecordSnapshot("user", []Task{{UUID: "a", Description: "one"}}, HistorySourceExternal, "sync")
+	store.RecordSnapshot("user", []Task{{UUID: "a", Description: "two"}}, HistorySourceCCSync, "Edit Task")
+
+	files, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, files, 1)
+	assert.NotContains(t, files[0].Name(), "user", "files are named by a hash of the user UUID")
+
+	restarted := newTestHistoryStore()
+	restarted.dir = dir
+	assert.Len(t, restarted.GetTaskHistory("user", "a"), 1)
+
+	// the saved snapshot is the baseline after a restart, so no change is missed
+	restarted.RecordSnapshot("user", []Task{{UUID: "a", Description: "three"}}, HistorySourceExternal, "sync")
+	history := restarted.GetTaskHistory("user", "a")
+	assert.Len(t, history, 2)
+	assert.Equal(t, []FieldChange{{Field: "description", Old: "two", New: "three"}}, history[1].Changes)
+}
+
+func TestMergeTasks_DisjointFields(t *testing.T) {
+	base := Task{UUID: "t1", Description: "Write report", Priority: "L", Due: "20250301T000000Z"}
+	current := base
+	current.Due = "20250305T000000Z"
+	proposed := base
+	proposed.Priority = "H"
+
+	result := MergeTasks(base, current, proposed)
+	assert.Equal(t, []string{"priority"}, result.Applied)
+	assert.Equal(t, []string{"due"}, result.Kept)
+	assert.Empty(t, result.Conflicts)
+}
+
+func TestMergeTasks_SameFieldDiverged(t *testing.T) {
+	base := Task{UUID: "t1", Description: "Write report", Tags: []string{"work"}}
+	current := base
+	current.Description = "Write the report"
+	current.Tags = []string{"work", "urgent"}
+	proposed := base
+	proposed.Description = "Write quarterly report"
+	proposed.Tags = []string{"urgent", "work"}
+
+	result := MergeTasks(base, current, proposed)
+	assert.Equal(t, []string{"description"}, result.Conflicts)
+	// both sides added the same tag, which is not a conflict
+	assert.Equal(t, []string{"tags"}, result.Kept)
+	assert.Empty(t, result.Applied)
+}
@@ -0,0 +1,80 @@
+package models
+
+import "strings"
+
+// MaxImportTasks is the number of tasks a single import can hold
+const MaxImportTasks = 1000
+
+// Import formats
+const (
+	// ImportFormatTodoTxt reads one task per line in the todo.txt format
+	ImportFormatTodoTxt = "todotxt"
+	// ImportFormatCSV reads one task per row, the first row naming the columns
+	ImportFormatCSV = "csv"
+	// ImportFormatJSON reads the output of task export
+	ImportFormatJSON = "json"
+)
+
+// Reasons for an imported task to be a duplicate
+const (
+	// DuplicateUUID marks a task whose UUID already exists
+	DuplicateUUID = "uuid"
+	// DuplicateDescription marks a task without UUID whose description matches an
+	// existing task's
+	DuplicateDescription = "description"
+)
+
+// ValidImportFormat reports whether format is a known import format
+func ValidImportFormat(format string) bool {
+	switch format {
+	case ImportFormatTodoTxt, ImportFormatCSV, ImportFormatJSON:
+		return true
+	}
+	return false
+}
+
+// ImportedTask is a task read from an import, as the JSON task import takes it
+type ImportedTask struct {
+	// Line is the line of the task in todo.txt and CSV input, and its position (from 1)
+	// in a JSON export
+	Line int                    `json:"line"`
+	Task map[string]interface{} `json:"task"`
+	// Duplicate is the reason the task is skipped, if it is a duplicate
+	Duplicate string `json:"duplicate,omitempty"`
+	// DuplicateOf is the UUID of the existing task it duplicates, empty when it repeats
+	// an earlier task of the same import
+	DuplicateOf string `json:"duplicateOf,omitempty"`
+}
+
+// UUID returns the UUID the task was imported with, if any
+func (t ImportedTask) UUID() string {
+	uuid, _ := t.Task["uuid"].(string)
+	return strings.ToLower(uuid)
+}
+
+// Description returns the description of the task
+func (t ImportedTask) Description() string {
+	description, _ := t.Task["description"].(string)
+	return description
+}
+
+// ImportError is an entry of the import that could not be read
+type ImportError struct {
+	Line    int    `json:"line"`
+	Message string `json:"message"`
+}
+
+// ImportPreview is what an import would do, returned by dry runs
+type ImportPreview struct {
+	Tasks      []ImportedTask `json:"tasks"`
+	Errors     []ImportError  `json:"errors"`
+	Importable int            `json:"importable"`
+	Duplicates int            `json:"duplicates"`
+}
+
+// ImportResult is the outcome of an import job
+type ImportResult struct {
+	// Imported lists the UUIDs of the imported tasks
+	Imported   []string       `json:"imported"`
+	Duplicates []ImportedTask `json:"duplicates"`
+}
@@ -0,0 +1,95 @@
+package models
+
+import (
+	"sync"
+	"time"
+)
+
+// JobRecord is the outcome of a queued job, kept so its owner can look it up later
+type JobRecord struct {
+	ID        string      `json:"id"`
+	Name      string      `json:"name"`
+	Status    string      `json:"status"` // queued, in-progress, success, failure
+	Error     string      `json:"error,omitempty"`
+	Result    interface{} `json:"result,omitempty"`
+	CreatedAt string      `json:"createdAt"`
+	UpdatedAt string      `json:"updatedAt"`
+	owner     string
+}
+
+// JobStore keeps the latest job records in memory, with a max of 1000 entries
+type JobStore struct {
+	mu      sync.RWMutex
+	records map[string]*JobRecord
+	order   []string
+	maxSize int
+}
+
+var (
+	// GlobalJobStore is the global instance of the job store
+	GlobalJobStore *JobStore
+	jobStoreOnce   sync.Once
+)
+
+// GetJobStore returns the singleton instance of JobStore
+func GetJobStore() *JobStore {
+	jobStoreOnce.Do(func() {
+		GlobalJobStore = &JobStore{
+			records: make(map[string]*JobRecord),
+			maxSize: 1000,
+		}
+	})
+	return GlobalJobStore
+}
+
+// Create records a newly queued job owned by the given user UUID
+func (js *JobStore) Create(id, name, owner string) {
+	js.mu.Lock()
+	defer js.mu.Unlock()
+
+	now := time.Now().Format(time.RFC3339)
+	js.records[id] = &JobRecord{
+		ID:        id,
+		Name:      name,
+		Status:    "queued",
+		CreatedAt: now,
+		UpdatedAt: now,
+		owner:     owner,
+	}
+	js.order = append(js.order, id)
+
+	if len(js.order) > js.maxSize {
+		evicted := js.order[:len(js.order)-js.maxSize]
+		for _, old := range evicted {
+			delete(js.records, old)
+		}
+		js.order = append([]string(nil), js.order[len(evicted):]...)
+	}
+}
+
+// Update sets the status of a job, along with its error and result once it has finished
+func (js *JobStore) Update(id, status, errMessage string, result interface{}) {
+	js.mu.Lock()
+	defer js.mu.Unlock()
+
+	record, ok := js.records[id]
+	if !ok {
+		return
+	}
+	record.Status = status
+	record.Error = errMessage
+	record.Result = result
+	record.UpdatedAt = time.Now().Format(time.RFC3339)
+}
+
+// Get returns a copy of the job record if it belongs to the given user
+func (js *JobStore) Get(id, owner string) (JobRecord, bool) {
+	js.mu.RLock()
+	defer js.mu.RUnlock()
+
+	record, ok := js.records[id]
+	if !ok || record.owner != owner {
+		return JobRecord{}, false
+	}
+	return *record, true
+}
@@ -0,0 +1,53 @@
+package models
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestJobStore(maxSize int) *JobStore {
+	return &JobStore{records: make(map[string]*JobRecord), maxSize: maxSize}
+}
+
+func TestJobStore_OwnerOnly(t *testing.T) {
+	js := newTestJobStore(10)
+	js.Create("job-1", "Edit Task", "user-a")
+
+	record, ok := js.Get("job-1", "user-a")
+	assert.True(t, ok)
+	assert.Equal(t, "queued", record.Status)
+
+	_, ok = js.Get("job-1", "user-b")
+	assert.False(t, ok)
+}
+
+func TestJobStore_Update(t *testing.T) {
+	js := newTestJobStore(10)
+	js.Create("job-1", "Edit Task", "user-a")
+	js.Update("job-1", "failure", "conflict", map[string]string{"taskUuid": "t1"})
+
+	record, _ := js.Get("job-1", "user-a")
+	assert.Equal(t, "failure", record.Status)
+	assert.Equal(t, "conflict", record.Error)
+	assert.Equal(t, map[string]string{"taskUuid": "t1"}, record.Result)
+
+	// unknown jobs are ignored
+	js.Update("job-2", "success", "", nil)
+	_, ok := js.Get("job-2", "user-a")
+	assert.False(t, ok)
+}
+
+func TestJobStore_EvictsOldest(t *testing.T) {
+	js := newTestJobStore(3)
+	for i := 0; i < 5; i++ {
+		js.Create(fmt.Sprintf("job-%d", i), "Edit Task", "user-a")
+	}
+
+	_, ok := js.Get("job-1", "user-a")
+	assert.False(t, ok)
+	_, ok = js.Get("job-2", "user-a")
+	assert.True(t, ok)
+	assert.Len(t, js.records, 3)
+}
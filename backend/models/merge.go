@@ -0,0 +1,38 @@
+package models
+
+// MergeResult is the outcome of a three-way merge between the version of a task a
+// client edited (base), the version now in the replica (current) and the client's edit
+type MergeResult struct {
+	Applied   []string `json:"applied"`             // fields taken from the client's edit
+	Kept      []string `json:"kept"`                // fields changed by another client, left as they are
+	Conflicts []string `json:"conflicts,omitempty"` // fields both sides changed to different values
+}
+
+// MergeTasks merges field by field: a field changed on one side only takes that side's
+// value, a field changed identically on both sides is kept, and a field changed to
+// different values on both sides is a conflict
+func MergeTasks(base, current, proposed Task) MergeResult {
+	theirs := make(map[string]FieldChange)
+	for _, change := range DiffTasks(base, current) {
+		theirs[change.Field] = change
+	}
+	mine := make(map[string]FieldChange)
+	for _, change := range DiffTasks(base, proposed) {
+		mine[change.Field] = change
+	}
+
+	result := MergeResult{Applied: []string{}, Kept: []string{}}
+	for _, field := range historyFieldOrder {
+		myChange, changedByMe := mine[field]
+		theirChange, changedByThem := theirs[field]
+		switch {
+		case changedByMe && changedByThem && myChange.New != theirChange.New:
+			result.Conflicts = append(result.Conflicts, field)
+		case changedByMe && !changedByThem:
+			result.Applied = append(result.Applied, field)
+		case changedByThem:
+			result.Kept = append(result.Kept, field)
+		}
+	}
+	return result
+}
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"ccsync_backend/utils"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// dataPath returns the path of a file in the directory named by CCSYNC_DATA_DIR, where
+// stores keep what must survive a restart. Without it, stores are kept in memory only
+// and an empty path is returned.
+func dataPath(name string) string {
+	dir := os.Getenv("CCSYNC_DATA_DIR")
+	if dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, name)
+}
+
+// loadData reads the JSON file at path into v. A missing file, or an empty path, leaves
+// v as it is.
+func loadData(path string, v interface{}) {
+	if path == "" {
+		return
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return
+	}
+	if err == nil {
+		err = json.Unmarshal(data, v)
+	}
+	if err != nil {
+		utils.Logger.Warnf("Failed to load %s: %v", path, err)
+	}
+}
+
+// saveData writes v as JSON to path, through a temporary file renamed over it so a
+// crash never leaves the file half written. Failures are logged, the data being kept
+// in memory anyway.
+func saveData(path string, v interface{}) {
+	if path == "" {
+		return
+	}
+	if err := writeData(path, v); err != nil {
+		utils.Logger.Warnf("Failed to save %s: %v", path, err)
+	}
+}
+
+func writeData(path string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
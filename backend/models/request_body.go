@@ -30,6 +30,8 @@ type ModifyTaskRequestBody struct {
 	Due              string   `json:"due"`
 	Tags             []string `json:"tags"`
 	Depends          []string `json:"depends"`
+	ExpectedModified string   `json:"expectedModified,omitempty"`
+	Base             *Task    `json:"base,omitempty"`
 }
 type EditTaskRequestBody struct {
 	Email            string       `json:"email"`
@@ -47,6 +49,8 @@ type EditTaskRequestBody struct {
 	Due              string       `json:"due"`
 	Recur            string       `json:"recur"`
 	Annotations      []Annotation `json:"annotations"`
+	ExpectedModified string       `json:"expectedModified,omitempty"`
+	Base             *Task        `json:"base,omitempty"`
 }
 
 type CompleteTaskRequestBody struct {
@@ -54,22 +58,177 @@ type CompleteTaskRequestBody struct {
 	EncryptionSecret string `json:"encryptionSecret"`
 	UUID             string `json:"UUID"`
 	TaskUUID         string `json:"taskuuid"`
+	ExpectedModified string `json:"expectedModified,omitempty"`
+	Base             *Task  `json:"base,omitempty"`
+	Cascade          string `json:"cascade,omitempty"` // strip, cascade or refuse for tasks depending on this one
 }
 type DeleteTaskRequestBody struct {
 	Email            string `json:"email"`
 	EncryptionSecret string `json:"encryptionSecret"`
 	UUID             string `json:"UUID"`
 	TaskUUID         string `json:"taskuuid"`
+	ExpectedModified string `json:"expectedModified,omitempty"`
+	Base             *Task  `json:"base,omitempty"`
+	Cascade          string `json:"cascade,omitempty"` // strip, cascade or refuse for tasks depending on this one
 }
 type BulkCompleteTaskRequestBody struct {
 	Email            string   `json:"email"`
 	EncryptionSecret string   `json:"encryptionSecret"`
 	UUID             string   `json:"UUID"`
 	TaskUUIDs        []string `json:"taskuuids"`
+	Cascade          string   `json:"cascade,omitempty"` // strip, cascade or refuse for tasks depending on these
 }
 type BulkDeleteTaskRequestBody struct {
 	Email            string   `json:"email"`
 	EncryptionSecret string   `json:"encryptionSecret"`
 	UUID             string   `json:"UUID"`
 	TaskUUIDs        []string `json:"taskuuids"`
+	Cascade          string   `json:"cascade,omitempty"` // strip, cascade or refuse for tasks depending on these
+}
+type StartTaskRequestBody struct {
+	Email            string `json:"email"`
+	EncryptionSecret string `json:"encryptionSecret"`
+	UUID             string `json:"UUID"`
+}
+type StopTaskRequestBody struct {
+	Email            string `json:"email"`
+	EncryptionSecret string `json:"encryptionSecret"`
+	UUID             string `json:"UUID"`
+}
+type UpdateRecurrenceRequestBody struct {
+	Email            string  `json:"email"`
+	EncryptionSecret string  `json:"encryptionSecret"`
+	UUID             string  `json:"UUID"`
+	Recur            string  `json:"recur"`
+	Until            *string `json:"until"`
+}
+type SkipRecurrenceRequestBody struct {
+	Email            string `json:"email"`
+	EncryptionSecret string `json:"encryptionSecret"`
+	UUID             string `json:"UUID"`
+}
+type StopRecurrenceRequestBody struct {
+	Email            string `json:"email"`
+	EncryptionSecret string `json:"encryptionSecret"`
+	UUID             string `json:"UUID"`
+	DeletePending    bool   `json:"deletePending"`
+}
+type AddAnnotationRequestBody struct {
+	Email            string `json:"email"`
+	EncryptionSecret string `json:"encryptionSecret"`
+	UUID             string `json:"UUID"`
+	Description      string `json:"description"`
+}
+type BatchRequestBody struct {
+	Email            string           `json:"email"`
+	EncryptionSecret string           `json:"encryptionSecret"`
+	UUID             string           `json:"UUID"`
+	Operations       []BatchOperation `json:"operations"`
+}
+type BulkModifyTaskRequestBody struct {
+	Email            string        `json:"email"`
+	EncryptionSecret string        `json:"encryptionSecret"`
+	UUID             string        `json:"UUID"`
+	TaskUUIDs        []string      `json:"taskuuids"`
+	Filter           string        `json:"filter"`
+	Patch            BulkTaskPatch `json:"patch"`
+}
+
+// BulkTaskPatch is the change applied to every task of a bulk modification. Project
+// and priority are left alone when omitted and cleared when empty; dueShift moves
+// existing due dates by a signed duration such as "+2d" or "-1wk".
+type BulkTaskPatch struct {
+	Project    *string  `json:"project,omitempty"`
+	Priority   *string  `json:"priority,omitempty"`
+	AddTags    []string `json:"addTags,omitempty"`
+	RemoveTags []string `json:"removeTags,omitempty"`
+	DueShift   string   `json:"dueShift,omitempty"`
+}
+
+// BulkModifyResult reports the tasks a bulk modification changed and the ones it failed on
+type BulkModifyResult struct {
+	Modified []string          `json:"modified"`
+	Failed   map[string]string `json:"failed"`
+}
+type RestoreTaskRequestBody struct {
+	Email            string `json:"email"`
+	EncryptionSecret string `json:"encryptionSecret"`
+	UUID             string `json:"UUID"`
+}
+type PurgeTasksRequestBody struct {
+	Email            string   `json:"email"`
+	EncryptionSecret string   `json:"encryptionSecret"`
+	UUID             string   `json:"UUID"`
+	TaskUUIDs        []string `json:"taskuuids"`
+	OlderThan        string   `json:"olderThan"`
+}
+
+// PurgeResult reports the tasks a purge removed and the ones it refused or failed on
+type PurgeResult struct {
+	Purged []string          `json:"purged"`
+	Failed map[string]string `json:"failed"`
+}
+type ReopenTaskRequestBody struct {
+	Email            string `json:"email"`
+	EncryptionSecret string `json:"encryptionSecret"`
+	UUID             string `json:"UUID"`
+}
+type BulkReopenTaskRequestBody struct {
+	Email            string   `json:"email"`
+	EncryptionSecret string   `json:"encryptionSecret"`
+	UUID             string   `json:"UUID"`
+	TaskUUIDs        []string `json:"taskuuids"`
+}
+type TimezoneSettingsRequestBody struct {
+	Email            string `json:"email"`
+	EncryptionSecret string `json:"encryptionSecret"`
+	UUID             string `json:"UUID"`
+	Timezone         string `json:"timezone"` // IANA name, e.g. Europe/Berlin; empty resets to UTC
+}
+type UrgencySettingsRequestBody struct {
+	Email            string             `json:"email"`
+	EncryptionSecret string             `json:"encryptionSecret"`
+	UUID             string             `json:"UUID"`
+	Coefficients     map[string]float64 `json:"coefficients"`
+}
+type TaskrcSettingsRequestBody struct {
+	Email            string            `json:"email"`
+	EncryptionSecret string            `json:"encryptionSecret"`
+	UUID             string            `json:"UUID"`
+	Settings         map[string]string `json:"settings"`
+}
+type ContextRequestBody struct {
+	Email            string `json:"email"`
+	EncryptionSecret string `json:"encryptionSecret"`
+	UUID             string `json:"UUID"`
+	Name             string `json:"name"`
+	Filter           string `json:"filter"`
+}
+type ActiveContextRequestBody struct {
+	Email            string `json:"email"`
+	EncryptionSecret string `json:"encryptionSecret"`
+	UUID             string `json:"UUID"`
+	Name             string `json:"name"`
+}
+type ImportContextsRequestBody struct {
+	Email            string `json:"email"`
+	EncryptionSecret string `json:"encryptionSecret"`
+	UUID             string `json:"UUID"`
+	Taskrc           string `json:"taskrc"`
+}
+type FeedTokenRequestBody struct {
+	Email            string `json:"email"`
+	EncryptionSecret string `json:"encryptionSecret"`
+	UUID             string `json:"UUID"`
+	Name             string `json:"name"`
+	Scope            string `json:"scope,omitempty"` // ics (default) or caldav
+}
+type ImportRequestBody struct {
+	Email            string            `json:"email"`
+	EncryptionSecret string            `json:"encryptionSecret"`
+	UUID             string            `json:"UUID"`
+	Format           string            `json:"format"` // todotxt, csv or json
+	Data             string            `json:"data"`
+	Mapping          map[string]string `json:"mapping,omitempty"` // task attribute -> CSV column
+	DryRun           bool              `json:"dryRun"`
 }
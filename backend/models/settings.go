@@ -0,0 +1,96 @@
+package models
+
+import (
+	"sync"
+)
+
+// UserSettings are the preferences kept for a user
+type UserSettings struct {
+	Timezone string `json:"timezone"` // IANA name; empty means UTC
+	// Urgency overrides urgency coefficients, named as in .taskrc without the urgency.
+	// prefix and .coefficient suffix, e.g. "due" or "user.tag.next"
+	Urgency map[string]float64 `json:"urgency,omitempty"`
+	// Taskrc holds rc settings applied to every replica ccsync creates for the user
+	Taskrc map[string]string `json:"taskrc,omitempty"`
+	// Contexts maps the names of the user's contexts to their filter
+	Contexts map[string]string `json:"contexts,omitempty"`
+	// Context is the name of the active context, applied to task listings
+	Context string `json:"context,omitempty"`
+}
+
+// settingsFile is the file in CCSYNC_DATA_DIR the settings are saved to
+const settingsFile = "settings.json"
+
+// SettingsStore keeps the settings of every user, keyed by user UUID. With a path they
+// are saved there on every update, and survive restarts.
+type SettingsStore struct {
+	mu    sync.RWMutex
+	users map[string]UserSettings
+	path  string
+}
+
+var (
+	// GlobalSettingsStore is the global instance of the settings store
+	GlobalSettingsStore *SettingsStore
+	settingsOnce        sync.Once
+)
+
+// GetSettingsStore returns the singleton instance of SettingsStore
+func GetSettingsStore() *SettingsStore {
+	settingsOnce.Do(func() {
+		GlobalSettingsStore = &SettingsStore{
+			users: make(map[string]UserSettings),
+			path:  dataPath(settingsFile),
+		}
+		loadData(GlobalSettingsStore.path, &GlobalSettingsStore.users)
+		if GlobalSettingsStore.users == nil {
+			GlobalSettingsStore.users = make(map[string]UserSettings)
+		}
+	})
+	return GlobalSettingsStore
+}
+
+// Get returns a copy of the settings of a user, the zero value if none were saved
+func (ss *SettingsStore) Get(userUUID string) UserSettings {
+	ss.mu.RLock()
+	defer ss.mu.RUnlock()
+	return ss.users[userUUID].clone()
+}
+
+// Update applies change to a copy of the settings of a user, stores it and returns
+// another copy. Callers thus never share the stored maps, which are read and written
+// outside the lock.
+func (ss *SettingsStore) Update(userUUID string, change func(*UserSettings)) UserSettings {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	settings := ss.users[userUUID].clone()
+	change(&settings)
+	ss.users[userUUID] = settings
+	saveData(ss.path, ss.users)
+	return settings.clone()
+}
+
+// clone copies the settings along with their maps
+func (s UserSettings) clone() UserSettings {
+	if s.Urgency != nil {
+		urgency := make(map[string]float64, len(s.Urgency))
+		for key, value := range s.Urgency {
+			urgency[key] = value
+		}
+		s.Urgency = urgency
+	}
+	s.Taskrc = cloneStrings(s.Taskrc)
+	s.Contexts = cloneStrings(s.Contexts)
+	return s
+}
+
+func cloneStrings(values map[string]string) map[string]string {
+	if values == nil {
+		return nil
+	}
+	cloned := make(map[string]string, len(values))
+	for key, value := range values {
+		cloned[key] = value
+	}
+	return cloned
+}
@@ -1,5 +1,13 @@
 package models
 
+import (
+	"ccsync_backend/utils"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+)
+
 // Annotation represents a single annotation entry on a Taskwarrior task
 type Annotation struct {
 	Entry       string `json:"entry"`
@@ -21,9 +29,94 @@ type Task struct {
 	End         string       `json:"end"`
 	Entry       string       `json:"entry"`
 	Wait        string       `json:"wait"`
+	Scheduled   string       `json:"scheduled"`
 	Modified    string       `json:"modified"`
 	Depends     []string     `json:"depends"`
 	RType       string       `json:"rtype"`
 	Recur       string       `json:"recur"`
+	Until       string       `json:"until"`
+	Parent      string       `json:"parent"`
+	Mask        string       `json:"mask"`
 	Annotations []Annotation `json:"annotations"`
+	// UDAs holds the user-defined attributes of the task, by name
+	UDAs map[string]interface{} `json:"udas,omitempty"`
+}
+
+// taskAttributes are the JSON names of the attributes Task models, along with the
+// built-in Taskwarrior attributes it ignores, which are therefore not UDAs
+var taskAttributes = func() map[string]bool {
+	attributes := map[string]bool{"imask": true, "last": true, "template": true}
+	taskType := reflect.TypeOf(Task{})
+	for i := 0; i < taskType.NumField(); i++ {
+		if name := strings.Split(taskType.Field(i).Tag.Get("json"), ",")[0]; name != "" && name != "-" {
+			attributes[name] = true
+		}
+	}
+	return attributes
+}()
+
+// UnmarshalJSON decodes a task as exported by Taskwarrior, collecting the attributes
+// Task does not model into UDAs
+func (t *Task) UnmarshalJSON(data []byte) error {
+	type plainTask Task
+	if err := json.Unmarshal(data, (*plainTask)(t)); err != nil {
+		return err
+	}
+
+	var attributes map[string]interface{}
+	if err := json.Unmarshal(data, &attributes); err != nil {
+		return err
+	}
+	for name, value := range attributes {
+		if taskAttributes[name] {
+			continue
+		}
+		if t.UDAs == nil {
+			t.UDAs = make(map[string]interface{})
+		}
+		t.UDAs[name] = value
+	}
+	return nil
+}
+
+// TrashedTask is a deleted task as listed in the trash, with the time it was deleted
+type TrashedTask struct {
+	Task
+	DeletedAt string `json:"deletedAt"`
+}
+
+// WithDateOffsets returns a copy of the task with its dates rewritten from Taskwarrior's
+// compact UTC format to RFC 3339 in loc, with an explicit offset (e.g.
+// "2025-03-30T03:00:00+02:00"). Values that are not Taskwarrior dates are kept as is.
+func (t Task) WithDateOffsets(loc *time.Location) Task {
+	localize := func(value string) string {
+		parsed, err := time.Parse(utils.TaskwarriorDateLayout, value)
+		if err != nil {
+			return value
+		}
+		return parsed.In(loc).Format(time.RFC3339)
+	}
+
+	for _, date := range []*string{&t.Due, &t.Start, &t.End, &t.Entry, &t.Wait, &t.Scheduled, &t.Modified, &t.Until} {
+		*date = localize(*date)
+	}
+	if len(t.Annotations) > 0 {
+		annotations := make([]Annotation, len(t.Annotations))
+		for i, annotation := range t.Annotations {
+			annotation.Entry = localize(annotation.Entry)
+			annotations[i] = annotation
+		}
+		t.Annotations = annotations
+	}
+	return t
+}
+
+// TaskDependencies extracts the dependency information of tasks, as the dependency
+// checks and graph of utils take it
+func TaskDependencies(tasks []Task) []utils.TaskDependency {
+	taskDeps := make([]utils.TaskDependency, len(tasks))
+	for i, task := range tasks {
+		taskDeps[i] = utils.TaskDependency{UUID: task.UUID, Depends: task.Depends, Status: task.Status}
+	}
+	return taskDeps
 }
@@ -0,0 +1,81 @@
+package models
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTask_WithDateOffsets(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	assert.NoError(t, err)
+
+	task := Task{
+		Due:         "20250330T003000Z", // before the switch to summer time
+		Wait:        "20250330T013000Z", // after it
+		Entry:       "20251026T003000Z", // summer time, just before clocks go back
+		End:         "20251026T013000Z", // winter time, the repeated hour
+		Recur:       "weekly",
+		Annotations: []Annotation{{Entry: "20250101T120000Z", Description: "note"}},
+	}
+	localized := task.WithDateOffsets(berlin)
+
+	assert.Equal(t, "2025-03-30T01:30:00+01:00", localized.Due)
+	assert.Equal(t, "2025-03-30T03:30:00+02:00", localized.Wait)
+	assert.Equal(t, "2025-10-26T02:30:00+02:00", localized.Entry)
+	assert.Equal(t, "2025-10-26T02:30:00+01:00", localized.End)
+	assert.Equal(t, "", localized.Start)
+	assert.Equal(t, "weekly", localized.Recur)
+	assert.Equal(t, "2025-01-01T13:00:00+01:00", localized.Annotations[0].Entry)
+	assert.Equal(t, "20250101T120000Z", task.Annotations[0].Entry, "the original task is left untouched")
+}
+
+func TestSettingsStore_Update(t *testing.T) {
+	ss := &SettingsStore{users: make(map[string]UserSettings)}
+	assert.Equal(t, UserSettings{}, ss.Get("user-a"))
+
+	updated := ss.Update("user-a", func(s *UserSettings) { s.Timezone = "Asia/Tokyo" })
+	assert.Equal(t, "Asia/Tokyo", updated.Timezone)
+	assert.Equal(t, "Asia/Tokyo", ss.Get("user-a").Timezone)
+	assert.Equal(t, "", ss.Get("user-b").Timezone)
+
+	// callers get copies of the maps, never the stored ones
+	updated = ss.Update("user-a", func(s *UserSettings) { s.Contexts = map[string]string{"work": "+work"} })
+	updated.Contexts["home"] = "+home"
+	read := ss.Get("user-a")
+	read.Contexts["errands"] = "+errands"
+	assert.Equal(t, map[string]string{"work": "+work"}, ss.Get("user-a").Contexts)
+}
+
+func TestSettingsStore_Persisted(t *testing.T) {
+	path := filepath.Join(t.TempDir(), settingsFile)
+	ss := &SettingsStore{users: make(map[string]UserSettings), path: path}
+	ss.Update("user-a", func(s *UserSettings) {
+		s.Timezone = "Asia/Tokyo"
+		s.Contexts = map[string]string{"work": "+work"}
+	})
+
+	restarted := &SettingsStore{users: make(map[string]UserSettings), path: path}
+	loadData(restarted.path, &restarted.users)
+	assert.Equal(t, "Asia/Tokyo", restarted.Get("user-a").Timezone)
+	assert.Equal(t, map[string]string{"work": "+work"}, restarted.Get("user-a").Contexts)
+}
+
+func TestTask_UnmarshalJSON_CapturesUDAs(t *testing.T) {
+	var task Task
+	err := json.Unmarshal([]byte(`{
+		"uuid": "a", "description": "Write report", "scheduled": "20250310T000000Z",
+		"imask": 2, "estimate": 3, "complexity": "large"
+	}`), &task)
+	assert.NoError(t, err)
+	assert.Equal(t, "Write report", task.Description)
+	assert.Equal(t, "20250310T000000Z", task.Scheduled)
+	assert.Equal(t, map[string]interface{}{"estimate": 3.0, "complexity": "large"}, task.UDAs)
+
+	var plain Task
+	assert.NoError(t, json.Unmarshal([]byte(`{"uuid": "b"}`), &plain))
+	assert.Nil(t, plain.UDAs)
+}
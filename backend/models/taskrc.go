@@ -0,0 +1,127 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// MaxTaskrcSettings is the number of rc settings a user can store
+const MaxTaskrcSettings = 100
+
+// maxTaskrcValueLength bounds the length of a single rc value
+const maxTaskrcValueLength = 256
+
+// taskrcKeys are the rc keys users may set, with a validator for their value. Keys that
+// would change how ccsync talks to Taskwarrior (sync.*, confirmation, verbose, json.*,
+// data.location, ...) are deliberately left out.
+var taskrcKeys = map[string]func(string) error{
+	"default.project":       anyValue,
+	"default.due":           anyValue,
+	"default.scheduled":     anyValue,
+	"dateformat":            anyValue,
+	"dateformat.report":     anyValue,
+	"dateformat.annotation": anyValue,
+	"weekstart":             oneOf("sunday", "monday"),
+	"displayweeknumber":     booleanValue,
+	"due":                   positiveInteger,
+	"recurrence":            booleanValue,
+	"recurrence.limit":      positiveInteger,
+	"recurrence.indicator":  anyValue,
+	"search.case.sensitive": booleanValue,
+	"regex":                 booleanValue,
+}
+
+// udaAttributes are the settings of a user-defined attribute, uda.<name>.<attribute>
+var udaAttributes = map[string]func(string) error{
+	"type":      oneOf("string", "numeric", "date", "duration"),
+	"label":     anyValue,
+	"values":    anyValue,
+	"default":   anyValue,
+	"indicator": anyValue,
+}
+
+var udaKeyPattern = regexp.MustCompile(`^uda\.([A-Za-z][A-Za-z0-9_]*)\.([a-z]+)$`)
+
+// builtinAttributes cannot be redefined as UDAs, except priority, which Taskwarrior 3
+// itself defines as one
+var builtinAttributes = map[string]bool{
+	"annotations": true, "depends": true, "description": true, "due": true, "end": true,
+	"entry": true, "id": true, "imask": true, "mask": true, "modified": true, "parent": true,
+	"project": true, "recur": true, "rtype": true, "scheduled": true, "start": true,
+	"status": true, "tags": true, "template": true, "until": true, "urgency": true,
+	"uuid": true, "wait": true,
+}
+
+// ValidateTaskrcSettings checks rc settings against the allowlist of keys users may set
+func ValidateTaskrcSettings(settings map[string]string) error {
+	if len(settings) > MaxTaskrcSettings {
+		return fmt.Errorf("too many settings: %d (max %d)", len(settings), MaxTaskrcSettings)
+	}
+	for key, value := range settings {
+		validate, err := taskrcValidator(key)
+		if err != nil {
+			return err
+		}
+		if value == "" {
+			return fmt.Errorf("setting '%s' cannot be empty", key)
+		}
+		if len(value) > maxTaskrcValueLength {
+			return fmt.Errorf("setting '%s' is longer than %d characters", key, maxTaskrcValueLength)
+		}
+		if strings.IndexFunc(value, unicode.IsControl) >= 0 {
+			return fmt.Errorf("setting '%s' contains control characters", key)
+		}
+		if err := validate(value); err != nil {
+			return fmt.Errorf("invalid value for '%s': %v", key, err)
+		}
+	}
+	return nil
+}
+
+func taskrcValidator(key string) (func(string) error, error) {
+	if validate, ok := taskrcKeys[key]; ok {
+		return validate, nil
+	}
+	if matches := udaKeyPattern.FindStringSubmatch(key); matches != nil {
+		if builtinAttributes[matches[1]] {
+			return nil, fmt.Errorf("setting '%s' would redefine the built-in attribute '%s'", key, matches[1])
+		}
+		if validate, ok := udaAttributes[matches[2]]; ok {
+			return validate, nil
+		}
+	}
+	return nil, fmt.Errorf("setting '%s' is not allowed", key)
+}
+
+func anyValue(string) error {
+	return nil
+}
+
+func booleanValue(value string) error {
+	switch strings.ToLower(value) {
+	case "on", "off", "yes", "no", "true", "false", "1", "0", "y", "n":
+		return nil
+	}
+	return fmt.Errorf("expected on or off")
+}
+
+func positiveInteger(value string) error {
+	if n, err := strconv.Atoi(value); err != nil || n < 1 {
+		return fmt.Errorf("expected a positive integer")
+	}
+	return nil
+}
+
+func oneOf(allowed ...string) func(string) error {
+	return func(value string) error {
+		for _, candidate := range allowed {
+			if value == candidate {
+				return nil
+			}
+		}
+		return fmt.Errorf("expected one of %s", strings.Join(allowed, ", "))
+	}
+}
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateTaskrcSettings(t *testing.T) {
+	assert.NoError(t, ValidateTaskrcSettings(map[string]string{
+		"weekstart":             "monday",
+		"default.project":       "Inbox",
+		"recurrence.limit":      "3",
+		"search.case.sensitive": "no",
+		"uda.estimate.type":     "duration",
+		"uda.estimate.label":    "Estimate",
+		"uda.priority.values":   "H,M,L,",
+		"dateformat.annotation": "Y-M-D",
+	}))
+
+	invalid := []map[string]string{
+		{"sync.encryption_secret": "secret"},
+		{"data.location": "/tmp"},
+		{"confirmation": "on"},
+		{"weekstart": "tuesday"},
+		{"recurrence.limit": "0"},
+		{"regex": "maybe"},
+		{"uda.due.type": "date"},
+		{"uda.estimate.type": "list"},
+		{"uda.estimate.color": "red"},
+		{"default.project": ""},
+		{"default.project": "Inbox\nsync.server.url=http://evil"},
+	}
+	for _, settings := range invalid {
+		assert.Error(t, ValidateTaskrcSettings(settings), "%v", settings)
+	}
+}
@@ -0,0 +1,12 @@
+package models
+
+// TodoFields are the task attributes a CalDAV client sets when it saves a to-do
+type TodoFields struct {
+	Description string   `json:"description"`
+	Due         string   `json:"due"`      // Taskwarrior format; empty for no due date
+	Priority    string   `json:"priority"` // H, M, L or empty
+	Tags        []string `json:"tags"`
+	Status      string   `json:"status"`        // pending, completed or deleted
+	Started     bool     `json:"started"`       // whether a pending task is active
+	End         string   `json:"end,omitempty"` // completion time of a completed task, if known
+}
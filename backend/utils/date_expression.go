@@ -0,0 +1,324 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// absoluteDateLayouts are the absolute dates accepted in date expressions, with
+// whether each names a whole day
+var absoluteDateLayouts = []struct {
+	layout string
+	day    bool
+}{
+	{TaskwarriorDateLayout, false},
+	{"2006-01-02T15:04:05.000Z", false},
+	{time.RFC3339, false},
+	{"2006-01-02T15:04:05", false},
+	{"2006-01-02T15:04", false},
+	{"2006-01-02", true},
+	{"20060102", true},
+}
+
+var weekdayNames = map[string]time.Weekday{
+	"sunday": time.Sunday, "sun": time.Sunday,
+	"monday": time.Monday, "mon": time.Monday,
+	"tuesday": time.Tuesday, "tue": time.Tuesday,
+	"wednesday": time.Wednesday, "wed": time.Wednesday,
+	"thursday": time.Thursday, "thu": time.Thursday,
+	"friday": time.Friday, "fri": time.Friday,
+	"saturday": time.Saturday, "sat": time.Saturday,
+}
+
+var monthNames = map[string]time.Month{
+	"january": time.January, "jan": time.January,
+	"february": time.February, "feb": time.February,
+	"march": time.March, "mar": time.March,
+	"april": time.April, "apr": time.April,
+	"may":  time.May,
+	"june": time.June, "jun": time.June,
+	"july": time.July, "jul": time.July,
+	"august": time.August, "aug": time.August,
+	"september": time.September, "sep": time.September,
+	"october": time.October, "oct": time.October,
+	"november": time.November, "nov": time.November,
+	"december": time.December, "dec": time.December,
+}
+
+// periodBounds return the [start, end) bounds of the calendar period containing t
+var periodBounds = map[string]func(t time.Time) (time.Time, time.Time){
+	"d":  DayBounds,
+	"w":  WeekBounds,
+	"ww": workWeekBounds,
+	"m":  MonthBounds,
+	"q":  QuarterBounds,
+	"y":  YearBounds,
+}
+
+var (
+	// so/eo + optional c (current), n (next) or p (previous) + period, e.g. eow, sonm, eopq
+	periodBoundaryPattern = regexp.MustCompile(`^(so|eo)([cnp]?)(d|ww|w|m|q|y)$`)
+	ordinalDayPattern     = regexp.MustCompile(`^(\d{1,2})(st|nd|rd|th)$`)
+	timeOfDayPattern      = regexp.MustCompile(`^(\d{1,2})(?::(\d{2}))?(?::(\d{2}))?(am|pm)?$`)
+	meridiemPattern       = regexp.MustCompile(`(\d) (am|pm)\b`)
+)
+
+// someday is Taskwarrior's date for "later" and "someday", far enough away to never come due
+var someday = time.Date(9999, time.December, 30, 0, 0, 0, 0, time.UTC)
+
+// ParseDateExpression resolves a date as users write it on the Taskwarrior command line,
+// relative to now and in now's location. It accepts:
+//   - absolute dates such as 2025-04-01, 2025-04-01T08:30:00Z or 20250401T083000Z
+//   - now, today, sod, eod, tomorrow, yesterday, later and someday
+//   - period boundaries sow/eow, soww/eoww (work week), som/eom, soq/eoq, soy/eoy, with
+//     c, n or p for the current, next or previous period, e.g. sonw or eopm
+//   - weekday and month names, optionally after "next", and ordinals such as 15th,
+//     naming the next such day
+//   - easter, goodfriday, eastermonday, ascension and pentecost
+//   - durations from now such as 3d, +2wks, -1w, "in 3 days" or "2 weeks ago"
+//   - any of the days above followed by a time of day, e.g. "tomorrow 9am",
+//     "monday at 17:30" or "2025-04-01 noon", or a time of day alone for today
+//
+// The second return value reports whether the result names a whole day rather than
+// a point in time.
+func ParseDateExpression(value string, now time.Time) (time.Time, bool, error) {
+	normalized := strings.Join(strings.Fields(strings.ToLower(value)), " ")
+	if normalized == "" {
+		return time.Time{}, false, fmt.Errorf("empty date")
+	}
+
+	for _, candidate := range absoluteDateLayouts {
+		loc := now.Location()
+		if strings.HasSuffix(candidate.layout, "Z") {
+			loc = time.UTC // a literal Z, which Go does not read as a zone
+		}
+		if parsed, err := time.ParseInLocation(candidate.layout, strings.TrimSpace(value), loc); err == nil {
+			return parsed, candidate.day, nil
+		}
+	}
+	normalized = meridiemPattern.ReplaceAllString(normalized, "$1$2")
+
+	if resolved, day, ok := resolveNamedDate(normalized, now); ok {
+		return resolved, day, nil
+	}
+
+	if resolved, ok := resolveRelativeDate(normalized, now); ok {
+		return resolved, false, nil
+	}
+
+	// a day followed by a time of day, e.g. "tomorrow 9am" or "friday at 17:00"
+	if i := strings.LastIndex(normalized, " "); i > 0 {
+		dayPart := strings.TrimSuffix(normalized[:i], " at")
+		if hour, minute, second, ok := parseTimeOfDay(normalized[i+1:]); ok {
+			day, wholeDay, err := ParseDateExpression(dayPart, now)
+			if err == nil && wholeDay {
+				return time.Date(day.Year(), day.Month(), day.Day(), hour, minute, second, 0, day.Location()), false, nil
+			}
+		}
+	}
+	if hour, minute, second, ok := parseTimeOfDay(normalized); ok {
+		return time.Date(now.Year(), now.Month(), now.Day(), hour, minute, second, 0, now.Location()), false, nil
+	}
+
+	return time.Time{}, false, fmt.Errorf("unable to parse date '%s'", value)
+}
+
+// resolveNamedDate resolves the named dates, which do not take a time of day themselves
+func resolveNamedDate(name string, now time.Time) (time.Time, bool, bool) {
+	today := StartOfDay(now)
+
+	switch name {
+	case "now":
+		return now, false, true
+	case "today":
+		return today, true, true
+	case "tomorrow":
+		return today.AddDate(0, 0, 1), true, true
+	case "yesterday":
+		return today.AddDate(0, 0, -1), true, true
+	case "later", "someday":
+		return someday.In(now.Location()), true, true
+	case "easter", "goodfriday", "eastermonday", "ascension", "pentecost":
+		offsets := map[string]int{"easter": 0, "goodfriday": -2, "eastermonday": 1, "ascension": 39, "pentecost": 49}
+		holiday := easterSunday(now.Year(), now.Location()).AddDate(0, 0, offsets[name])
+		if holiday.Before(today) {
+			holiday = easterSunday(now.Year()+1, now.Location()).AddDate(0, 0, offsets[name])
+		}
+		return holiday, true, true
+	}
+
+	if matches := periodBoundaryPattern.FindStringSubmatch(name); matches != nil {
+		reference := now
+		switch matches[2] {
+		case "n":
+			_, reference = periodBounds[matches[3]](now)
+		case "p":
+			start, _ := periodBounds[matches[3]](now)
+			reference = start.Add(-time.Second)
+		}
+		start, end := periodBounds[matches[3]](reference)
+		if matches[1] == "so" {
+			return start, true, true
+		}
+		return end.Add(-time.Second), false, true
+	}
+
+	next := strings.HasPrefix(name, "next ")
+	name = strings.TrimPrefix(name, "next ")
+
+	if weekday, ok := weekdayNames[name]; ok {
+		days := (int(weekday) - int(now.Weekday()) + 7) % 7
+		if days == 0 {
+			days = 7
+		}
+		return today.AddDate(0, 0, days), true, true
+	}
+
+	if month, ok := monthNames[name]; ok {
+		year := now.Year()
+		if month <= now.Month() {
+			year++
+		}
+		return time.Date(year, month, 1, 0, 0, 0, 0, now.Location()), true, true
+	}
+
+	if matches := ordinalDayPattern.FindStringSubmatch(name); matches != nil {
+		day, _ := strconv.Atoi(matches[1])
+		if day < 1 || day > 31 {
+			return time.Time{}, false, false
+		}
+		// the next month, this one included if the day is still ahead, that has the day
+		for months := 0; months <= 12; months++ {
+			first := time.Date(now.Year(), now.Month()+time.Month(months), 1, 0, 0, 0, 0, now.Location())
+			candidate := first.AddDate(0, 0, day-1)
+			if candidate.Month() == first.Month() && candidate.After(today) {
+				return candidate, true, true
+			}
+		}
+	}
+
+	switch name {
+	case "week", "month", "quarter", "year":
+		if next {
+			_, end := periodBounds[name[:1]](now)
+			return end, true, true
+		}
+	}
+
+	return time.Time{}, false, false
+}
+
+// resolveRelativeDate resolves durations from now: 3d, +3d, -1w, "in 3 days", "3 days ago"
+func resolveRelativeDate(value string, now time.Time) (time.Time, bool) {
+	sign := 1
+	if strings.HasPrefix(value, "in ") {
+		value = value[len("in "):]
+	} else if strings.HasSuffix(value, " ago") {
+		value, sign = strings.TrimSuffix(value, " ago"), -1
+	}
+	period, periodSign, err := ParseSignedPeriod(value)
+	if err != nil || period.IsZero() || period.Weekdays {
+		return time.Time{}, false
+	}
+	return period.AddTo(now, sign*periodSign), true
+}
+
+// parseTimeOfDay parses 9am, 9:30pm, 17:00, 17:00:30, noon and midnight. A bare hour
+// without am/pm is not a time of day, so that "3" is not mistaken for one.
+func parseTimeOfDay(value string) (int, int, int, bool) {
+	switch value {
+	case "noon":
+		return 12, 0, 0, true
+	case "midnight":
+		return 0, 0, 0, true
+	}
+
+	matches := timeOfDayPattern.FindStringSubmatch(value)
+	if matches == nil || (matches[2] == "" && matches[4] == "") {
+		return 0, 0, 0, false
+	}
+	hour, _ := strconv.Atoi(matches[1])
+	minute, _ := strconv.Atoi(matches[2])
+	second, _ := strconv.Atoi(matches[3])
+	if minute > 59 || second > 59 {
+		return 0, 0, 0, false
+	}
+
+	switch matches[4] {
+	case "am", "pm":
+		if hour < 1 || hour > 12 {
+			return 0, 0, 0, false
+		}
+		hour %= 12
+		if matches[4] == "pm" {
+			hour += 12
+		}
+	default:
+		if hour > 23 {
+			return 0, 0, 0, false
+		}
+	}
+	return hour, minute, second, true
+}
+
+// easterSunday computes the date of Easter in the Gregorian calendar (anonymous algorithm)
+func easterSunday(year int, loc *time.Location) time.Time {
+	a := year % 19
+	b, c := year/100, year%100
+	d, e := b/4, b%4
+	f := (b + 8) / 25
+	g := (b - f + 1) / 3
+	h := (19*a + b - d - g + 15) % 30
+	i, k := c/4, c%4
+	l := (32 + 2*e + 2*i - h - k) % 7
+	m := (a + 11*h + 22*l) / 451
+	month := (h + l - 7*m + 114) / 31
+	day := (h+l-7*m+114)%31 + 1
+	return time.Date(year, time.Month(month), day, 0, 0, 0, 0, loc)
+}
+
+// StartOfDay returns midnight of the day containing t, in its location
+func StartOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// DayBounds returns the day containing t
+func DayBounds(t time.Time) (time.Time, time.Time) {
+	start := StartOfDay(t)
+	return start, start.AddDate(0, 0, 1)
+}
+
+// WeekBounds returns the week containing t, starting on Monday
+func WeekBounds(t time.Time) (time.Time, time.Time) {
+	daysSinceMonday := (int(t.Weekday()) + 6) % 7
+	start := StartOfDay(t).AddDate(0, 0, -daysSinceMonday)
+	return start, start.AddDate(0, 0, 7)
+}
+
+// workWeekBounds returns Monday to Friday of the week containing t
+func workWeekBounds(t time.Time) (time.Time, time.Time) {
+	start, _ := WeekBounds(t)
+	return start, start.AddDate(0, 0, 5)
+}
+
+// MonthBounds returns the month containing t
+func MonthBounds(t time.Time) (time.Time, time.Time) {
+	start := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+	return start, start.AddDate(0, 1, 0)
+}
+
+// QuarterBounds returns the calendar quarter containing t
+func QuarterBounds(t time.Time) (time.Time, time.Time) {
+	firstMonth := time.Month((int(t.Month())-1)/3*3 + 1)
+	start := time.Date(t.Year(), firstMonth, 1, 0, 0, 0, 0, t.Location())
+	return start, start.AddDate(0, 3, 0)
+}
+
+// YearBounds returns the year containing t
+func YearBounds(t time.Time) (time.Time, time.Time) {
+	start := time.Date(t.Year(), time.January, 1, 0, 0, 0, 0, t.Location())
+	return start, start.AddDate(1, 0, 0)
+}
@@ -5,6 +5,12 @@ import (
 	"time"
 )
 
+// TaskwarriorDateLayout is the compact UTC format Taskwarrior uses for dates in `task export`
+const TaskwarriorDateLayout = "20060102T150405Z"
+
+// ConvertISOToTaskwarriorFormat converts an ISO date from the API into the compact UTC
+// format Taskwarrior stores. Dates without a time of day are taken as midnight UTC; use
+// NormalizeDateInput first to read them in the user's timezone.
 func ConvertISOToTaskwarriorFormat(isoDatetime string) (string, error) {
 	if isoDatetime == "" {
 		return "", nil
@@ -14,6 +20,7 @@ func ConvertISOToTaskwarriorFormat(isoDatetime string) (string, error) {
 	formats := []string{
 		"2006-01-02T15:04:05.000Z", // "2025-12-27T14:30:00.000Z" (frontend datetime with milliseconds)
 		"2006-01-02T15:04:05Z",     // "2025-12-27T14:30:00Z" (datetime without milliseconds)
+		time.RFC3339,               // "2025-12-27T15:30:00+01:00" (datetime with offset)
 		"2006-01-02",               // "2025-12-27" (date only)
 		"20060102T150405Z",         // "20260128T000000Z" (compact ISO format)
 		"20060102",                 // "20260128" (compact date only)
@@ -21,13 +28,10 @@ func ConvertISOToTaskwarriorFormat(isoDatetime string) (string, error) {
 
 	var parsedTime time.Time
 	var err error
-	var isDateOnly bool
 
-	for i, format := range formats {
+	for _, format := range formats {
 		parsedTime, err = time.Parse(format, isoDatetime)
 		if err == nil {
-			// Check if it's date-only format
-			isDateOnly = (i == 2 || i == 4) // "2006-01-02" or "20060102" formats
 			break
 		}
 	}
@@ -36,11 +40,7 @@ func ConvertISOToTaskwarriorFormat(isoDatetime string) (string, error) {
 		return "", fmt.Errorf("unable to parse datetime '%s': %v", isoDatetime, err)
 	}
 
-	if isDateOnly {
-		return parsedTime.Format("2006-01-02"), nil
-	} else {
-		return parsedTime.Format("2006-01-02T15:04:05"), nil
-	}
+	return parsedTime.UTC().Format(TaskwarriorDateLayout), nil
 }
 func ConvertOptionalISOToTaskwarriorFormat(isoDatetime *string) (string, error) {
 	if isoDatetime == nil || *isoDatetime == "" {
@@ -48,3 +48,18 @@ func ConvertOptionalISOToTaskwarriorFormat(isoDatetime *string) (string, error)
 	}
 	return ConvertISOToTaskwarriorFormat(*isoDatetime)
 }
+
+// NormalizeDateInput reads a date from the API, ISO or a date expression (see
+// ParseDateExpression), relative to now and in now's location, and returns the UTC
+// instant it names in the compact format. Dates without an explicit offset, such as
+// "2025-04-01" or "tomorrow 9am", are thus taken in the user's timezone.
+func NormalizeDateInput(value string, now time.Time) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	resolved, _, err := ParseDateExpression(value, now)
+	if err != nil {
+		return "", err
+	}
+	return resolved.UTC().Format(TaskwarriorDateLayout), nil
+}
@@ -1,11 +1,32 @@
 package utils
 
-import "os/exec"
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
 
-func ExecCommandInDir(dir, command string, args ...string) error {
+// ReplicaTaskrc is the name of the taskrc SetTaskwarriorConfig writes in a replica's
+// directory. Taskwarrior commands run in that directory read it instead of the
+// ~/.taskrc every replica would otherwise share.
+const ReplicaTaskrc = ".taskrc"
+
+// commandInDir prepares a command run in dir, pointing Taskwarrior at the replica's
+// taskrc when dir has one
+func commandInDir(dir, command string, args ...string) *exec.Cmd {
 	cmd := exec.Command(command, args...)
 	cmd.Dir = dir
-	return cmd.Run()
+	if taskrc, err := filepath.Abs(filepath.Join(dir, ReplicaTaskrc)); err == nil {
+		if _, err := os.Stat(taskrc); err == nil {
+			cmd.Env = append(os.Environ(), "TASKRC="+taskrc)
+		}
+	}
+	return cmd
+}
+
+func ExecCommandInDir(dir, command string, args ...string) error {
+	return commandInDir(dir, command, args...).Run()
 }
 
 func ExecCommand(command string, args ...string) error {
@@ -14,7 +35,13 @@ func ExecCommand(command string, args ...string) error {
 }
 
 func ExecCommandForOutputInDir(dir, command string, args ...string) ([]byte, error) {
-	cmd := exec.Command(command, args...)
-	cmd.Dir = dir
-	return cmd.Output()
+	return commandInDir(dir, command, args...).Output()
+}
+
+// ExecCommandWithInputInDir runs a command in dir like ExecCommandInDir, with its stdin
+// fed from input, e.g. the JSON given to task import
+func ExecCommandWithInputInDir(dir string, input []byte, command string, args ...string) error {
+	cmd := commandInDir(dir, command, args...)
+	cmd.Stdin = bytes.NewReader(input)
+	return cmd.Run()
 }
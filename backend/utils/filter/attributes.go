@@ -0,0 +1,288 @@
+package filter
+
+import (
+	"ccsync_backend/models"
+	"ccsync_backend/utils"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type attributeKind int
+
+const (
+	stringAttribute attributeKind = iota
+	dateAttribute
+	listAttribute
+	numberAttribute
+)
+
+type attribute struct {
+	kind attributeKind
+	get  func(task *models.Task) string
+	list func(task *models.Task) []string
+	num  func(task *models.Task) float64
+}
+
+func stringField(get func(task *models.Task) string) attribute {
+	return attribute{kind: stringAttribute, get: get}
+}
+
+func dateField(get func(task *models.Task) string) attribute {
+	return attribute{kind: dateAttribute, get: get}
+}
+
+var attributes = map[string]attribute{
+	"description": stringField(func(t *models.Task) string { return t.Description }),
+	"project":     stringField(func(t *models.Task) string { return t.Project }),
+	"status":      stringField(func(t *models.Task) string { return t.Status }),
+	"priority":    stringField(func(t *models.Task) string { return t.Priority }),
+	"recur":       stringField(func(t *models.Task) string { return t.Recur }),
+	"uuid":        stringField(func(t *models.Task) string { return t.UUID }),
+	"parent":      stringField(func(t *models.Task) string { return t.Parent }),
+	"due":         dateField(func(t *models.Task) string { return t.Due }),
+	"wait":        dateField(func(t *models.Task) string { return t.Wait }),
+	"start":       dateField(func(t *models.Task) string { return t.Start }),
+	"end":         dateField(func(t *models.Task) string { return t.End }),
+	"entry":       dateField(func(t *models.Task) string { return t.Entry }),
+	"modified":    dateField(func(t *models.Task) string { return t.Modified }),
+	"until":       dateField(func(t *models.Task) string { return t.Until }),
+	"tags":        {kind: listAttribute, list: func(t *models.Task) []string { return t.Tags }},
+	"depends":     {kind: listAttribute, list: func(t *models.Task) []string { return t.Depends }},
+	"urgency":     {kind: numberAttribute, num: func(t *models.Task) float64 { return float64(t.Urgency) }},
+	"id":          {kind: numberAttribute, num: func(t *models.Task) float64 { return float64(t.ID) }},
+}
+
+// attribute abbreviations accepted by Taskwarrior
+var attributeAliases = map[string]string{
+	"desc": "description",
+	"pro":  "project",
+	"proj": "project",
+	"pri":  "priority",
+	"tag":  "tags",
+	"dep":  "depends",
+}
+
+// modifier spellings mapped to their canonical name
+var modifierAliases = map[string]string{
+	"":           "",
+	"is":         "is",
+	"equals":     "is",
+	"isnt":       "isnt",
+	"not":        "isnt",
+	"has":        "has",
+	"contains":   "has",
+	"hasnt":      "hasnt",
+	"startswith": "startswith",
+	"left":       "startswith",
+	"endswith":   "endswith",
+	"right":      "endswith",
+	"before":     "before",
+	"below":      "before",
+	"under":      "before",
+	"after":      "after",
+	"above":      "after",
+	"over":       "after",
+	"by":         "by",
+	"none":       "none",
+	"any":        "any",
+}
+
+// attributePredicate compiles attr[.modifier]:value
+func attributePredicate(name, modifier, value string) (predicate, error) {
+	if alias, ok := attributeAliases[name]; ok {
+		name = alias
+	}
+	attr, ok := attributes[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown attribute '%s'", name)
+	}
+	canonical, ok := modifierAliases[modifier]
+	if !ok {
+		return nil, fmt.Errorf("unknown modifier '%s'", modifier)
+	}
+
+	// attr: with no value, like project:, matches tasks without the attribute
+	if canonical == "" && value == "" {
+		canonical = "none"
+	}
+
+	switch canonical {
+	case "none", "any":
+		present := presence(attr)
+		want := canonical == "any"
+		return func(_ *Context, task *models.Task) bool {
+			return present(task) == want
+		}, nil
+	}
+
+	switch attr.kind {
+	case dateAttribute:
+		return datePredicate(name, attr, canonical, value)
+	case listAttribute:
+		return listPredicate(name, attr, canonical, value)
+	case numberAttribute:
+		return numberPredicate(name, attr, canonical, value)
+	default:
+		return stringPredicate(name, attr, canonical, value)
+	}
+}
+
+func presence(attr attribute) func(task *models.Task) bool {
+	switch attr.kind {
+	case listAttribute:
+		return func(task *models.Task) bool { return len(attr.list(task)) > 0 }
+	case numberAttribute:
+		return func(task *models.Task) bool { return attr.num(task) != 0 }
+	default:
+		return func(task *models.Task) bool { return attr.get(task) != "" }
+	}
+}
+
+func stringPredicate(name string, attr attribute, modifier, value string) (predicate, error) {
+	want := strings.ToLower(value)
+	var match func(got string) bool
+
+	switch modifier {
+	case "":
+		switch name {
+		case "project":
+			// project:Home also matches the sub-projects Home.Garden, Home.Kitchen, ...
+			match = func(got string) bool {
+				return got == want || strings.HasPrefix(got, want+".")
+			}
+		case "description":
+			match = func(got string) bool { return strings.Contains(got, want) }
+		case "uuid":
+			match = func(got string) bool { return strings.HasPrefix(got, want) }
+		case "status":
+			return statusPredicate(want), nil
+		default:
+			match = func(got string) bool { return got == want }
+		}
+	case "is":
+		match = func(got string) bool { return got == want }
+	case "isnt":
+		match = func(got string) bool { return got != want }
+	case "has":
+		match = func(got string) bool { return strings.Contains(got, want) }
+	case "hasnt":
+		match = func(got string) bool { return !strings.Contains(got, want) }
+	case "startswith":
+		match = func(got string) bool { return strings.HasPrefix(got, want) }
+	case "endswith":
+		match = func(got string) bool { return strings.HasSuffix(got, want) }
+	default:
+		return nil, fmt.Errorf("modifier '%s' does not apply to '%s'", modifier, name)
+	}
+
+	return func(_ *Context, task *models.Task) bool {
+		return match(strings.ToLower(attr.get(task)))
+	}, nil
+}
+
+// statusPredicate treats pending tasks with a future wait date as waiting, so
+// status:pending and status:waiting agree with Taskwarrior 2 and 3 replicas alike
+func statusPredicate(status string) predicate {
+	switch status {
+	case "pending":
+		return virtualTags["PENDING"]
+	case "waiting":
+		return isWaiting
+	default:
+		return statusIs(status)
+	}
+}
+
+func datePredicate(name string, attr attribute, modifier, value string) (predicate, error) {
+	// the value is resolved at evaluation time, so that relative dates like "tomorrow"
+	// stay correct for cached filters
+	if _, _, err := utils.ParseDateExpression(value, time.Now()); err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+
+	// only equality widens to the whole day; before, after and by compare points in
+	// time, so due.after:today includes tasks due later today, as in Taskwarrior
+	var compare func(got, want time.Time, wholeDay bool) bool
+	switch modifier {
+	case "", "is":
+		compare = sameDate
+	case "isnt":
+		compare = func(got, want time.Time, wholeDay bool) bool { return !sameDate(got, want, wholeDay) }
+	case "before":
+		compare = func(got, want time.Time, _ bool) bool { return got.Before(want) }
+	case "after":
+		compare = func(got, want time.Time, _ bool) bool { return got.After(want) }
+	case "by":
+		compare = func(got, want time.Time, _ bool) bool { return !got.After(want) }
+	default:
+		return nil, fmt.Errorf("modifier '%s' does not apply to '%s'", modifier, name)
+	}
+
+	return func(ctx *Context, task *models.Task) bool {
+		got, ok := parseTaskDate(attr.get(task))
+		if !ok {
+			return modifier == "isnt"
+		}
+		want, wholeDay, err := utils.ParseDateExpression(value, ctx.Now)
+		if err != nil {
+			return false
+		}
+		return compare(got.In(ctx.Location), want, wholeDay)
+	}, nil
+}
+
+func sameDate(got, want time.Time, wholeDay bool) bool {
+	if wholeDay {
+		return !got.Before(want) && got.Before(want.AddDate(0, 0, 1))
+	}
+	return got.Equal(want)
+}
+
+func listPredicate(name string, attr attribute, modifier, value string) (predicate, error) {
+	contains := func(task *models.Task) bool {
+		for _, item := range attr.list(task) {
+			if strings.EqualFold(item, value) || (name == "depends" && strings.HasPrefix(strings.ToLower(item), strings.ToLower(value))) {
+				return true
+			}
+		}
+		return false
+	}
+
+	switch modifier {
+	case "", "is", "has":
+		return func(_ *Context, task *models.Task) bool { return contains(task) }, nil
+	case "isnt", "hasnt":
+		return func(_ *Context, task *models.Task) bool { return !contains(task) }, nil
+	default:
+		return nil, fmt.Errorf("modifier '%s' does not apply to '%s'", modifier, name)
+	}
+}
+
+func numberPredicate(name string, attr attribute, modifier, value string) (predicate, error) {
+	want, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%s: '%s' is not a number", name, value)
+	}
+
+	var compare func(got float64) bool
+	switch modifier {
+	case "", "is":
+		compare = func(got float64) bool { return got == want }
+	case "isnt":
+		compare = func(got float64) bool { return got != want }
+	case "before":
+		compare = func(got float64) bool { return got < want }
+	case "after":
+		compare = func(got float64) bool { return got > want }
+	case "by":
+		compare = func(got float64) bool { return got <= want }
+	default:
+		return nil, fmt.Errorf("modifier '%s' does not apply to '%s'", modifier, name)
+	}
+
+	return func(_ *Context, task *models.Task) bool {
+		return compare(attr.num(task))
+	}, nil
+}
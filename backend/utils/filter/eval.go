@@ -0,0 +1,123 @@
+package filter
+
+import (
+	"ccsync_backend/models"
+	"ccsync_backend/utils"
+	"strings"
+	"time"
+)
+
+// Context carries what a filter needs beyond the task itself: the reference time
+// for relative dates and the other tasks, for dependency-based virtual tags
+type Context struct {
+	Now      time.Time
+	Location *time.Location
+
+	tasks    map[string]*models.Task
+	blocking map[string]bool
+}
+
+// NewContext indexes tasks for evaluating filters at now. Day boundaries
+// (today, sow, +DUETODAY, ...) are computed in loc, which defaults to UTC.
+func NewContext(tasks []models.Task, now time.Time, loc *time.Location) *Context {
+	if loc == nil {
+		loc = time.UTC
+	}
+	ctx := &Context{
+		Now:      now.In(loc),
+		Location: loc,
+		tasks:    make(map[string]*models.Task, len(tasks)),
+		blocking: make(map[string]bool),
+	}
+	for i := range tasks {
+		ctx.tasks[tasks[i].UUID] = &tasks[i]
+	}
+	for i := range tasks {
+		if !isOpen(ctx, &tasks[i]) {
+			continue
+		}
+		for _, dependency := range tasks[i].Depends {
+			ctx.blocking[dependency] = true
+		}
+	}
+	return ctx
+}
+
+// Match reports whether the task satisfies the filter
+func (f *Filter) Match(ctx *Context, task models.Task) bool {
+	return f.match(ctx, &task)
+}
+
+// Apply returns the tasks matching the filter, keeping their order
+func (f *Filter) Apply(tasks []models.Task, now time.Time, loc *time.Location) []models.Task {
+	ctx := NewContext(tasks, now, loc)
+	matched := []models.Task{}
+	for i := range tasks {
+		if f.match(ctx, &tasks[i]) {
+			matched = append(matched, tasks[i])
+		}
+	}
+	return matched
+}
+
+// isOpen reports whether a task still needs doing (pending or waiting)
+func isOpen(_ *Context, task *models.Task) bool {
+	return task.Status == "pending" || task.Status == "waiting"
+}
+
+// isWaiting covers both the explicit waiting status of Taskwarrior 2 and
+// pending tasks with a future wait date, as Taskwarrior 3 stores them
+func isWaiting(ctx *Context, task *models.Task) bool {
+	if task.Status == "waiting" {
+		return true
+	}
+	if task.Status != "pending" {
+		return false
+	}
+	wait, ok := parseTaskDate(task.Wait)
+	return ok && wait.After(ctx.Now)
+}
+
+func isBlocked(ctx *Context, task *models.Task) bool {
+	for _, dependency := range task.Depends {
+		if other, ok := ctx.tasks[dependency]; ok && isOpen(ctx, other) {
+			return true
+		}
+	}
+	return false
+}
+
+func parseTaskDate(value string) (time.Time, bool) {
+	if value == "" {
+		return time.Time{}, false
+	}
+	parsed, err := time.Parse(utils.TaskwarriorDateLayout, value)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return parsed, true
+}
+
+// And returns a filter matching the tasks that every filter matches
+func And(filters ...*Filter) *Filter {
+	sources := make([]string, 0, len(filters))
+	namesStatus := false
+	for _, f := range filters {
+		if strings.TrimSpace(f.source) != "" {
+			sources = append(sources, "("+f.source+")")
+		}
+		namesStatus = namesStatus || f.namesStatus
+	}
+	return &Filter{
+		source:      strings.Join(sources, " and "),
+		namesStatus: namesStatus,
+		match: func(ctx *Context, task *models.Task) bool {
+			for _, f := range filters {
+				if !f.match(ctx, task) {
+					return false
+				}
+			}
+			return true
+		},
+	}
+}
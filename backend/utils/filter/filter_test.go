@@ -0,0 +1,368 @@
+package filter
+
+import (
+	"ccsync_backend/models"
+	"ccsync_backend/utils"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Saturday 2025-03-15 10:00 UTC
+var testNow = time.Date(2025, time.March, 15, 10, 0, 0, 0, time.UTC)
+
+func testTasks() []models.Task {
+	return []models.Task{
+		{ID: 1, UUID: "aaaaaaaa-0000-0000-0000-000000000001", Description: "Water the plants", Project: "Home.Garden", Status: "pending", Tags: []string{"chores"}, Due: "20250315T180000Z", Priority: "L"},
+		{ID: 2, UUID: "bbbbbbbb-0000-0000-0000-000000000002", Description: "File taxes", Project: "Finance", Status: "pending", Tags: []string{"urgent", "paperwork"}, Due: "20250310T090000Z", Priority: "H", Urgency: 12.5},
+		{ID: 3, UUID: "cccccccc-0000-0000-0000-000000000003", Description: "Write report", Project: "Work", Status: "pending", Depends: []string{"dddddddd-0000-0000-0000-000000000004"}, Start: "20250315T080000Z"},
+		{ID: 4, UUID: "dddddddd-0000-0000-0000-000000000004", Description: "Collect numbers", Project: "Work", Status: "pending", Due: "20250316T120000Z", Annotations: []models.Annotation{{Entry: "20250301T100000Z", Description: "ask finance team"}}},
+		{UUID: "eeeeeeee-0000-0000-0000-000000000005", Description: "Fix the fence", Project: "Home", Status: "completed", End: "20250301T100000Z", Due: "20250301T000000Z"},
+		{UUID: "ffffffff-0000-0000-0000-000000000006", Description: "Old idea", Status: "deleted", Tags: []string{"someday"}},
+		{ID: 5, UUID: "99999999-0000-0000-0000-000000000007", Description: "Renew passport", Status: "pending", Wait: "20250401T000000Z", Due: "20250601T000000Z"},
+		{ID: 6, UUID: "88888888-0000-0000-0000-000000000008", Description: "Plan trip", Status: "waiting", Wait: "20250320T000000Z", Project: "Home"},
+	}
+}
+
+// matchDescriptions applies the filter to the test tasks and returns the matching descriptions
+func matchDescriptions(t *testing.T, expression string) []string {
+	t.Helper()
+	f, err := Parse(expression)
+	require.NoError(t, err, "filter %q", expression)
+
+	descriptions := []string{}
+	for _, task := range f.Apply(testTasks(), testNow, time.UTC) {
+		descriptions = append(descriptions, task.Description)
+	}
+	return descriptions
+}
+
+func Test_Tokenize(t *testing.T) {
+	tokens, err := tokenize(`(project:"Home Garden" or +urgent)and 'not'`)
+	require.NoError(t, err)
+
+	texts := []string{}
+	for _, tok := range tokens {
+		texts = append(texts, tok.text)
+	}
+	assert.Equal(t, []string{"(", "project:Home Garden", "or", "+urgent", ")", "and", "not"}, texts)
+	assert.True(t, tokens[1].quoted)
+	assert.False(t, tokens[1].literal)
+	assert.True(t, tokens[6].literal)
+	assert.Equal(t, 1, tokens[1].pos)
+}
+
+func Test_Tokenize_EscapedQuote(t *testing.T) {
+	tokens, err := tokenize(`"say \"hi\""`)
+	require.NoError(t, err)
+	require.Len(t, tokens, 1)
+	assert.Equal(t, `say "hi"`, tokens[0].text)
+}
+
+func Test_Parse_EmptyMatchesAll(t *testing.T) {
+	for _, expression := range []string{"", "   "} {
+		assert.Len(t, matchDescriptions(t, expression), len(testTasks()))
+	}
+}
+
+func Test_Parse_Errors(t *testing.T) {
+	tests := []struct {
+		expression string
+		pos        int
+		message    string
+	}{
+		{`project:"Home`, 8, "unterminated quote"},
+		{`(project:Home`, 0, "unbalanced '('"},
+		{`project:Home)`, 12, "unexpected ')'"},
+		{`()`, 1, "unexpected ')'"},
+		{`and +urgent`, 0, "missing term before 'and'"},
+		{`+urgent or`, 10, "unexpected end of filter"},
+		{`+urgent and or +home`, 12, "missing term before 'or'"},
+		{`not`, 3, "unexpected end of filter"},
+		{`colour:red`, 0, "unknown attribute 'colour'"},
+		{`project.between:a`, 0, "unknown modifier 'between'"},
+		{`due:whenever`, 0, "unable to parse date 'whenever'"},
+		{`tags.startswith:a`, 0, "modifier 'startswith' does not apply to 'tags'"},
+		{`project.before:a`, 0, "modifier 'before' does not apply to 'project'"},
+		{`urgency.over:high`, 0, "'high' is not a number"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			_, err := Parse(tt.expression)
+			require.Error(t, err)
+			syntaxErr, ok := err.(*SyntaxError)
+			require.True(t, ok, "expected a SyntaxError, got %T", err)
+			assert.Equal(t, tt.pos, syntaxErr.Pos)
+			assert.Contains(t, syntaxErr.Msg, tt.message)
+		})
+	}
+}
+
+func Test_Parse_Limits(t *testing.T) {
+	_, err := Parse(strings.Repeat("a", maxFilterLength+1))
+	assert.Error(t, err)
+
+	deep := strings.Repeat("(", maxDepth+1) + "+a" + strings.Repeat(")", maxDepth+1)
+	_, err = Parse(deep)
+	assert.Error(t, err)
+
+	shallow := strings.Repeat("(", maxDepth) + "+a" + strings.Repeat(")", maxDepth)
+	_, err = Parse(shallow)
+	assert.NoError(t, err)
+
+	_, err = Parse(strings.Repeat("not ", maxDepth+1) + "+a")
+	assert.Error(t, err)
+}
+
+func Test_Filter_String(t *testing.T) {
+	f, err := Parse("project:Home +urgent")
+	require.NoError(t, err)
+	assert.Equal(t, "project:Home +urgent", f.String())
+}
+
+func Test_Filter_NamesStatus(t *testing.T) {
+	for input, want := range map[string]bool{
+		"project:Home +urgent":      false,
+		"status:completed":          true,
+		"Status.not:deleted":        true,
+		"+home or -DELETED":         true,
+		"+COMPLETED":                true,
+		"+OVERDUE":                  false,
+		`"status:completed" report`: false,
+	} {
+		f, err := Parse(input)
+		require.NoError(t, err)
+		assert.Equal(t, want, f.NamesStatus(), input)
+	}
+
+	f, err := Parse("status:pending")
+	require.NoError(t, err)
+	assert.True(t, And(&Filter{}, f).NamesStatus())
+}
+
+func Test_And(t *testing.T) {
+	context, err := Parse("project:Home")
+	require.NoError(t, err)
+	empty, err := Parse("")
+	require.NoError(t, err)
+	f, err := Parse("status:pending or +chores")
+	require.NoError(t, err)
+
+	combined := And(context, empty, f)
+	assert.Equal(t, "(project:Home) and (status:pending or +chores)", combined.String())
+
+	descriptions := []string{}
+	for _, task := range combined.Apply(testTasks(), testNow, time.UTC) {
+		descriptions = append(descriptions, task.Description)
+	}
+	assert.Equal(t, []string{"Water the plants"}, descriptions)
+}
+
+func Test_Filter_Project(t *testing.T) {
+	assert.Equal(t, []string{"Water the plants", "Fix the fence", "Plan trip"}, matchDescriptions(t, "project:Home"))
+	assert.Equal(t, []string{"Water the plants"}, matchDescriptions(t, "project:home.garden"))
+	assert.Equal(t, []string{"Fix the fence", "Plan trip"}, matchDescriptions(t, "project.is:Home"))
+	assert.Empty(t, matchDescriptions(t, "project:Hom"))
+	assert.Equal(t, []string{"Water the plants", "Fix the fence", "Plan trip"}, matchDescriptions(t, "pro.startswith:Hom"))
+	assert.Equal(t, []string{"Old idea", "Renew passport"}, matchDescriptions(t, "project:"))
+	assert.Equal(t, []string{"Old idea", "Renew passport"}, matchDescriptions(t, "project.none:"))
+	assert.Len(t, matchDescriptions(t, "project.any:"), 6)
+	assert.Equal(t, []string{"Write report", "Collect numbers"}, matchDescriptions(t, `project:"Work"`))
+}
+
+func Test_Filter_Tags(t *testing.T) {
+	assert.Equal(t, []string{"File taxes"}, matchDescriptions(t, "+urgent"))
+	assert.Equal(t, []string{"File taxes"}, matchDescriptions(t, "+URGENT"))
+	assert.Equal(t, []string{"Water the plants", "Write report", "Collect numbers", "Fix the fence", "Renew passport", "Plan trip"}, matchDescriptions(t, "-urgent -someday"))
+	assert.Equal(t, []string{"File taxes"}, matchDescriptions(t, "tags:paperwork"))
+	assert.Equal(t, []string{"File taxes"}, matchDescriptions(t, "tags.has:urgent"))
+	assert.Len(t, matchDescriptions(t, "tags.hasnt:urgent"), 7)
+	assert.Equal(t, []string{"Water the plants", "File taxes", "Old idea"}, matchDescriptions(t, "tags.any:"))
+}
+
+func Test_Filter_Status(t *testing.T) {
+	assert.Equal(t, []string{"Water the plants", "File taxes", "Write report", "Collect numbers"}, matchDescriptions(t, "status:pending"))
+	assert.Equal(t, []string{"Renew passport", "Plan trip"}, matchDescriptions(t, "status:waiting"))
+	assert.Equal(t, []string{"Fix the fence"}, matchDescriptions(t, "status:Completed"))
+	assert.Equal(t, []string{"Fix the fence", "Old idea"}, matchDescriptions(t, "status:completed or status:deleted"))
+	assert.Len(t, matchDescriptions(t, "status.not:deleted"), 7)
+}
+
+func Test_Filter_BooleanOperators(t *testing.T) {
+	// and binds tighter than or
+	assert.Equal(t, []string{"File taxes", "Write report", "Collect numbers"}, matchDescriptions(t, "+urgent or project:Work and status:pending"))
+	assert.Equal(t, []string{"File taxes"}, matchDescriptions(t, "(+urgent or project:Work) and +paperwork"))
+	assert.Equal(t, []string{"File taxes"}, matchDescriptions(t, "(+urgent or project:Work) +paperwork"))
+	assert.Equal(t, []string{"File taxes", "Fix the fence", "Plan trip"}, matchDescriptions(t, "project:Home xor +chores or +urgent"))
+	assert.Equal(t, []string{"Fix the fence", "Plan trip"}, matchDescriptions(t, "project:Home xor +chores"))
+	assert.Equal(t, []string{"Write report", "Collect numbers"}, matchDescriptions(t, "not (project:Home or project:Finance) status:pending"))
+	assert.Equal(t, []string{"Water the plants"}, matchDescriptions(t, "not not +chores"))
+	assert.Equal(t, []string{"Water the plants", "File taxes"}, matchDescriptions(t, "+chores OR +urgent"))
+}
+
+func Test_Filter_QuotedOperatorIsText(t *testing.T) {
+	assert.Equal(t, []string{"Water the plants", "Fix the fence"}, matchDescriptions(t, `"the"`))
+	assert.Empty(t, matchDescriptions(t, `"and"`))
+	assert.Equal(t, []string{"Collect numbers"}, matchDescriptions(t, `"finance team"`))
+}
+
+func Test_Filter_BareWords(t *testing.T) {
+	assert.Equal(t, []string{"Water the plants", "Fix the fence"}, matchDescriptions(t, "the"))
+	assert.Equal(t, []string{"Collect numbers"}, matchDescriptions(t, "FINANCE"))
+	assert.Equal(t, []string{"Water the plants"}, matchDescriptions(t, "water plants"))
+	assert.Equal(t, []string{"File taxes"}, matchDescriptions(t, "description.has:ax"))
+	assert.Equal(t, []string{"File taxes"}, matchDescriptions(t, "desc.startswith:file"))
+	assert.Equal(t, []string{"Write report", "Renew passport"}, matchDescriptions(t, "description.endswith:PORT"))
+}
+
+func Test_Filter_IDsAndUUIDs(t *testing.T) {
+	assert.Equal(t, []string{"Water the plants", "Write report", "Collect numbers", "Renew passport"}, matchDescriptions(t, "1,3-5"))
+	assert.Equal(t, []string{"File taxes"}, matchDescriptions(t, "2"))
+	assert.Equal(t, []string{"Write report"}, matchDescriptions(t, "cccccccc"))
+	assert.Equal(t, []string{"Write report"}, matchDescriptions(t, "CCCCCCCC-0000-0000-0000-000000000003"))
+	assert.Equal(t, []string{"Collect numbers"}, matchDescriptions(t, "uuid:dddd"))
+	assert.Equal(t, []string{"File taxes"}, matchDescriptions(t, "id:2"))
+}
+
+func Test_Filter_Dates(t *testing.T) {
+	assert.Equal(t, []string{"File taxes", "Fix the fence"}, matchDescriptions(t, "due.before:today"))
+	assert.Equal(t, []string{"Water the plants", "File taxes", "Fix the fence"}, matchDescriptions(t, "due.before:tomorrow"))
+	assert.Equal(t, []string{"Water the plants"}, matchDescriptions(t, "due:today"))
+	assert.Equal(t, []string{"Water the plants"}, matchDescriptions(t, "due:2025-03-15"))
+	assert.Equal(t, []string{"Water the plants"}, matchDescriptions(t, "due.is:20250315T180000Z"))
+	assert.Equal(t, []string{"Water the plants", "Collect numbers", "Renew passport"}, matchDescriptions(t, "due.after:today"))
+	assert.Equal(t, []string{"Water the plants", "Collect numbers", "Renew passport"}, matchDescriptions(t, "due.after:now"))
+	assert.Equal(t, []string{"File taxes", "Fix the fence"}, matchDescriptions(t, "due.by:2025-03-15"))
+	assert.Equal(t, []string{"Water the plants", "File taxes", "Fix the fence"}, matchDescriptions(t, "due.by:eod"))
+	assert.Equal(t, []string{"Water the plants", "Collect numbers"}, matchDescriptions(t, "due.after:now due.before:+2d"))
+	assert.Equal(t, []string{"File taxes"}, matchDescriptions(t, "due.after:-1w due.before:yesterday"))
+	assert.Equal(t, []string{"Water the plants", "File taxes", "Collect numbers", "Fix the fence"}, matchDescriptions(t, "due.before:eom"))
+	assert.Equal(t, []string{"Collect numbers"}, matchDescriptions(t, "due:sunday"))
+	assert.Equal(t, []string{"Write report", "Old idea", "Plan trip"}, matchDescriptions(t, "due:"))
+	assert.Equal(t, []string{"Write report", "Old idea", "Plan trip"}, matchDescriptions(t, "due.none:"))
+	assert.Equal(t, []string{"File taxes", "Write report", "Collect numbers", "Fix the fence", "Old idea", "Renew passport", "Plan trip"}, matchDescriptions(t, "due.isnt:today"))
+	assert.Equal(t, []string{"Write report"}, matchDescriptions(t, "start.after:sod"))
+	assert.Equal(t, []string{"Fix the fence"}, matchDescriptions(t, "end.before:2025-03-02T00:00:00Z"))
+}
+
+func Test_Filter_DatesUseLocation(t *testing.T) {
+	f, err := Parse("due:tomorrow")
+	require.NoError(t, err)
+
+	var utc []string
+	for _, task := range f.Apply(testTasks(), testNow, time.UTC) {
+		utc = append(utc, task.Description)
+	}
+	assert.Equal(t, []string{"Collect numbers"}, utc)
+
+	// at the same instant it is already 23:00 in Auckland, so tomorrow starts an hour later
+	auckland := time.FixedZone("NZDT", 13*3600)
+	var local []string
+	for _, task := range f.Apply(testTasks(), testNow, auckland) {
+		local = append(local, task.Description)
+	}
+	assert.Equal(t, []string{"Water the plants"}, local)
+}
+
+func Test_Filter_Numbers(t *testing.T) {
+	assert.Equal(t, []string{"File taxes"}, matchDescriptions(t, "urgency.over:10"))
+	assert.Equal(t, []string{"Water the plants", "File taxes"}, matchDescriptions(t, "id.below:3 id.any:"))
+	assert.Equal(t, []string{"File taxes"}, matchDescriptions(t, "priority:h"))
+	assert.Equal(t, []string{"Water the plants", "File taxes"}, matchDescriptions(t, "priority.any:"))
+}
+
+func Test_Filter_VirtualTags(t *testing.T) {
+	tests := []struct {
+		tag      string
+		expected []string
+	}{
+		{"+PENDING", []string{"Water the plants", "File taxes", "Write report", "Collect numbers"}},
+		{"+WAITING", []string{"Renew passport", "Plan trip"}},
+		{"+COMPLETED", []string{"Fix the fence"}},
+		{"+DELETED", []string{"Old idea"}},
+		{"+ACTIVE", []string{"Write report"}},
+		{"+OVERDUE", []string{"File taxes"}},
+		{"+DUE", []string{"Water the plants", "Collect numbers"}},
+		{"+DUETODAY", []string{"Water the plants"}},
+		{"+TODAY", []string{"Water the plants"}},
+		{"+TOMORROW", []string{"Collect numbers"}},
+		{"+WEEK", []string{"Water the plants", "File taxes", "Collect numbers"}},
+		{"+MONTH", []string{"Water the plants", "File taxes", "Collect numbers"}},
+		{"+YEAR", []string{"Water the plants", "File taxes", "Collect numbers", "Renew passport"}},
+		{"+BLOCKED", []string{"Write report"}},
+		{"+BLOCKING", []string{"Collect numbers"}},
+		{"+READY", []string{"Water the plants", "File taxes", "Collect numbers"}},
+		{"+ANNOTATED", []string{"Collect numbers"}},
+		{"+TAGGED", []string{"Water the plants", "File taxes", "Old idea"}},
+		{"-PROJECT", []string{"Old idea", "Renew passport"}},
+		{"+UNBLOCKED +PENDING", []string{"Water the plants", "File taxes", "Collect numbers"}},
+		{"+OVERDUE or +BLOCKED", []string{"File taxes", "Write report"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.tag, func(t *testing.T) {
+			assert.Equal(t, tt.expected, matchDescriptions(t, tt.tag))
+		})
+	}
+}
+
+func Test_Filter_BlockedIgnoresClosedDependencies(t *testing.T) {
+	tasks := testTasks()
+	tasks[3].Status = "completed"
+
+	f, err := Parse("+BLOCKED or +BLOCKING")
+	require.NoError(t, err)
+	assert.Empty(t, f.Apply(tasks, testNow, time.UTC))
+}
+
+func Test_Filter_MatchSingleTask(t *testing.T) {
+	f, err := Parse("project:Work +ACTIVE")
+	require.NoError(t, err)
+
+	tasks := testTasks()
+	ctx := NewContext(tasks, testNow, nil)
+	assert.True(t, f.Match(ctx, tasks[2]))
+	assert.False(t, f.Match(ctx, tasks[3]))
+	assert.Equal(t, time.UTC, ctx.Location)
+}
+
+func Test_ResolveDate(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected time.Time
+		wholeDay bool
+	}{
+		{"now", testNow, false},
+		{"today", time.Date(2025, 3, 15, 0, 0, 0, 0, time.UTC), true},
+		{"eod", time.Date(2025, 3, 15, 23, 59, 59, 0, time.UTC), false},
+		{"yesterday", time.Date(2025, 3, 14, 0, 0, 0, 0, time.UTC), true},
+		{"sow", time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC), true},
+		{"eow", time.Date(2025, 3, 16, 23, 59, 59, 0, time.UTC), false},
+		{"som", time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC), true},
+		{"eom", time.Date(2025, 3, 31, 23, 59, 59, 0, time.UTC), false},
+		{"soq", time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), true},
+		{"eoq", time.Date(2025, 3, 31, 23, 59, 59, 0, time.UTC), false},
+		{"soy", time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), true},
+		{"eoy", time.Date(2025, 12, 31, 23, 59, 59, 0, time.UTC), false},
+		{"saturday", time.Date(2025, 3, 22, 0, 0, 0, 0, time.UTC), true},
+		{"mon", time.Date(2025, 3, 17, 0, 0, 0, 0, time.UTC), true},
+		{"3d", time.Date(2025, 3, 18, 10, 0, 0, 0, time.UTC), false},
+		{"-2wks", time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC), false},
+		{"2025-04-01", time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC), true},
+		{"2025-04-01T08:30:00Z", time.Date(2025, 4, 1, 8, 30, 0, 0, time.UTC), false},
+		{"20250401T083000Z", time.Date(2025, 4, 1, 8, 30, 0, 0, time.UTC), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			resolved, wholeDay, err := utils.ParseDateExpression(tt.value, testNow)
+			require.NoError(t, err)
+			assert.True(t, tt.expected.Equal(resolved), "expected %v, got %v", tt.expected, resolved)
+			assert.Equal(t, tt.wholeDay, wholeDay)
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package filter
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokenWord tokenKind = iota
+	tokenLeftParen
+	tokenRightParen
+)
+
+type token struct {
+	kind    tokenKind
+	text    string
+	quoted  bool // part of the word was quoted, so it is never an operator
+	literal bool // the word started with a quote, so it is searched as plain text
+	pos     int
+}
+
+// SyntaxError reports a malformed filter along with the character offset it was found at
+type SyntaxError struct {
+	Pos int
+	Msg string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("invalid filter at position %d: %s", e.Pos, e.Msg)
+}
+
+func syntaxError(pos int, format string, args ...interface{}) *SyntaxError {
+	return &SyntaxError{Pos: pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+// tokenize splits a filter into words and parentheses. Single or double quotes
+// group text containing spaces or parentheses, e.g. project:"Home Garden".
+func tokenize(input string) ([]token, error) {
+	var tokens []token
+	runes := []rune(input)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, token{kind: tokenLeftParen, text: "(", pos: i})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{kind: tokenRightParen, text: ")", pos: i})
+			i++
+		default:
+			start := i
+			var word strings.Builder
+			quoted := false
+			for i < len(runes) && !unicode.IsSpace(runes[i]) && runes[i] != '(' && runes[i] != ')' {
+				if runes[i] != '"' && runes[i] != '\'' {
+					word.WriteRune(runes[i])
+					i++
+					continue
+				}
+
+				quote := runes[i]
+				quoteStart := i
+				quoted = true
+				i++
+				for i < len(runes) && runes[i] != quote {
+					if runes[i] == '\\' && i+1 < len(runes) && runes[i+1] == quote {
+						i++
+					}
+					word.WriteRune(runes[i])
+					i++
+				}
+				if i >= len(runes) {
+					return nil, syntaxError(quoteStart, "unterminated quote")
+				}
+				i++
+			}
+			literal := runes[start] == '"' || runes[start] == '\''
+			tokens = append(tokens, token{kind: tokenWord, text: word.String(), quoted: quoted, literal: literal, pos: start})
+		}
+	}
+
+	return tokens, nil
+}
@@ -0,0 +1,335 @@
+package filter
+
+import (
+	"ccsync_backend/models"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	// maxFilterLength and maxDepth bound the work a single request can cause
+	maxFilterLength = 2048
+	maxDepth        = 32
+)
+
+// predicate is a compiled filter expression evaluated against a single task
+type predicate func(ctx *Context, task *models.Task) bool
+
+// Filter is a parsed Taskwarrior-style filter expression
+type Filter struct {
+	source      string
+	match       predicate
+	namesStatus bool
+}
+
+// Parse compiles a filter expression such as
+//
+//	project:Home and (+urgent or due.before:tomorrow) -someday
+//
+// Terms next to each other are joined with "and". Supported terms are attribute
+// matches (attr[.modifier]:value), +tag / -tag, virtual tags like +OVERDUE, task IDs,
+// UUIDs and bare words, which search the description and annotations.
+// An empty expression matches every task.
+func Parse(input string) (*Filter, error) {
+	if len(input) > maxFilterLength {
+		return nil, syntaxError(maxFilterLength, "filter is longer than %d characters", maxFilterLength)
+	}
+
+	tokens, err := tokenize(input)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tokens) == 0 {
+		return &Filter{source: input, match: func(*Context, *models.Task) bool { return true }}, nil
+	}
+
+	p := &parser{tokens: tokens, inputLength: len([]rune(input))}
+	match, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok, ok := p.peek(); ok {
+		if tok.kind == tokenRightParen {
+			return nil, syntaxError(tok.pos, "unexpected ')'")
+		}
+		return nil, syntaxError(tok.pos, "unexpected '%s'", tok.text)
+	}
+
+	return &Filter{source: input, match: match, namesStatus: p.namesStatus}, nil
+}
+
+// String returns the expression the filter was parsed from
+func (f *Filter) String() string {
+	return f.source
+}
+
+// NamesStatus reports whether the filter selects tasks by status, with status:... or a
+// virtual tag such as +COMPLETED, rather than leaving it to the caller
+func (f *Filter) NamesStatus() bool {
+	return f.namesStatus
+}
+
+type parser struct {
+	tokens      []token
+	pos         int
+	depth       int
+	inputLength int
+	namesStatus bool
+}
+
+func (p *parser) peek() (token, bool) {
+	if p.pos >= len(p.tokens) {
+		return token{}, false
+	}
+	return p.tokens[p.pos], true
+}
+
+// peekOperator reports whether the next token is the given (unquoted) keyword
+func (p *parser) peekOperator(operator string) bool {
+	tok, ok := p.peek()
+	return ok && tok.kind == tokenWord && !tok.quoted && strings.EqualFold(tok.text, operator)
+}
+
+func (p *parser) parseOr() (predicate, error) {
+	left, err := p.parseXor()
+	if err != nil {
+		return nil, err
+	}
+	for p.peekOperator("or") {
+		p.pos++
+		right, err := p.parseXor()
+		if err != nil {
+			return nil, err
+		}
+		l, r := left, right
+		left = func(ctx *Context, task *models.Task) bool {
+			return l(ctx, task) || r(ctx, task)
+		}
+	}
+	return left, nil
+}
+
+func (p *parser) parseXor() (predicate, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peekOperator("xor") {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		l, r := left, right
+		left = func(ctx *Context, task *models.Task) bool {
+			return l(ctx, task) != r(ctx, task)
+		}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (predicate, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if p.peekOperator("and") {
+			p.pos++
+		} else if tok, ok := p.peek(); !ok || tok.kind == tokenRightParen || p.peekOperator("or") || p.peekOperator("xor") {
+			return left, nil
+		}
+
+		// adjacent terms are implicitly joined with "and"
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		l, r := left, right
+		left = func(ctx *Context, task *models.Task) bool {
+			return l(ctx, task) && r(ctx, task)
+		}
+	}
+}
+
+func (p *parser) parseNot() (predicate, error) {
+	if p.peekOperator("not") {
+		p.pos++
+		if err := p.enter(); err != nil {
+			return nil, err
+		}
+		inner, err := p.parseNot()
+		p.depth--
+		if err != nil {
+			return nil, err
+		}
+		return func(ctx *Context, task *models.Task) bool {
+			return !inner(ctx, task)
+		}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (predicate, error) {
+	tok, ok := p.peek()
+	if !ok {
+		return nil, syntaxError(p.inputLength, "unexpected end of filter")
+	}
+
+	switch tok.kind {
+	case tokenLeftParen:
+		p.pos++
+		if err := p.enter(); err != nil {
+			return nil, err
+		}
+		inner, err := p.parseOr()
+		p.depth--
+		if err != nil {
+			return nil, err
+		}
+		closing, ok := p.peek()
+		if !ok || closing.kind != tokenRightParen {
+			return nil, syntaxError(tok.pos, "unbalanced '('")
+		}
+		p.pos++
+		return inner, nil
+	case tokenRightParen:
+		return nil, syntaxError(tok.pos, "unexpected ')'")
+	}
+
+	if !tok.quoted {
+		for _, operator := range []string{"and", "or", "xor"} {
+			if strings.EqualFold(tok.text, operator) {
+				return nil, syntaxError(tok.pos, "missing term before '%s'", tok.text)
+			}
+		}
+	}
+
+	p.pos++
+	if namesStatus(tok) {
+		p.namesStatus = true
+	}
+	return parseTerm(tok)
+}
+
+func (p *parser) enter() error {
+	p.depth++
+	if p.depth > maxDepth {
+		return syntaxError(p.tokens[p.pos-1].pos, "filter is nested more than %d levels deep", maxDepth)
+	}
+	return nil
+}
+
+var (
+	attributePattern = regexp.MustCompile(`^([a-zA-Z]+)(?:\.([a-zA-Z]+))?[:=]`)
+	idListPattern    = regexp.MustCompile(`^\d+(-\d+)?(,\d+(-\d+)?)*$`)
+	uuidPattern      = regexp.MustCompile(`^[0-9a-fA-F]{8}(-[0-9a-fA-F]{4}){0,3}(-[0-9a-fA-F]{12})?$`)
+	tagPattern       = regexp.MustCompile(`^[+-][^\s:+-][^\s:]*$`)
+)
+
+// statusTags are the virtual tags selecting tasks by status
+var statusTags = map[string]bool{"PENDING": true, "WAITING": true, "COMPLETED": true, "DELETED": true}
+
+// namesStatus reports whether a single word of the filter selects tasks by status
+func namesStatus(tok token) bool {
+	if tok.literal {
+		return false
+	}
+	if tagPattern.MatchString(tok.text) {
+		return statusTags[tok.text[1:]]
+	}
+	matches := attributePattern.FindStringSubmatch(tok.text)
+	return matches != nil && strings.EqualFold(matches[1], "status")
+}
+
+// parseTerm compiles a single word of the filter
+func parseTerm(tok token) (predicate, error) {
+	text := tok.text
+	if tok.literal {
+		return textPredicate(text), nil
+	}
+
+	if tagPattern.MatchString(text) {
+		include := text[0] == '+'
+		match := tagPredicate(text[1:])
+		if include {
+			return match, nil
+		}
+		return func(ctx *Context, task *models.Task) bool {
+			return !match(ctx, task)
+		}, nil
+	}
+
+	if matches := attributePattern.FindStringSubmatch(text); matches != nil {
+		name := strings.ToLower(matches[1])
+		modifier := strings.ToLower(matches[2])
+		value := text[len(matches[0]):]
+		match, err := attributePredicate(name, modifier, value)
+		if err != nil {
+			return nil, syntaxError(tok.pos, "%v", err)
+		}
+		return match, nil
+	}
+
+	if idListPattern.MatchString(text) {
+		return idPredicate(text), nil
+	}
+
+	if uuidPattern.MatchString(text) {
+		prefix := strings.ToLower(text)
+		return func(_ *Context, task *models.Task) bool {
+			return strings.HasPrefix(strings.ToLower(task.UUID), prefix)
+		}, nil
+	}
+
+	return textPredicate(text), nil
+}
+
+// idPredicate matches working-set IDs given as a list of numbers and ranges, e.g. 1,4-6
+func idPredicate(list string) predicate {
+	type idRange struct{ low, high int64 }
+	var ranges []idRange
+	for _, part := range strings.Split(list, ",") {
+		bounds := strings.SplitN(part, "-", 2)
+		low, _ := strconv.ParseInt(bounds[0], 10, 32)
+		high := low
+		if len(bounds) == 2 {
+			high, _ = strconv.ParseInt(bounds[1], 10, 32)
+		}
+		if low > high {
+			low, high = high, low
+		}
+		ranges = append(ranges, idRange{low, high})
+	}
+
+	return func(_ *Context, task *models.Task) bool {
+		id := int64(task.ID)
+		if id == 0 {
+			return false
+		}
+		for _, r := range ranges {
+			if id >= r.low && id <= r.high {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// textPredicate matches words in the description or any annotation, ignoring case
+func textPredicate(text string) predicate {
+	needle := strings.ToLower(text)
+	return func(_ *Context, task *models.Task) bool {
+		if strings.Contains(strings.ToLower(task.Description), needle) {
+			return true
+		}
+		for _, annotation := range task.Annotations {
+			if strings.Contains(strings.ToLower(annotation.Description), needle) {
+				return true
+			}
+		}
+		return false
+	}
+}
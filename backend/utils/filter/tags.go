@@ -0,0 +1,112 @@
+package filter
+
+import (
+	"ccsync_backend/models"
+	"ccsync_backend/utils"
+	"strings"
+	"time"
+)
+
+// virtualTags are computed from task state, following Taskwarrior's definitions
+var virtualTags = map[string]predicate{
+	"PENDING": func(ctx *Context, task *models.Task) bool {
+		return task.Status == "pending" && !isWaiting(ctx, task)
+	},
+	"WAITING":   isWaiting,
+	"COMPLETED": statusIs("completed"),
+	"DELETED":   statusIs("deleted"),
+	"ACTIVE": func(ctx *Context, task *models.Task) bool {
+		return task.Start != "" && isOpen(ctx, task)
+	},
+	"BLOCKED": isBlocked,
+	"UNBLOCKED": func(ctx *Context, task *models.Task) bool {
+		return !isBlocked(ctx, task)
+	},
+	"BLOCKING": func(ctx *Context, task *models.Task) bool {
+		return ctx.blocking[task.UUID] && isOpen(ctx, task)
+	},
+	"READY": func(ctx *Context, task *models.Task) bool {
+		return task.Status == "pending" && !isWaiting(ctx, task) && !isBlocked(ctx, task)
+	},
+	"OVERDUE": func(ctx *Context, task *models.Task) bool {
+		due, ok := parseTaskDate(task.Due)
+		return ok && isOpen(ctx, task) && due.Before(ctx.Now)
+	},
+	// due soon: any time today, or within the next 7 days
+	"DUE": func(ctx *Context, task *models.Task) bool {
+		due, ok := parseTaskDate(task.Due)
+		return ok && isOpen(ctx, task) &&
+			!due.Before(utils.StartOfDay(ctx.Now)) && due.Before(ctx.Now.AddDate(0, 0, 7))
+	},
+	"DUETODAY":  dueInPeriod(utils.DayBounds),
+	"TODAY":     dueInPeriod(utils.DayBounds),
+	"TOMORROW":  dueInPeriod(func(now time.Time) (time.Time, time.Time) { return utils.DayBounds(now.AddDate(0, 0, 1)) }),
+	"YESTERDAY": dueInPeriod(func(now time.Time) (time.Time, time.Time) { return utils.DayBounds(now.AddDate(0, 0, -1)) }),
+	"WEEK":      dueInPeriod(utils.WeekBounds),
+	"MONTH":     dueInPeriod(utils.MonthBounds),
+	"QUARTER":   dueInPeriod(utils.QuarterBounds),
+	"YEAR":      dueInPeriod(utils.YearBounds),
+	"ANNOTATED": func(_ *Context, task *models.Task) bool {
+		return len(task.Annotations) > 0
+	},
+	"TAGGED": func(_ *Context, task *models.Task) bool {
+		return len(task.Tags) > 0
+	},
+	"PROJECT": func(_ *Context, task *models.Task) bool {
+		return task.Project != ""
+	},
+	"PRIORITY": func(_ *Context, task *models.Task) bool {
+		return task.Priority != ""
+	},
+	"UNTIL": func(_ *Context, task *models.Task) bool {
+		return task.Until != ""
+	},
+	"RECURRING": func(_ *Context, task *models.Task) bool {
+		return task.Recur != ""
+	},
+	"TEMPLATE": statusIs("recurring"),
+	"PARENT":   statusIs("recurring"),
+	"INSTANCE": func(_ *Context, task *models.Task) bool {
+		return task.Parent != ""
+	},
+	"CHILD": func(_ *Context, task *models.Task) bool {
+		return task.Parent != ""
+	},
+}
+
+// tagPredicate matches a virtual tag when the name is one, otherwise a regular tag
+func tagPredicate(name string) predicate {
+	if virtual, ok := virtualTags[name]; ok {
+		return virtual
+	}
+	return func(_ *Context, task *models.Task) bool {
+		return hasTag(task, name)
+	}
+}
+
+func hasTag(task *models.Task, name string) bool {
+	for _, tag := range task.Tags {
+		if strings.EqualFold(tag, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func statusIs(status string) predicate {
+	return func(_ *Context, task *models.Task) bool {
+		return task.Status == status
+	}
+}
+
+// dueInPeriod matches open tasks due within the [start, end) period computed from now
+func dueInPeriod(bounds func(now time.Time) (time.Time, time.Time)) predicate {
+	return func(ctx *Context, task *models.Task) bool {
+		due, ok := parseTaskDate(task.Due)
+		if !ok || !isOpen(ctx, task) {
+			return false
+		}
+		start, end := bounds(ctx.Now)
+		return !due.Before(start) && due.Before(end)
+	}
+}
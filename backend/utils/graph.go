@@ -0,0 +1,180 @@
+package utils
+
+// DependencyGraph links tasks to the tasks they depend on. Only open tasks, pending
+// or waiting, constrain anything: a dependency on a completed task is satisfied.
+type DependencyGraph struct {
+	tasks      map[string]TaskDependency
+	order      []string
+	depends    map[string][]string
+	dependents map[string][]string
+}
+
+// DanglingDependency is a depends entry pointing at a deleted or unknown task
+type DanglingDependency struct {
+	Task      string `json:"task"`
+	DependsOn string `json:"dependsOn"`
+	Reason    string `json:"reason"` // deleted, unknown
+}
+
+// NewDependencyGraph links the given tasks, which should be all of a user's: a
+// dependency on a task missing from them is taken as dangling. When a UUID appears
+// twice, the last entry wins.
+func NewDependencyGraph(tasks []TaskDependency) *DependencyGraph {
+	graph := &DependencyGraph{
+		tasks:      make(map[string]TaskDependency, len(tasks)),
+		depends:    make(map[string][]string),
+		dependents: make(map[string][]string),
+	}
+	for _, task := range tasks {
+		if _, seen := graph.tasks[task.UUID]; !seen {
+			graph.order = append(graph.order, task.UUID)
+		}
+		graph.tasks[task.UUID] = task
+	}
+	for _, uuid := range graph.order {
+		if graph.IsOpen(uuid) {
+			graph.SetDepends(uuid, graph.tasks[uuid].Depends)
+		}
+	}
+	return graph
+}
+
+// IsOpen reports whether the task is known and still to be done
+func (g *DependencyGraph) IsOpen(uuid string) bool {
+	task, ok := g.tasks[uuid]
+	return ok && (task.Status == "pending" || task.Status == "waiting")
+}
+
+// SetDepends replaces the dependencies of a task, e.g. to check a proposed change
+func (g *DependencyGraph) SetDepends(uuid string, depends []string) {
+	for _, dep := range g.depends[uuid] {
+		g.dependents[dep] = without(g.dependents[dep], uuid)
+	}
+	g.depends[uuid] = append([]string(nil), depends...)
+	for _, dep := range depends {
+		g.dependents[dep] = append(g.dependents[dep], uuid)
+	}
+}
+
+// Blocked reports whether the task waits on a dependency that is still open
+func (g *DependencyGraph) Blocked(uuid string) bool {
+	for _, dep := range g.depends[uuid] {
+		if g.IsOpen(dep) {
+			return true
+		}
+	}
+	return false
+}
+
+// Blocking reports whether the task is open and an open task depends on it
+func (g *DependencyGraph) Blocking(uuid string) bool {
+	if !g.IsOpen(uuid) {
+		return false
+	}
+	for _, dependent := range g.dependents[uuid] {
+		if g.IsOpen(dependent) {
+			return true
+		}
+	}
+	return false
+}
+
+// Dependents lists the open tasks that depend on the task directly
+func (g *DependencyGraph) Dependents(uuid string) []string {
+	dependents := []string{}
+	for _, dependent := range g.dependents[uuid] {
+		if g.IsOpen(dependent) {
+			dependents = append(dependents, dependent)
+		}
+	}
+	return dependents
+}
+
+// TransitiveDependents lists the open tasks that depend on the task directly or
+// through other open tasks, nearest first
+func (g *DependencyGraph) TransitiveDependents(uuid string) []string {
+	seen := map[string]bool{uuid: true}
+	result := []string{}
+	queue := []string{uuid}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, dependent := range g.Dependents(current) {
+			if !seen[dependent] {
+				seen[dependent] = true
+				result = append(result, dependent)
+				queue = append(queue, dependent)
+			}
+		}
+	}
+	return result
+}
+
+// Dangling lists the dependencies of open tasks on deleted or unknown tasks
+func (g *DependencyGraph) Dangling() []DanglingDependency {
+	dangling := []DanglingDependency{}
+	for _, uuid := range g.order {
+		if !g.IsOpen(uuid) {
+			continue
+		}
+		for _, dep := range g.depends[uuid] {
+			task, ok := g.tasks[dep]
+			switch {
+			case !ok:
+				dangling = append(dangling, DanglingDependency{Task: uuid, DependsOn: dep, Reason: "unknown"})
+			case task.Status == "deleted":
+				dangling = append(dangling, DanglingDependency{Task: uuid, DependsOn: dep, Reason: "deleted"})
+			}
+		}
+	}
+	return dangling
+}
+
+// HasCycleFrom reports whether following dependencies from the task leads back to a
+// task already on the path
+func (g *DependencyGraph) HasCycleFrom(uuid string) bool {
+	return detectCycle(g.depends, uuid)
+}
+
+// CriticalPath returns the longest chain of open dependencies leading to the task,
+// ordered from the first task to do to the task itself. It is the minimum number of
+// tasks that have to be finished one after another before the task can be done.
+func (g *DependencyGraph) CriticalPath(uuid string) []string {
+	longest := make(map[string][]string)
+	onPath := make(map[string]bool)
+
+	var walk func(string) []string
+	walk = func(node string) []string {
+		if path, done := longest[node]; done {
+			return path
+		}
+		onPath[node] = true
+		var best []string
+		for _, dep := range g.depends[node] {
+			// edges closing a cycle are skipped, as no order satisfies them anyway
+			if !g.IsOpen(dep) || onPath[dep] {
+				continue
+			}
+			if path := walk(dep); len(path) > len(best) {
+				best = path
+			}
+		}
+		onPath[node] = false
+
+		path := append(append([]string(nil), best...), node)
+		longest[node] = path
+		return path
+	}
+
+	return walk(uuid)
+}
+
+func without(values []string, value string) []string {
+	kept := values[:0]
+	for _, v := range values {
+		if v != value {
+			kept = append(kept, v)
+		}
+	}
+	return kept
+}
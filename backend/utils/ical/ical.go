@@ -0,0 +1,328 @@
+package ical
+
+import (
+	"ccsync_backend/models"
+	"ccsync_backend/utils"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	dateLayout     = "20060102"
+	dateTimeLayout = "20060102T150405Z"
+
+	// maxLineLength is the length in octets after which content lines are folded
+	maxLineLength = 75
+
+	productID = "-//CCSync//Taskwarrior//EN"
+)
+
+// Options control which components a calendar contains and how dates are written
+type Options struct {
+	// Name is shown by calendar apps as the calendar's name
+	Name string
+	// Events adds a VEVENT per task on its due date, or scheduled date if it has none
+	Events bool
+	// Todos adds a VTODO per task, with its due and scheduled dates
+	Todos bool
+	// Location decides which dates are whole days: those at midnight in Location are
+	// written as dates rather than times
+	Location *time.Location
+	Now      time.Time
+	// OmitRecurrence leaves out recurrence rules, for clients that see every instance
+	// of a series as a task of its own
+	OmitRecurrence bool
+}
+
+// priorities maps Taskwarrior priorities to iCalendar ones (1 highest, 9 lowest)
+var priorities = map[string]int{"H": 1, "M": 5, "L": 9}
+
+// Build writes an iCalendar (RFC 5545) document for the open tasks that have a due or
+// scheduled date. A recurring series appears once, from its template (or its earliest
+// instance when the template is not among the tasks), with a recurrence rule derived
+// from its recur attribute.
+func Build(tasks []models.Task, opts Options) string {
+	w := &writer{}
+	w.line("BEGIN:VCALENDAR")
+	w.line("VERSION:2.0")
+	w.line("PRODID:" + productID)
+	w.line("CALSCALE:GREGORIAN")
+	w.line("METHOD:PUBLISH")
+	if opts.Name != "" {
+		w.line("X-WR-CALNAME:" + escapeText(opts.Name))
+	}
+	w.line("REFRESH-INTERVAL;VALUE=DURATION:PT1H")
+	w.line("X-PUBLISHED-TTL:PT1H")
+
+	for _, task := range calendarTasks(tasks) {
+		if opts.Events {
+			writeEvent(w, task, opts)
+		}
+		if opts.Todos {
+			writeTodo(w, task, opts)
+		}
+	}
+
+	w.line("END:VCALENDAR")
+	return w.String()
+}
+
+// calendarTasks keeps the open tasks with a due or scheduled date, and one task per
+// recurring series
+func calendarTasks(tasks []models.Task) []models.Task {
+	templates := make(map[string]bool)
+	for _, task := range tasks {
+		if task.Status == "recurring" && hasDate(task) {
+			templates[task.UUID] = true
+		}
+	}
+
+	selected := []models.Task{}
+	// earliest maps a series without template to the index of its earliest instance
+	earliest := make(map[string]int)
+	for _, task := range tasks {
+		switch {
+		case !hasDate(task):
+		case task.Status == "recurring":
+			selected = append(selected, task)
+		case task.Status != "pending" && task.Status != "waiting":
+		case task.Parent != "" && task.Recur != "":
+			if templates[task.Parent] {
+				continue
+			}
+			// compact UTC dates sort chronologically as strings
+			if i, ok := earliest[task.Parent]; !ok {
+				earliest[task.Parent] = len(selected)
+				selected = append(selected, task)
+			} else if firstDate(task) < firstDate(selected[i]) {
+				selected[i] = task
+			}
+		default:
+			selected = append(selected, task)
+		}
+	}
+	return selected
+}
+
+func writeEvent(w *writer, task models.Task, opts Options) {
+	start, _ := parseDate(firstDate(task))
+	allDay := isMidnight(start, opts.Location)
+
+	w.line("BEGIN:VEVENT")
+	w.line("UID:" + task.UUID + "-event")
+	writeCommon(w, task, opts)
+	w.line(dateProperty("DTSTART", start, allDay, opts.Location))
+	if allDay {
+		w.line(dateProperty("DTEND", start.In(opts.Location).AddDate(0, 0, 1), true, opts.Location))
+	}
+	if !opts.OmitRecurrence {
+		writeRecurrence(w, task, allDay, opts.Location)
+	}
+	w.line("END:VEVENT")
+}
+
+func writeTodo(w *writer, task models.Task, opts Options) {
+	due, hasDue := parseDate(task.Due)
+	scheduled, hasScheduled := parseDate(task.Scheduled)
+	// DUE and DTSTART must share their value type
+	allDay := (!hasDue || isMidnight(due, opts.Location)) && (!hasScheduled || isMidnight(scheduled, opts.Location))
+
+	w.line("BEGIN:VTODO")
+	w.line("UID:" + task.UUID)
+	writeCommon(w, task, opts)
+	if hasScheduled {
+		w.line(dateProperty("DTSTART", scheduled, allDay, opts.Location))
+	}
+	if hasDue {
+		w.line(dateProperty("DUE", due, allDay, opts.Location))
+	}
+	switch {
+	case task.Status == "completed":
+		w.line("STATUS:COMPLETED")
+		if end, ok := parseDate(task.End); ok {
+			w.line("COMPLETED:" + end.Format(dateTimeLayout))
+		}
+	case task.Status == "deleted":
+		w.line("STATUS:CANCELLED")
+	case task.Start != "":
+		w.line("STATUS:IN-PROCESS")
+	default:
+		w.line("STATUS:NEEDS-ACTION")
+	}
+	if !opts.OmitRecurrence {
+		writeRecurrence(w, task, allDay, opts.Location)
+	}
+	w.line("END:VTODO")
+}
+
+// writeCommon writes the properties events and to-dos share
+func writeCommon(w *writer, task models.Task, opts Options) {
+	w.line("DTSTAMP:" + opts.Now.UTC().Format(dateTimeLayout))
+	if entry, ok := parseDate(task.Entry); ok {
+		w.line("CREATED:" + entry.Format(dateTimeLayout))
+	}
+	if modified, ok := parseDate(task.Modified); ok {
+		w.line("LAST-MODIFIED:" + modified.Format(dateTimeLayout))
+	}
+	w.line("SUMMARY:" + escapeText(task.Description))
+
+	var description []string
+	if task.Project != "" {
+		description = append(description, "Project: "+task.Project)
+	}
+	for _, annotation := range task.Annotations {
+		description = append(description, annotation.Description)
+	}
+	if len(description) > 0 {
+		w.line("DESCRIPTION:" + escapeText(strings.Join(description, "\n")))
+	}
+	if len(task.Tags) > 0 {
+		categories := make([]string, len(task.Tags))
+		for i, tag := range task.Tags {
+			categories[i] = escapeText(tag)
+		}
+		w.line("CATEGORIES:" + strings.Join(categories, ","))
+	}
+	if priority, ok := priorities[task.Priority]; ok {
+		w.line("PRIORITY:" + strconv.Itoa(priority))
+	}
+}
+
+func writeRecurrence(w *writer, task models.Task, allDay bool, loc *time.Location) {
+	if task.Recur == "" {
+		return
+	}
+	start, _ := parseDate(firstDate(task))
+	rule, ok := RecurrenceRule(task.Recur, start.In(loc))
+	if !ok {
+		return
+	}
+	if until, ok := parseDate(task.Until); ok {
+		if allDay {
+			rule += ";UNTIL=" + until.In(loc).Format(dateLayout)
+		} else {
+			rule += ";UNTIL=" + until.Format(dateTimeLayout)
+		}
+	}
+	w.line("RRULE:" + rule)
+}
+
+// RecurrenceRule converts a Taskwarrior recurrence period into an RRULE value for a
+// series starting at start, in the task's timezone. Month and year steps from the 29th
+// onwards fall back to the last day of shorter months, as Taskwarrior does. Periods
+// mixing units, such as P1M2D, have no equivalent.
+func RecurrenceRule(recur string, start time.Time) (string, bool) {
+	period, err := utils.ParsePeriod(recur)
+	if err != nil || period.IsZero() {
+		return "", false
+	}
+
+	units := 0
+	for _, set := range []bool{period.Years != 0, period.Months != 0, period.Days != 0, period.Duration != 0} {
+		if set {
+			units++
+		}
+	}
+	if units != 1 {
+		return "", false
+	}
+
+	var freq string
+	var interval int
+	switch {
+	case period.Weekdays:
+		return "FREQ=DAILY;BYDAY=MO,TU,WE,TH,FR", true
+	case period.Years != 0:
+		freq, interval = "YEARLY", period.Years
+	case period.Months != 0:
+		freq, interval = "MONTHLY", period.Months
+	case period.Days != 0 && period.Days%7 == 0:
+		freq, interval = "WEEKLY", period.Days/7
+	case period.Days != 0:
+		freq, interval = "DAILY", period.Days
+	case period.Duration%time.Hour == 0:
+		freq, interval = "HOURLY", int(period.Duration/time.Hour)
+	case period.Duration%time.Minute == 0:
+		freq, interval = "MINUTELY", int(period.Duration/time.Minute)
+	default:
+		freq, interval = "SECONDLY", int(period.Duration/time.Second)
+	}
+
+	rule := "FREQ=" + freq
+	if interval > 1 {
+		rule += ";INTERVAL=" + strconv.Itoa(interval)
+	}
+	if (freq == "MONTHLY" || freq == "YEARLY") && start.Day() > 28 {
+		if freq == "YEARLY" {
+			rule += fmt.Sprintf(";BYMONTH=%d", int(start.Month()))
+		}
+		days := []string{}
+		for day := 28; day <= start.Day(); day++ {
+			days = append(days, strconv.Itoa(day))
+		}
+		rule += ";BYMONTHDAY=" + strings.Join(days, ",") + ";BYSETPOS=-1"
+	}
+	return rule, true
+}
+
+func dateProperty(name string, t time.Time, allDay bool, loc *time.Location) string {
+	if allDay {
+		return name + ";VALUE=DATE:" + t.In(loc).Format(dateLayout)
+	}
+	return name + ":" + t.UTC().Format(dateTimeLayout)
+}
+
+func isMidnight(t time.Time, loc *time.Location) bool {
+	local := t.In(loc)
+	return local.Hour() == 0 && local.Minute() == 0 && local.Second() == 0
+}
+
+func hasDate(task models.Task) bool {
+	return task.Due != "" || task.Scheduled != ""
+}
+
+// firstDate is the date a task is placed at in a calendar: due, else scheduled
+func firstDate(task models.Task) string {
+	if task.Due != "" {
+		return task.Due
+	}
+	return task.Scheduled
+}
+
+func parseDate(value string) (time.Time, bool) {
+	if value == "" {
+		return time.Time{}, false
+	}
+	parsed, err := time.Parse(utils.TaskwarriorDateLayout, value)
+	return parsed, err == nil
+}
+
+var textEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", "")
+
+// escapeText escapes a TEXT value
+func escapeText(value string) string {
+	return textEscaper.Replace(value)
+}
+
+// writer accumulates content lines, folded and terminated with CRLF
+type writer struct {
+	strings.Builder
+}
+
+func (w *writer) line(content string) {
+	limit := maxLineLength
+	for len(content) > limit {
+		cut := limit
+		// never split a UTF-8 sequence
+		for cut > 0 && content[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		w.WriteString(content[:cut] + "\r\n ")
+		content = content[cut:]
+		// continuation lines start with a space, which counts towards the limit
+		limit = maxLineLength - 1
+	}
+	w.WriteString(content + "\r\n")
+}
@@ -0,0 +1,104 @@
+package ical
+
+import (
+	"ccsync_backend/models"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testNow = time.Date(2025, 3, 14, 10, 0, 0, 0, time.UTC)
+
+func Test_Build_EventsAndTodos(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	assert.NoError(t, err)
+
+	tasks := []models.Task{
+		{UUID: "a", Description: "Pay rent, on time", Status: "pending", Due: "20250331T220000Z", Project: "Home", Tags: []string{"bills"}, Priority: "H"},
+		{UUID: "b", Description: "Call Bob", Status: "pending", Due: "20250315T143000Z", Scheduled: "20250314T230000Z", Start: "20250314T090000Z"},
+		{UUID: "c", Description: "No date", Status: "pending"},
+		{UUID: "d", Description: "Done", Status: "completed", Due: "20250301T000000Z"},
+	}
+	calendar := Build(tasks, Options{Name: "Home", Events: true, Todos: true, Location: berlin, Now: testNow})
+
+	assert.True(t, strings.HasPrefix(calendar, "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n"))
+	assert.True(t, strings.HasSuffix(calendar, "END:VCALENDAR\r\n"))
+	assert.Contains(t, calendar, "X-WR-CALNAME:Home\r\n")
+	assert.Equal(t, 2, strings.Count(calendar, "BEGIN:VEVENT"))
+	assert.Equal(t, 2, strings.Count(calendar, "BEGIN:VTODO"))
+	assert.NotContains(t, calendar, "No date")
+	assert.NotContains(t, calendar, "UID:d")
+
+	// midnight in Berlin is a whole day
+	assert.Contains(t, calendar, "UID:a-event\r\n")
+	assert.Contains(t, calendar, "DTSTART;VALUE=DATE:20250401\r\nDTEND;VALUE=DATE:20250402\r\n")
+	assert.Contains(t, calendar, "SUMMARY:Pay rent\\, on time\r\n")
+	assert.Contains(t, calendar, "CATEGORIES:bills\r\nPRIORITY:1\r\n")
+
+	// a timed due date forces DTSTART and DUE of the to-do to be times too
+	assert.Contains(t, calendar, "DTSTART:20250315T143000Z\r\n")
+	assert.Contains(t, calendar, "DTSTART:20250314T230000Z\r\nDUE:20250315T143000Z\r\nSTATUS:IN-PROCESS\r\n")
+}
+
+func Test_Build_RecurringSeriesOnce(t *testing.T) {
+	tasks := []models.Task{
+		{UUID: "template", Description: "Water plants", Status: "recurring", Due: "20250103T000000Z", Recur: "weekly", Until: "20250601T000000Z"},
+		{UUID: "i1", Description: "Water plants", Status: "pending", Parent: "template", Recur: "weekly", Due: "20250314T000000Z"},
+		{UUID: "i2", Description: "Water plants", Status: "pending", Parent: "template", Recur: "weekly", Due: "20250321T000000Z"},
+		{UUID: "j2", Description: "Review", Status: "pending", Parent: "orphan", Recur: "2wks", Due: "20250328T090000Z"},
+		{UUID: "j1", Description: "Review", Status: "pending", Parent: "orphan", Recur: "2wks", Due: "20250314T090000Z"},
+	}
+	calendar := Build(tasks, Options{Events: true, Location: time.UTC, Now: testNow})
+
+	assert.Equal(t, 2, strings.Count(calendar, "BEGIN:VEVENT"))
+	assert.Contains(t, calendar, "UID:template-event\r\n")
+	assert.Contains(t, calendar, "RRULE:FREQ=WEEKLY;UNTIL=20250601\r\n")
+	assert.Contains(t, calendar, "UID:j1-event\r\n")
+	assert.Contains(t, calendar, "RRULE:FREQ=WEEKLY;INTERVAL=2\r\n")
+	assert.NotContains(t, calendar, "UID:i1")
+}
+
+func Test_RecurrenceRule(t *testing.T) {
+	jan15 := time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC)
+	jan31 := time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		recur string
+		start time.Time
+		want  string
+	}{
+		{"daily", jan15, "FREQ=DAILY"},
+		{"3d", jan15, "FREQ=DAILY;INTERVAL=3"},
+		{"biweekly", jan15, "FREQ=WEEKLY;INTERVAL=2"},
+		{"weekdays", jan15, "FREQ=DAILY;BYDAY=MO,TU,WE,TH,FR"},
+		{"quarterly", jan15, "FREQ=MONTHLY;INTERVAL=3"},
+		{"monthly", jan31, "FREQ=MONTHLY;BYMONTHDAY=28,29,30,31;BYSETPOS=-1"},
+		{"yearly", jan15, "FREQ=YEARLY"},
+		{"2h", jan15, "FREQ=HOURLY;INTERVAL=2"},
+	}
+	for _, tt := range tests {
+		got, ok := RecurrenceRule(tt.recur, tt.start)
+		assert.True(t, ok, tt.recur)
+		assert.Equal(t, tt.want, got, tt.recur)
+	}
+
+	_, ok := RecurrenceRule("P1M2D", jan15)
+	assert.False(t, ok)
+	_, ok = RecurrenceRule("sometimes", jan15)
+	assert.False(t, ok)
+}
+
+func Test_Writer_FoldsLongLines(t *testing.T) {
+	w := &writer{}
+	w.line("SUMMARY:" + strings.Repeat("é", 60))
+
+	lines := strings.Split(strings.TrimSuffix(w.String(), "\r\n"), "\r\n")
+	assert.Len(t, lines, 2)
+	for _, line := range lines {
+		assert.LessOrEqual(t, len(line), maxLineLength)
+	}
+	assert.True(t, strings.HasPrefix(lines[1], " "))
+	assert.Equal(t, "SUMMARY:"+strings.Repeat("é", 60), lines[0]+lines[1][1:])
+}
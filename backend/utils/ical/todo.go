@@ -0,0 +1,230 @@
+package ical
+
+import (
+	"ccsync_backend/models"
+	"ccsync_backend/utils"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Todo writes a calendar object holding the task as a single VTODO, as stored by a
+// CalDAV server. Unlike Build, the task is written whatever its status and dates.
+func Todo(task models.Task, opts Options) string {
+	w := &writer{}
+	w.line("BEGIN:VCALENDAR")
+	w.line("VERSION:2.0")
+	w.line("PRODID:" + productID)
+	writeTodo(w, task, opts)
+	w.line("END:VCALENDAR")
+	return w.String()
+}
+
+// ParsedTodo holds the properties of a VTODO that map onto a task
+type ParsedTodo struct {
+	UID        string
+	Summary    string
+	Due        *time.Time
+	Priority   int
+	Status     string
+	Categories []string
+	Completed  *time.Time
+}
+
+// ParseTodo reads the first VTODO of a calendar object. Dates without a timezone
+// (floating times and whole days) are read in loc.
+func ParseTodo(data string, loc *time.Location) (ParsedTodo, error) {
+	var todo ParsedTodo
+	found := false
+	// depth counts the components opened inside the VTODO, such as VALARM, whose
+	// properties are not the task's
+	depth := -1
+
+	for _, line := range unfold(data) {
+		name, params, value := splitContentLine(line)
+		switch {
+		case name == "BEGIN" && value == "VTODO" && !found && depth < 0:
+			depth = 0
+			continue
+		case depth < 0:
+			continue
+		case name == "BEGIN":
+			depth++
+			continue
+		case name == "END" && depth > 0:
+			depth--
+			continue
+		case name == "END":
+			found = true
+			depth = -1
+			continue
+		case depth > 0:
+			continue
+		}
+
+		switch name {
+		case "UID":
+			todo.UID = value
+		case "SUMMARY":
+			todo.Summary = unescapeText(value)
+		case "DUE":
+			due, err := parseValueDate(value, params, loc)
+			if err != nil {
+				return ParsedTodo{}, fmt.Errorf("invalid DUE: %v", err)
+			}
+			todo.Due = &due
+		case "COMPLETED":
+			completed, err := parseValueDate(value, params, loc)
+			if err != nil {
+				return ParsedTodo{}, fmt.Errorf("invalid COMPLETED: %v", err)
+			}
+			todo.Completed = &completed
+		case "PRIORITY":
+			priority, err := strconv.Atoi(value)
+			if err != nil || priority < 0 || priority > 9 {
+				return ParsedTodo{}, fmt.Errorf("invalid PRIORITY '%s'", value)
+			}
+			todo.Priority = priority
+		case "STATUS":
+			todo.Status = strings.ToUpper(value)
+		case "CATEGORIES":
+			for _, category := range splitList(value) {
+				if category = strings.TrimSpace(unescapeText(category)); category != "" {
+					todo.Categories = append(todo.Categories, category)
+				}
+			}
+		}
+	}
+
+	if !found {
+		return ParsedTodo{}, fmt.Errorf("no VTODO found")
+	}
+	if todo.UID == "" {
+		return ParsedTodo{}, fmt.Errorf("VTODO has no UID")
+	}
+	if strings.TrimSpace(todo.Summary) == "" {
+		return ParsedTodo{}, fmt.Errorf("VTODO has no SUMMARY")
+	}
+	return todo, nil
+}
+
+// Fields converts the to-do into the task attributes it sets. Priorities 1 to 4 are
+// high, 5 medium and 6 to 9 low. Spaces in categories become underscores, as tags
+// cannot contain them.
+func (t ParsedTodo) Fields() models.TodoFields {
+	fields := models.TodoFields{
+		Description: t.Summary,
+		Status:      "pending",
+		Tags:        []string{},
+	}
+	if t.Due != nil {
+		fields.Due = t.Due.UTC().Format(utils.TaskwarriorDateLayout)
+	}
+	switch {
+	case t.Priority >= 1 && t.Priority <= 4:
+		fields.Priority = "H"
+	case t.Priority == 5:
+		fields.Priority = "M"
+	case t.Priority >= 6:
+		fields.Priority = "L"
+	}
+	switch t.Status {
+	case "COMPLETED":
+		fields.Status = "completed"
+		if t.Completed != nil {
+			fields.End = t.Completed.UTC().Format(utils.TaskwarriorDateLayout)
+		}
+	case "CANCELLED":
+		fields.Status = "deleted"
+	case "IN-PROCESS":
+		fields.Started = true
+	}
+	for _, category := range t.Categories {
+		fields.Tags = append(fields.Tags, strings.ReplaceAll(category, " ", "_"))
+	}
+	return fields
+}
+
+// unfold joins folded content lines and drops empty ones
+func unfold(data string) []string {
+	var lines []string
+	for _, line := range strings.Split(strings.ReplaceAll(data, "\r\n", "\n"), "\n") {
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// splitContentLine splits "NAME;PARAM=VALUE:value" into its upper-cased name, its
+// parameters and its value
+func splitContentLine(line string) (string, map[string]string, string) {
+	params := make(map[string]string)
+
+	// the value starts at the first colon outside a quoted parameter value
+	quoted := false
+	colon := -1
+	for i, r := range line {
+		if r == '"' {
+			quoted = !quoted
+		} else if r == ':' && !quoted {
+			colon = i
+			break
+		}
+	}
+	if colon < 0 {
+		return strings.ToUpper(line), params, ""
+	}
+
+	parts := strings.Split(line[:colon], ";")
+	for _, param := range parts[1:] {
+		if key, value, ok := strings.Cut(param, "="); ok {
+			params[strings.ToUpper(key)] = strings.Trim(value, `"`)
+		}
+	}
+	return strings.ToUpper(parts[0]), params, line[colon+1:]
+}
+
+// parseValueDate reads a DATE or DATE-TIME value, in the zone of its TZID parameter
+// if any
+func parseValueDate(value string, params map[string]string, loc *time.Location) (time.Time, error) {
+	if tzid, ok := params["TZID"]; ok {
+		if zone, err := time.LoadLocation(tzid); err == nil {
+			loc = zone
+		}
+	}
+	if strings.HasSuffix(value, "Z") {
+		return time.Parse(dateTimeLayout, value)
+	}
+	if params["VALUE"] == "DATE" || len(value) == len(dateLayout) {
+		return time.ParseInLocation(dateLayout, value, loc)
+	}
+	return time.ParseInLocation("20060102T150405", value, loc)
+}
+
+// splitList splits a list value on the commas that are not escaped
+func splitList(value string) []string {
+	var items []string
+	start := 0
+	for i := 0; i < len(value); i++ {
+		switch value[i] {
+		case '\\':
+			i++
+		case ',':
+			items = append(items, value[start:i])
+			start = i + 1
+		}
+	}
+	return append(items, value[start:])
+}
+
+var textUnescaper = strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n")
+
+func unescapeText(value string) string {
+	return textUnescaper.Replace(value)
+}
@@ -0,0 +1,68 @@
+package ical
+
+import (
+	"ccsync_backend/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ParseTodo(t *testing.T) {
+	data := "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nBEGIN:VTODO\r\nUID:abc\r\n" +
+		"SUMMARY:Pay rent\\, then\r\n  relax\r\n" +
+		"DUE;TZID=Europe/Berlin:20250315T090000\r\n" +
+		"PRIORITY:3\r\nSTATUS:COMPLETED\r\nCOMPLETED:20250314T100000Z\r\n" +
+		"CATEGORIES:bills,home office\r\n" +
+		"BEGIN:VALARM\r\nSUMMARY:Reminder\r\nEND:VALARM\r\n" +
+		"END:VTODO\r\nEND:VCALENDAR\r\n"
+
+	todo, err := ParseTodo(data, time.UTC)
+	assert.NoError(t, err)
+	assert.Equal(t, "abc", todo.UID)
+	assert.Equal(t, "Pay rent, then relax", todo.Summary)
+	assert.Equal(t, time.Date(2025, 3, 15, 8, 0, 0, 0, time.UTC), todo.Due.UTC())
+
+	fields := todo.Fields()
+	assert.Equal(t, models.TodoFields{
+		Description: "Pay rent, then relax",
+		Due:         "20250315T080000Z",
+		Priority:    "H",
+		Tags:        []string{"bills", "home_office"},
+		Status:      "completed",
+		End:         "20250314T100000Z",
+	}, fields)
+
+	_, err = ParseTodo("BEGIN:VCALENDAR\r\nBEGIN:VTODO\r\nUID:abc\r\nEND:VTODO\r\nEND:VCALENDAR\r\n", time.UTC)
+	assert.Error(t, err)
+	_, err = ParseTodo("BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nUID:abc\r\nSUMMARY:x\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n", time.UTC)
+	assert.Error(t, err)
+}
+
+func Test_Todo_RoundTrip(t *testing.T) {
+	task := models.Task{
+		UUID:        "11111111-2222-3333-4444-555555555555",
+		Description: "Water plants",
+		Status:      "pending",
+		Start:       "20250314T080000Z",
+		Due:         "20250316T000000Z",
+		Priority:    "L",
+		Recur:       "weekly",
+		Tags:        []string{"home"},
+	}
+	data := Todo(task, Options{Location: time.UTC, Now: testNow, OmitRecurrence: true})
+	assert.NotContains(t, data, "RRULE")
+	assert.NotContains(t, data, "METHOD")
+
+	todo, err := ParseTodo(data, time.UTC)
+	assert.NoError(t, err)
+	assert.Equal(t, task.UUID, todo.UID)
+	assert.Equal(t, models.TodoFields{
+		Description: "Water plants",
+		Due:         "20250316T000000Z",
+		Priority:    "L",
+		Tags:        []string{"home"},
+		Status:      "pending",
+		Started:     true,
+	}, todo.Fields())
+}
@@ -0,0 +1,186 @@
+package importer
+
+import (
+	"ccsync_backend/models"
+	"ccsync_backend/utils"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// csvAttributes are the task attributes CSV columns can be mapped to
+var csvAttributes = map[string]bool{
+	"description": true,
+	"project":     true,
+	"priority":    true,
+	"tags":        true,
+	"status":      true,
+	"uuid":        true,
+	"due":         true,
+	"scheduled":   true,
+	"wait":        true,
+	"entry":       true,
+	"end":         true,
+}
+
+// csvDateAttributes are read as dates, ISO or as on the Taskwarrior command line
+var csvDateAttributes = []string{"due", "scheduled", "wait", "entry", "end"}
+
+// parseCSV reads one task per row. The first row names the columns, which are mapped
+// to attributes by opts.Mapping, or else by name. Tags are separated by commas or
+// spaces.
+func parseCSV(data string, opts Options) ([]models.ImportedTask, []models.ImportError, error) {
+	reader := csv.NewReader(strings.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil, fmt.Errorf("missing header row")
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid CSV: %v", err)
+	}
+	columns, err := csvColumns(header, opts.Mapping)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var tasks []models.ImportedTask
+	var errs []models.ImportError
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		line, _ := reader.FieldPos(0)
+		if err != nil {
+			// a malformed row, such as an unterminated quote, leaves the reader unusable
+			errs = append(errs, models.ImportError{Line: line, Message: err.Error()})
+			break
+		}
+		if isBlankRecord(record) {
+			continue
+		}
+
+		values := make(map[string]string)
+		for attribute, column := range columns {
+			if column < len(record) {
+				values[attribute] = strings.TrimSpace(record[column])
+			}
+		}
+		task, err := csvTask(values, opts)
+		if err != nil {
+			errs = append(errs, models.ImportError{Line: line, Message: err.Error()})
+			continue
+		}
+		tasks = append(tasks, models.ImportedTask{Line: line, Task: task})
+	}
+	return tasks, errs, nil
+}
+
+// csvColumns maps each attribute to the index of its column
+func csvColumns(header []string, mapping map[string]string) (map[string]int, error) {
+	indexes := make(map[string]int)
+	for i, name := range header {
+		indexes[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	columns := make(map[string]int)
+	if len(mapping) == 0 {
+		for name, i := range indexes {
+			if csvAttributes[name] {
+				columns[name] = i
+			}
+		}
+	}
+	for attribute, column := range mapping {
+		if !csvAttributes[attribute] {
+			return nil, fmt.Errorf("invalid mapping: unknown attribute '%s'", attribute)
+		}
+		i, ok := indexes[strings.ToLower(strings.TrimSpace(column))]
+		if !ok {
+			return nil, fmt.Errorf("invalid mapping: no column named '%s'", column)
+		}
+		columns[attribute] = i
+	}
+
+	if _, ok := columns["description"]; !ok {
+		return nil, fmt.Errorf("no column is mapped to description")
+	}
+	return columns, nil
+}
+
+func csvTask(values map[string]string, opts Options) (map[string]interface{}, error) {
+	if values["description"] == "" {
+		return nil, fmt.Errorf("missing description")
+	}
+	task := newTask(values["description"], opts)
+
+	if project := values["project"]; project != "" {
+		task["project"] = project
+	}
+	priority, err := parsePriority(values["priority"])
+	if err != nil {
+		return nil, err
+	}
+	if priority != "" {
+		task["priority"] = priority
+	}
+
+	var tags []string
+	for _, tag := range strings.FieldsFunc(values["tags"], func(r rune) bool { return r == ',' || r == ' ' }) {
+		tag = strings.TrimPrefix(tag, "+")
+		if !validTag(tag) {
+			return nil, fmt.Errorf("invalid tag '%s'", tag)
+		}
+		tags = append(tags, tag)
+	}
+	if len(tags) > 0 {
+		task["tags"] = tags
+	}
+
+	if value := values["uuid"]; value != "" {
+		parsed, err := uuid.Parse(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid uuid '%s'", value)
+		}
+		task["uuid"] = parsed.String()
+	}
+
+	for _, attribute := range csvDateAttributes {
+		if values[attribute] == "" {
+			continue
+		}
+		date, err := utils.NormalizeDateInput(values[attribute], opts.Now)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %v", attribute, err)
+		}
+		task[attribute] = date
+	}
+
+	switch status := strings.ToLower(values["status"]); status {
+	case "", "pending":
+		delete(task, "end")
+	case "completed", "deleted":
+		task["status"] = status
+		if _, ok := task["end"]; !ok {
+			task["end"] = opts.Now.UTC().Format(utils.TaskwarriorDateLayout)
+		}
+	default:
+		return nil, fmt.Errorf("invalid status '%s': expected pending, completed or deleted", values["status"])
+	}
+	return task, nil
+}
+
+func isBlankRecord(record []string) bool {
+	for _, value := range record {
+		if strings.TrimSpace(value) != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,128 @@
+package importer
+
+import (
+	"ccsync_backend/models"
+	"ccsync_backend/utils"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Options control how imported values are read
+type Options struct {
+	// Now is the time relative dates are resolved against, and the entry date of tasks
+	// that have none; dates without an offset are read in its location
+	Now time.Time
+	// Mapping maps task attributes to CSV column names
+	Mapping map[string]string
+}
+
+// Parse reads the tasks of an import. Entries that cannot be read are returned as
+// errors, with the line they are on, while the others are still read; an error is
+// only returned when the input as a whole cannot be read.
+func Parse(format, data string, opts Options) ([]models.ImportedTask, []models.ImportError, error) {
+	switch format {
+	case models.ImportFormatTodoTxt:
+		tasks, errs := parseTodoTxt(data, opts)
+		return tasks, errs, nil
+	case models.ImportFormatCSV:
+		return parseCSV(data, opts)
+	case models.ImportFormatJSON:
+		return parseJSON(data, opts)
+	}
+	return nil, nil, fmt.Errorf("invalid format '%s': expected todotxt, csv or json", format)
+}
+
+// MarkDuplicates flags the imported tasks that already exist, or that repeat an earlier
+// task of the import. Tasks with a UUID are duplicates when the UUID is taken; the
+// others when a task that is not deleted has the same description, ignoring case and
+// spacing. Tasks with a UUID are not compared by description, as the instances of a
+// recurring series share theirs.
+func MarkDuplicates(tasks []models.ImportedTask, existing []models.Task) {
+	uuids := make(map[string]bool)
+	descriptions := make(map[string]string)
+	for _, task := range existing {
+		uuids[task.UUID] = true
+		if task.Status != "deleted" {
+			descriptions[normalizeDescription(task.Description)] = task.UUID
+		}
+	}
+
+	seenUUIDs := make(map[string]bool)
+	seenDescriptions := make(map[string]bool)
+	for i := range tasks {
+		task := &tasks[i]
+		task.Duplicate, task.DuplicateOf = "", ""
+
+		if uuid := task.UUID(); uuid != "" {
+			switch {
+			case uuids[uuid]:
+				task.Duplicate, task.DuplicateOf = models.DuplicateUUID, uuid
+			case seenUUIDs[uuid]:
+				task.Duplicate = models.DuplicateUUID
+			}
+			seenUUIDs[uuid] = true
+			continue
+		}
+
+		description := normalizeDescription(task.Description())
+		if existingUUID, ok := descriptions[description]; ok {
+			task.Duplicate, task.DuplicateOf = models.DuplicateDescription, existingUUID
+		} else if seenDescriptions[description] {
+			task.Duplicate = models.DuplicateDescription
+		}
+		seenDescriptions[description] = true
+	}
+}
+
+// Preview summarizes what importing the tasks would do
+func Preview(tasks []models.ImportedTask, errs []models.ImportError) models.ImportPreview {
+	preview := models.ImportPreview{Tasks: tasks, Errors: errs}
+	if preview.Tasks == nil {
+		preview.Tasks = []models.ImportedTask{}
+	}
+	if preview.Errors == nil {
+		preview.Errors = []models.ImportError{}
+	}
+	for _, task := range tasks {
+		if task.Duplicate != "" {
+			preview.Duplicates++
+		} else {
+			preview.Importable++
+		}
+	}
+	return preview
+}
+
+func normalizeDescription(description string) string {
+	return strings.Join(strings.Fields(strings.ToLower(description)), " ")
+}
+
+// newTask starts a pending task with the given description, entered now
+func newTask(description string, opts Options) map[string]interface{} {
+	return map[string]interface{}{
+		"description": description,
+		"status":      "pending",
+		"entry":       opts.Now.UTC().Format(utils.TaskwarriorDateLayout),
+	}
+}
+
+// parsePriority reads a Taskwarrior priority, also spelled out
+func parsePriority(value string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "":
+		return "", nil
+	case "h", "high":
+		return "H", nil
+	case "m", "medium":
+		return "M", nil
+	case "l", "low":
+		return "L", nil
+	}
+	return "", fmt.Errorf("invalid priority '%s': expected H, M or L", value)
+}
+
+// validTag reports whether a tag can be used on the Taskwarrior command line
+func validTag(tag string) bool {
+	return tag != "" && !strings.ContainsAny(tag, " \t") && !strings.HasPrefix(tag, "+") && !strings.HasPrefix(tag, "-")
+}
@@ -0,0 +1,153 @@
+package importer
+
+import (
+	"ccsync_backend/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testOptions(t *testing.T) Options {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	assert.NoError(t, err)
+	return Options{Now: time.Date(2025, 3, 14, 10, 0, 0, 0, berlin)}
+}
+
+func Test_ParseTodoTxt(t *testing.T) {
+	data := "(A) 2025-03-01 Call mom +Family @phone due:2025-03-20 rec:1w\n" +
+		"\n" +
+		"x 2025-03-10 2025-03-01 Pay rent +Home +bills pri:B\n" +
+		"(B) +Work @office\n" +
+		"Renew passport t:next-year\n"
+
+	tasks, errs, err := Parse(models.ImportFormatTodoTxt, data, testOptions(t))
+	assert.NoError(t, err)
+	assert.Len(t, tasks, 2)
+	assert.Equal(t, []models.ImportError{
+		{Line: 4, Message: "missing description"},
+		{Line: 5, Message: "invalid t date 'next-year': expected YYYY-MM-DD"},
+	}, errs)
+
+	assert.Equal(t, 1, tasks[0].Line)
+	assert.Equal(t, map[string]interface{}{
+		"description": "Call mom rec:1w",
+		"status":      "pending",
+		"priority":    "H",
+		"project":     "Family",
+		"tags":        []string{"phone"},
+		"entry":       "20250228T230000Z",
+		"due":         "20250319T230000Z",
+	}, tasks[0].Task)
+
+	assert.Equal(t, 3, tasks[1].Line)
+	assert.Equal(t, map[string]interface{}{
+		"description": "Pay rent",
+		"status":      "completed",
+		"priority":    "M",
+		"project":     "Home",
+		"tags":        []string{"bills"},
+		"entry":       "20250228T230000Z",
+		"end":         "20250309T230000Z",
+	}, tasks[1].Task)
+}
+
+func Test_ParseCSV(t *testing.T) {
+	data := "Title,Folder,Labels,Due Date,Done\n" +
+		"Buy milk,Home,\"errands, food\",2025-03-15,\n" +
+		",Home,,,\n" +
+		"\"Write report\",Work,,tomorrow,completed\n" +
+		"Fix bike,Home,,,maybe\n"
+	mapping := map[string]string{"description": "Title", "project": "Folder", "tags": "Labels", "due": "Due Date", "status": "Done"}
+
+	opts := testOptions(t)
+	opts.Mapping = mapping
+	tasks, errs, err := Parse(models.ImportFormatCSV, data, opts)
+	assert.NoError(t, err)
+	assert.Len(t, tasks, 2)
+	assert.Equal(t, []models.ImportError{
+		{Line: 3, Message: "missing description"},
+		{Line: 5, Message: "invalid status 'maybe': expected pending, completed or deleted"},
+	}, errs)
+
+	assert.Equal(t, []string{"errands", "food"}, tasks[0].Task["tags"])
+	assert.Equal(t, "20250314T230000Z", tasks[0].Task["due"])
+	assert.Equal(t, "completed", tasks[1].Task["status"])
+	assert.Equal(t, "20250314T090000Z", tasks[1].Task["end"])
+	assert.Equal(t, "20250314T230000Z", tasks[1].Task["due"])
+
+	// without mapping, columns named after attributes are used
+	tasks, errs, err = Parse(models.ImportFormatCSV, "Description,Priority\nBuy milk,high\n", testOptions(t))
+	assert.NoError(t, err)
+	assert.Empty(t, errs)
+	assert.Equal(t, "H", tasks[0].Task["priority"])
+
+	_, _, err = Parse(models.ImportFormatCSV, "Title\nBuy milk\n", testOptions(t))
+	assert.EqualError(t, err, "no column is mapped to description")
+	opts.Mapping = map[string]string{"description": "Title", "color": "Title"}
+	_, _, err = Parse(models.ImportFormatCSV, "Title\nBuy milk\n", opts)
+	assert.Error(t, err)
+}
+
+func Test_ParseJSON(t *testing.T) {
+	data := `[
+		{"id": 3, "uuid": "AAAAAAAA-2222-3333-4444-555555555555", "description": "Water plants", "status": "pending", "entry": "20250301T000000Z", "urgency": 4.2, "estimate": "2h"},
+		{"description": "No UUID"},
+		{"uuid": "not-a-uuid", "description": "Broken"},
+		{"uuid": "bbbbbbbb-2222-3333-4444-555555555555"}
+	]`
+	tasks, errs, err := Parse(models.ImportFormatJSON, data, testOptions(t))
+	assert.NoError(t, err)
+	assert.Len(t, tasks, 2)
+	assert.Equal(t, []models.ImportError{
+		{Line: 3, Message: "invalid uuid 'not-a-uuid'"},
+		{Line: 4, Message: "missing description"},
+	}, errs)
+
+	assert.Equal(t, map[string]interface{}{
+		"uuid":        "aaaaaaaa-2222-3333-4444-555555555555",
+		"description": "Water plants",
+		"status":      "pending",
+		"entry":       "20250301T000000Z",
+		"estimate":    "2h",
+	}, tasks[0].Task)
+	assert.Equal(t, "pending", tasks[1].Task["status"])
+	assert.Equal(t, "20250314T090000Z", tasks[1].Task["entry"])
+
+	// one task per line, as older versions export
+	tasks, _, err = Parse(models.ImportFormatJSON, "{\"description\": \"a\"}\n{\"description\": \"b\"}\n", testOptions(t))
+	assert.NoError(t, err)
+	assert.Len(t, tasks, 2)
+
+	_, _, err = Parse(models.ImportFormatJSON, "[{", testOptions(t))
+	assert.Error(t, err)
+}
+
+func Test_MarkDuplicates(t *testing.T) {
+	existing := []models.Task{
+		{UUID: "aaaaaaaa-2222-3333-4444-555555555555", Description: "Water plants", Status: "pending"},
+		{UUID: "cccccccc-2222-3333-4444-555555555555", Description: "Old chore", Status: "deleted"},
+	}
+	tasks := []models.ImportedTask{
+		{Line: 1, Task: map[string]interface{}{"uuid": "aaaaaaaa-2222-3333-4444-555555555555", "description": "Renamed"}},
+		{Line: 2, Task: map[string]interface{}{"description": "water  PLANTS"}},
+		{Line: 3, Task: map[string]interface{}{"description": "Old chore"}},
+		{Line: 4, Task: map[string]interface{}{"description": "Old chore"}},
+		{Line: 5, Task: map[string]interface{}{"uuid": "dddddddd-2222-3333-4444-555555555555", "description": "Water plants"}},
+	}
+	MarkDuplicates(tasks, existing)
+
+	assert.Equal(t, models.DuplicateUUID, tasks[0].Duplicate)
+	assert.Equal(t, existing[0].UUID, tasks[0].DuplicateOf)
+	assert.Equal(t, models.DuplicateDescription, tasks[1].Duplicate)
+	assert.Equal(t, existing[0].UUID, tasks[1].DuplicateOf)
+	assert.Empty(t, tasks[2].Duplicate)
+	assert.Equal(t, models.DuplicateDescription, tasks[3].Duplicate)
+	assert.Empty(t, tasks[3].DuplicateOf)
+	assert.Empty(t, tasks[4].Duplicate)
+
+	preview := Preview(tasks, nil)
+	assert.Equal(t, 2, preview.Importable)
+	assert.Equal(t, 3, preview.Duplicates)
+	assert.NotNil(t, preview.Errors)
+}
@@ -0,0 +1,80 @@
+package importer
+
+import (
+	"bytes"
+	"ccsync_backend/models"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// computedAttributes are exported by Taskwarrior but not stored, so they are dropped
+var computedAttributes = []string{"id", "urgency"}
+
+// parseJSON reads the output of task export: an array of tasks, or one task per line
+// as older versions wrote it. Tasks keep every attribute, UDAs included.
+func parseJSON(data string, opts Options) ([]models.ImportedTask, []models.ImportError, error) {
+	var raw []map[string]interface{}
+	trimmed := strings.TrimSpace(data)
+	if strings.HasPrefix(trimmed, "[") {
+		if err := json.Unmarshal([]byte(trimmed), &raw); err != nil {
+			return nil, nil, fmt.Errorf("invalid JSON: %v", err)
+		}
+	} else {
+		decoder := json.NewDecoder(bytes.NewBufferString(trimmed))
+		for {
+			var task map[string]interface{}
+			err := decoder.Decode(&task)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, nil, fmt.Errorf("invalid JSON: %v", err)
+			}
+			raw = append(raw, task)
+		}
+	}
+
+	var tasks []models.ImportedTask
+	var errs []models.ImportError
+	for i, task := range raw {
+		if err := normalizeJSONTask(task, opts); err != nil {
+			errs = append(errs, models.ImportError{Line: i + 1, Message: err.Error()})
+			continue
+		}
+		tasks = append(tasks, models.ImportedTask{Line: i + 1, Task: task})
+	}
+	return tasks, errs, nil
+}
+
+func normalizeJSONTask(task map[string]interface{}, opts Options) error {
+	if task == nil {
+		return fmt.Errorf("not a task")
+	}
+	description, _ := task["description"].(string)
+	if strings.TrimSpace(description) == "" {
+		return fmt.Errorf("missing description")
+	}
+	for _, attribute := range computedAttributes {
+		delete(task, attribute)
+	}
+
+	if value, ok := task["uuid"]; ok {
+		text, _ := value.(string)
+		parsed, err := uuid.Parse(text)
+		if err != nil {
+			return fmt.Errorf("invalid uuid '%v'", value)
+		}
+		task["uuid"] = parsed.String()
+	}
+	defaults := newTask(description, opts)
+	for _, attribute := range []string{"status", "entry"} {
+		if _, ok := task[attribute]; !ok {
+			task[attribute] = defaults[attribute]
+		}
+	}
+	return nil
+}
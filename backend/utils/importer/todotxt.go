@@ -0,0 +1,125 @@
+package importer
+
+import (
+	"ccsync_backend/models"
+	"ccsync_backend/utils"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var todoTxtPriorityPattern = regexp.MustCompile(`^\(([A-Z])\)$`)
+
+// todoTxtPriorities maps todo.txt priorities to Taskwarrior ones; (D) and below are low
+var todoTxtPriorities = map[string]string{"A": "H", "B": "M", "C": "L"}
+
+// parseTodoTxt reads one task per non-empty line:
+//
+//	x 2025-03-14 2025-03-01 (A) Call mom +Family @phone due:2025-03-20
+//
+// The first +project is the project and any further ones, like @contexts, become
+// tags. due: sets the due date and t: (threshold) the wait date; other key:value
+// pairs are kept in the description.
+func parseTodoTxt(data string, opts Options) ([]models.ImportedTask, []models.ImportError) {
+	var tasks []models.ImportedTask
+	var errs []models.ImportError
+	for i, line := range strings.Split(strings.ReplaceAll(data, "\r\n", "\n"), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		task, err := parseTodoTxtLine(line, opts)
+		if err != nil {
+			errs = append(errs, models.ImportError{Line: i + 1, Message: err.Error()})
+			continue
+		}
+		tasks = append(tasks, models.ImportedTask{Line: i + 1, Task: task})
+	}
+	return tasks, errs
+}
+
+func parseTodoTxtLine(line string, opts Options) (map[string]interface{}, error) {
+	words := strings.Fields(line)
+	task := newTask("", opts)
+
+	if words[0] == "x" {
+		task["status"] = "completed"
+		task["end"] = task["entry"]
+		words = words[1:]
+		if len(words) > 0 {
+			if end, ok := todoTxtDate(words[0], opts); ok {
+				task["end"] = end
+				words = words[1:]
+			}
+		}
+	}
+	if len(words) > 0 {
+		if match := todoTxtPriorityPattern.FindStringSubmatch(words[0]); match != nil {
+			task["priority"] = todoTxtPriority(match[1])
+			words = words[1:]
+		}
+	}
+	if len(words) > 0 {
+		if entry, ok := todoTxtDate(words[0], opts); ok {
+			task["entry"] = entry
+			words = words[1:]
+		}
+	}
+
+	var description, tags []string
+	for _, word := range words {
+		key, value, isPair := strings.Cut(word, ":")
+		switch {
+		case len(word) > 1 && word[0] == '+':
+			if _, ok := task["project"]; !ok {
+				task["project"] = word[1:]
+			} else if validTag(word[1:]) {
+				tags = append(tags, word[1:])
+			}
+		case len(word) > 1 && word[0] == '@':
+			if validTag(word[1:]) {
+				tags = append(tags, word[1:])
+			}
+		case isPair && (key == "due" || key == "t") && value != "":
+			date, ok := todoTxtDate(value, opts)
+			if !ok {
+				return nil, fmt.Errorf("invalid %s date '%s': expected YYYY-MM-DD", key, value)
+			}
+			if key == "due" {
+				task["due"] = date
+			} else {
+				task["wait"] = date
+			}
+		case isPair && key == "pri" && len(value) == 1 && value[0] >= 'A' && value[0] <= 'Z':
+			// priorities of completed tasks, which lose their (A), are often kept this way
+			task["priority"] = todoTxtPriority(value)
+		default:
+			description = append(description, word)
+		}
+	}
+
+	if len(description) == 0 {
+		return nil, fmt.Errorf("missing description")
+	}
+	task["description"] = strings.Join(description, " ")
+	if len(tags) > 0 {
+		task["tags"] = tags
+	}
+	return task, nil
+}
+
+func todoTxtPriority(letter string) string {
+	if priority, ok := todoTxtPriorities[letter]; ok {
+		return priority
+	}
+	return "L"
+}
+
+// todoTxtDate reads a YYYY-MM-DD date as the start of that day in the user's timezone
+func todoTxtDate(value string, opts Options) (string, bool) {
+	date, err := time.ParseInLocation("2006-01-02", value, opts.Now.Location())
+	if err != nil {
+		return "", false
+	}
+	return date.UTC().Format(utils.TaskwarriorDateLayout), true
+}
@@ -0,0 +1,90 @@
+package query
+
+import (
+	"ccsync_backend/models"
+	"ccsync_backend/utils"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Changes are the tasks created, modified or deleted after a changes cursor
+type Changes struct {
+	Created  []models.Task `json:"created"`
+	Modified []models.Task `json:"modified"`
+	Deleted  []models.Task `json:"deleted"`
+	Cursor   string        `json:"cursor"`
+}
+
+// changesCursor marks a position in the replica's modification timeline. Taskwarrior
+// timestamps have one-second resolution, so the UUIDs already reported for the
+// cursor's second are kept to report later changes within that same second.
+type changesCursor struct {
+	Modified int64    `json:"m"`
+	Seen     []string `json:"u,omitempty"`
+}
+
+// ChangesSince lists the tasks changed after since, an opaque cursor returned by a
+// previous call; an empty cursor reports every task as created. Tasks purged from
+// the replica cannot be reported, as nothing is left of them to compare.
+func ChangesSince(tasks []models.Task, since string) (Changes, error) {
+	var from changesCursor
+	if since != "" {
+		data, err := base64.RawURLEncoding.DecodeString(since)
+		if err != nil || json.Unmarshal(data, &from) != nil {
+			return Changes{}, fmt.Errorf("invalid 'since' cursor")
+		}
+	}
+	seen := make(map[string]bool, len(from.Seen))
+	for _, uuid := range from.Seen {
+		seen[uuid] = true
+	}
+
+	changes := Changes{
+		Created:  []models.Task{},
+		Modified: []models.Task{},
+		Deleted:  []models.Task{},
+	}
+	next := from
+	next.Seen = append([]string(nil), from.Seen...)
+
+	for _, task := range tasks {
+		modified := unixSeconds(task.Modified, task.Entry)
+		if modified < from.Modified || (modified == from.Modified && seen[task.UUID]) {
+			continue
+		}
+
+		switch {
+		case task.Status == "deleted":
+			changes.Deleted = append(changes.Deleted, task)
+		case since == "" || unixSeconds(task.Entry, "") >= from.Modified:
+			changes.Created = append(changes.Created, task)
+		default:
+			changes.Modified = append(changes.Modified, task)
+		}
+
+		if modified > next.Modified {
+			next = changesCursor{Modified: modified}
+		}
+		if modified == next.Modified {
+			next.Seen = append(next.Seen, task.UUID)
+		}
+	}
+
+	data, _ := json.Marshal(next)
+	changes.Cursor = base64.RawURLEncoding.EncodeToString(data)
+	return changes, nil
+}
+
+// unixSeconds parses a Taskwarrior timestamp, falling back to a second one when empty
+func unixSeconds(value, fallback string) int64 {
+	if value == "" {
+		value = fallback
+	}
+	parsed, err := time.Parse(utils.TaskwarriorDateLayout, value)
+	if err != nil {
+		return 0
+	}
+	return parsed.Unix()
+}
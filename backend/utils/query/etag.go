@@ -0,0 +1,37 @@
+package query
+
+import (
+	"ccsync_backend/models"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// ETag fingerprints a task list from the replica state of its tasks, in order: every
+// change made through Taskwarrior bumps a task's modified timestamp. Urgency is
+// included because it drifts with age without touching modified. The variant
+// (e.g. the pagination headers) distinguishes responses built from the same tasks.
+func ETag(tasks []models.Task, variant string) string {
+	hash := sha256.New()
+	hash.Write([]byte(variant))
+	for _, task := range tasks {
+		fmt.Fprintf(hash, "\n%s %s %s %g", task.UUID, task.Modified, task.Status, task.Urgency)
+	}
+	return `"` + hex.EncodeToString(hash.Sum(nil))[:32] + `"`
+}
+
+// MatchesETag reports whether an If-None-Match header value covers etag,
+// using the weak comparison HTTP specifies for conditional GETs
+func MatchesETag(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
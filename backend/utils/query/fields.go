@@ -0,0 +1,44 @@
+package query
+
+import (
+	"ccsync_backend/models"
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// taskFields lists the JSON names of models.Task accepted by fields=
+var taskFields = func() map[string]bool {
+	fields := make(map[string]bool)
+	taskType := reflect.TypeOf(models.Task{})
+	for i := 0; i < taskType.NumField(); i++ {
+		name := strings.Split(taskType.Field(i).Tag.Get("json"), ",")[0]
+		if name != "" && name != "-" {
+			fields[name] = true
+		}
+	}
+	return fields
+}()
+
+// Project trims each task down to the requested fields. The uuid is always kept
+// so that clients can address the task afterwards.
+func Project(tasks []models.Task, fields []string) ([]map[string]interface{}, error) {
+	projected := make([]map[string]interface{}, 0, len(tasks))
+	for _, task := range tasks {
+		data, err := json.Marshal(task)
+		if err != nil {
+			return nil, err
+		}
+		var full map[string]interface{}
+		if err := json.Unmarshal(data, &full); err != nil {
+			return nil, err
+		}
+
+		sparse := map[string]interface{}{"uuid": task.UUID}
+		for _, field := range fields {
+			sparse[field] = full[field]
+		}
+		projected = append(projected, sparse)
+	}
+	return projected, nil
+}
@@ -0,0 +1,273 @@
+package query
+
+import (
+	"ccsync_backend/models"
+	"ccsync_backend/utils"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// MaxLimit caps the page size a client can request
+	MaxLimit = 500
+
+	// defaultSort orders pages when the client paginates without choosing a sort
+	defaultSort = "entry"
+)
+
+// sortable fields, with the direction used when no +/- prefix is given
+var sortFields = map[string]bool{
+	"urgency":  false,
+	"due":      true,
+	"modified": true,
+	"entry":    true,
+	"project":  true,
+}
+
+// SortField is one key of a multi-field sort
+type SortField struct {
+	Name      string
+	Ascending bool
+}
+
+// Options controls how a task list is sorted, paged and trimmed
+type Options struct {
+	Sort          []SortField
+	Limit         int // 0 returns every task
+	Cursor        string
+	Fields        []string
+	IncludeClosed bool
+}
+
+// Page is one slice of a sorted task list
+type Page struct {
+	Tasks      []models.Task
+	NextCursor string // empty on the last page
+	Total      int    // tasks across all pages
+}
+
+// ParseOptions reads sort, limit, cursor, fields and includeClosed from the query string.
+// Completed and deleted tasks are excluded by default when paginating (limit or cursor
+// given) and included otherwise, so existing full-list clients keep their behaviour. They
+// are always included when the task filter names a status, which would otherwise match
+// nothing but pending tasks.
+func ParseOptions(values url.Values, namesStatus bool) (Options, error) {
+	var opts Options
+
+	if raw := values.Get("sort"); raw != "" {
+		for _, part := range strings.Split(raw, ",") {
+			part = strings.TrimSpace(part)
+			name := strings.TrimLeft(part, "+-")
+			ascending, ok := sortFields[strings.ToLower(name)]
+			if !ok {
+				return Options{}, fmt.Errorf("cannot sort by '%s'", name)
+			}
+			if strings.HasPrefix(part, "-") {
+				ascending = false
+			} else if strings.HasPrefix(part, "+") {
+				ascending = true
+			}
+			opts.Sort = append(opts.Sort, SortField{Name: strings.ToLower(name), Ascending: ascending})
+		}
+	}
+
+	if raw := values.Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 {
+			return Options{}, fmt.Errorf("invalid 'limit' parameter")
+		}
+		if limit > MaxLimit {
+			limit = MaxLimit
+		}
+		opts.Limit = limit
+	}
+
+	opts.Cursor = values.Get("cursor")
+	paginated := opts.Limit > 0 || opts.Cursor != ""
+	if paginated && len(opts.Sort) == 0 {
+		opts.Sort = []SortField{{Name: defaultSort, Ascending: sortFields[defaultSort]}}
+	}
+
+	opts.IncludeClosed = !paginated || namesStatus
+	if raw := values.Get("includeClosed"); raw != "" {
+		include, err := strconv.ParseBool(raw)
+		if err != nil {
+			return Options{}, fmt.Errorf("invalid 'includeClosed' parameter")
+		}
+		opts.IncludeClosed = include
+	}
+
+	if raw := values.Get("fields"); raw != "" {
+		for _, field := range strings.Split(raw, ",") {
+			field = strings.TrimSpace(field)
+			if !taskFields[field] {
+				return Options{}, fmt.Errorf("unknown field '%s'", field)
+			}
+			opts.Fields = append(opts.Fields, field)
+		}
+	}
+
+	return opts, nil
+}
+
+// Apply filters out closed tasks if requested, sorts, and cuts the page after the cursor
+func Apply(tasks []models.Task, opts Options) (Page, error) {
+	selected := make([]models.Task, 0, len(tasks))
+	for _, task := range tasks {
+		if !opts.IncludeClosed && (task.Status == "completed" || task.Status == "deleted") {
+			continue
+		}
+		selected = append(selected, task)
+	}
+
+	if len(opts.Sort) > 0 {
+		sort.SliceStable(selected, func(i, j int) bool {
+			return compareKeys(sortKey(selected[i], opts.Sort), sortKey(selected[j], opts.Sort), opts.Sort) < 0
+		})
+	}
+
+	page := Page{Total: len(selected)}
+
+	start := 0
+	if opts.Cursor != "" {
+		after, err := decodeCursor(opts.Cursor, opts.Sort)
+		if err != nil {
+			return Page{}, err
+		}
+		// keyset pagination: resume after the last task of the previous page, which
+		// stays correct when tasks before it are added or removed in between
+		start = sort.Search(len(selected), func(i int) bool {
+			return compareKeys(sortKey(selected[i], opts.Sort), after, opts.Sort) > 0
+		})
+	}
+
+	end := len(selected)
+	if opts.Limit > 0 && start+opts.Limit < end {
+		end = start + opts.Limit
+		page.NextCursor = encodeCursor(sortKey(selected[end-1], opts.Sort), opts.Sort)
+	}
+	page.Tasks = selected[start:end]
+
+	return page, nil
+}
+
+// keyValue is one component of a sort key; missing values always sort last
+type keyValue struct {
+	Present bool    `json:"p"`
+	Number  float64 `json:"n,omitempty"`
+	Text    string  `json:"t,omitempty"`
+}
+
+// sortKey holds the values of the sort fields followed by the UUID as a tie-breaker
+func sortKey(task models.Task, fields []SortField) []keyValue {
+	key := make([]keyValue, 0, len(fields)+1)
+	for _, field := range fields {
+		switch field.Name {
+		case "urgency":
+			key = append(key, keyValue{Present: true, Number: float64(task.Urgency)})
+		case "project":
+			key = append(key, keyValue{Present: task.Project != "", Text: strings.ToLower(task.Project)})
+		default:
+			key = append(key, dateKey(taskDate(task, field.Name)))
+		}
+	}
+	return append(key, keyValue{Present: true, Text: task.UUID})
+}
+
+func taskDate(task models.Task, field string) string {
+	switch field {
+	case "due":
+		return task.Due
+	case "modified":
+		return task.Modified
+	default:
+		return task.Entry
+	}
+}
+
+func dateKey(value string) keyValue {
+	parsed, err := time.Parse(utils.TaskwarriorDateLayout, value)
+	if err != nil {
+		return keyValue{}
+	}
+	return keyValue{Present: true, Number: float64(parsed.Unix())}
+}
+
+func compareKeys(a, b []keyValue, fields []SortField) int {
+	for i := range a {
+		if i >= len(b) {
+			return 1
+		}
+		if a[i].Present != b[i].Present {
+			if a[i].Present {
+				return -1
+			}
+			return 1
+		}
+
+		result := 0
+		switch {
+		case a[i].Number < b[i].Number:
+			result = -1
+		case a[i].Number > b[i].Number:
+			result = 1
+		default:
+			result = strings.Compare(a[i].Text, b[i].Text)
+		}
+		if result == 0 {
+			continue
+		}
+		// the trailing UUID tie-breaker is always ascending
+		if i < len(fields) && !fields[i].Ascending {
+			result = -result
+		}
+		return result
+	}
+	if len(a) < len(b) {
+		return -1
+	}
+	return 0
+}
+
+type cursor struct {
+	Sort string     `json:"s"`
+	Key  []keyValue `json:"k"`
+}
+
+func sortSignature(fields []SortField) string {
+	parts := make([]string, 0, len(fields))
+	for _, field := range fields {
+		direction := "+"
+		if !field.Ascending {
+			direction = "-"
+		}
+		parts = append(parts, direction+field.Name)
+	}
+	return strings.Join(parts, ",")
+}
+
+func encodeCursor(key []keyValue, fields []SortField) string {
+	data, _ := json.Marshal(cursor{Sort: sortSignature(fields), Key: key})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(value string, fields []SortField) ([]keyValue, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	var decoded cursor
+	if err := json.Unmarshal(data, &decoded); err != nil || len(decoded.Key) != len(fields)+1 {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	if decoded.Sort != sortSignature(fields) {
+		return nil, fmt.Errorf("cursor was issued for a different sort order")
+	}
+	return decoded.Key, nil
+}
@@ -0,0 +1,234 @@
+package query
+
+import (
+	"ccsync_backend/models"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func queryTasks() []models.Task {
+	return []models.Task{
+		{UUID: "a", Description: "a", Status: "pending", Urgency: 3, Due: "20250310T000000Z", Entry: "20250101T000000Z", Project: "work"},
+		{UUID: "b", Description: "b", Status: "completed", Urgency: 0, Entry: "20250102T000000Z"},
+		{UUID: "c", Description: "c", Status: "pending", Urgency: 8, Due: "20250305T000000Z", Entry: "20250103T000000Z", Project: "Home"},
+		{UUID: "d", Description: "d", Status: "pending", Urgency: 3, Entry: "20250104T000000Z"},
+		{UUID: "e", Description: "e", Status: "deleted", Urgency: 1, Entry: "20250105T000000Z", Project: "home"},
+	}
+}
+
+func uuids(tasks []models.Task) []string {
+	result := []string{}
+	for _, task := range tasks {
+		result = append(result, task.UUID)
+	}
+	return result
+}
+
+func parse(t *testing.T, raw string) Options {
+	t.Helper()
+	values, err := url.ParseQuery(raw)
+	require.NoError(t, err)
+	opts, err := ParseOptions(values, false)
+	require.NoError(t, err)
+	return opts
+}
+
+func Test_ParseOptions_Defaults(t *testing.T) {
+	opts := parse(t, "")
+	assert.Empty(t, opts.Sort)
+	assert.Zero(t, opts.Limit)
+	assert.True(t, opts.IncludeClosed)
+
+	opts = parse(t, "limit=2")
+	assert.False(t, opts.IncludeClosed)
+	assert.Equal(t, []SortField{{Name: "entry", Ascending: true}}, opts.Sort)
+
+	opts = parse(t, "limit=2&includeClosed=true")
+	assert.True(t, opts.IncludeClosed)
+
+	// a filter on status:completed pages through completed tasks
+	values, _ := url.ParseQuery("limit=2")
+	opts, err := ParseOptions(values, true)
+	require.NoError(t, err)
+	assert.True(t, opts.IncludeClosed)
+	page, err := Apply([]models.Task{{UUID: "a", Status: "completed", Entry: "20240101T000000Z"}}, opts)
+	require.NoError(t, err)
+	assert.Len(t, page.Tasks, 1)
+
+	values, _ = url.ParseQuery("limit=2&includeClosed=false")
+	opts, err = ParseOptions(values, true)
+	require.NoError(t, err)
+	assert.False(t, opts.IncludeClosed)
+
+	opts = parse(t, "limit=100000")
+	assert.Equal(t, MaxLimit, opts.Limit)
+}
+
+func Test_ParseOptions_Sort(t *testing.T) {
+	opts := parse(t, "sort=urgency,-due,%2Bproject")
+	assert.Equal(t, []SortField{
+		{Name: "urgency", Ascending: false},
+		{Name: "due", Ascending: false},
+		{Name: "project", Ascending: true},
+	}, opts.Sort)
+}
+
+func Test_ParseOptions_Invalid(t *testing.T) {
+	for _, raw := range []string{"sort=colour", "limit=0", "limit=abc", "includeClosed=maybe", "fields=description,secret"} {
+		values, _ := url.ParseQuery(raw)
+		_, err := ParseOptions(values, false)
+		assert.Error(t, err, raw)
+	}
+}
+
+func Test_Apply_Sort(t *testing.T) {
+	page, err := Apply(queryTasks(), parse(t, "sort=urgency"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"c", "a", "d", "e", "b"}, uuids(page.Tasks))
+
+	// tasks without a due date sort last in either direction
+	page, err = Apply(queryTasks(), parse(t, "sort=due"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"c", "a", "b", "d", "e"}, uuids(page.Tasks))
+
+	page, err = Apply(queryTasks(), parse(t, "sort=-due"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "c", "b", "d", "e"}, uuids(page.Tasks))
+
+	page, err = Apply(queryTasks(), parse(t, "sort=project,-entry"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"e", "c", "a", "d", "b"}, uuids(page.Tasks))
+}
+
+func Test_Apply_UnsortedKeepsOrder(t *testing.T) {
+	page, err := Apply(queryTasks(), parse(t, ""))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "c", "d", "e"}, uuids(page.Tasks))
+	assert.Equal(t, 5, page.Total)
+	assert.Empty(t, page.NextCursor)
+}
+
+func Test_Apply_Pagination(t *testing.T) {
+	opts := parse(t, "sort=-urgency&limit=2&includeClosed=true")
+
+	var seen []string
+	for pages := 0; ; pages++ {
+		require.Less(t, pages, 5, "pagination did not terminate")
+		page, err := Apply(queryTasks(), opts)
+		require.NoError(t, err)
+		assert.Equal(t, 5, page.Total)
+		seen = append(seen, uuids(page.Tasks)...)
+		if page.NextCursor == "" {
+			break
+		}
+		opts.Cursor = page.NextCursor
+	}
+	assert.Equal(t, []string{"c", "a", "d", "e", "b"}, seen)
+}
+
+func Test_Apply_CursorSurvivesChanges(t *testing.T) {
+	opts := parse(t, "limit=2")
+	page, err := Apply(queryTasks(), opts)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "c"}, uuids(page.Tasks))
+	assert.Equal(t, 3, page.Total)
+
+	// a task before the cursor disappears and a newer one is added
+	tasks := queryTasks()[1:]
+	tasks = append(tasks, models.Task{UUID: "f", Status: "pending", Entry: "20250106T000000Z"})
+
+	opts.Cursor = page.NextCursor
+	page, err = Apply(tasks, opts)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"d", "f"}, uuids(page.Tasks))
+	assert.Empty(t, page.NextCursor)
+}
+
+func Test_Apply_CursorErrors(t *testing.T) {
+	opts := parse(t, "limit=1&sort=due")
+	page, err := Apply(queryTasks(), opts)
+	require.NoError(t, err)
+
+	mismatched := parse(t, "limit=1&sort=urgency")
+	mismatched.Cursor = page.NextCursor
+	_, err = Apply(queryTasks(), mismatched)
+	assert.Error(t, err)
+
+	opts.Cursor = "not a cursor"
+	_, err = Apply(queryTasks(), opts)
+	assert.Error(t, err)
+}
+
+func Test_Project(t *testing.T) {
+	projected, err := Project(queryTasks()[:1], []string{"description", "due"})
+	require.NoError(t, err)
+	assert.Equal(t, []map[string]interface{}{
+		{"uuid": "a", "description": "a", "due": "20250310T000000Z"},
+	}, projected)
+}
+
+func Test_ETag(t *testing.T) {
+	tasks := queryTasks()
+	etag := ETag(tasks, "limit=2")
+	assert.Equal(t, etag, ETag(queryTasks(), "limit=2"))
+	assert.NotEqual(t, etag, ETag(tasks, "limit=3"))
+
+	tasks[0].Modified = "20250320T000000Z"
+	assert.NotEqual(t, etag, ETag(tasks, "limit=2"))
+
+	assert.NotEqual(t, etag, ETag(queryTasks()[1:], "limit=2"))
+}
+
+func Test_MatchesETag(t *testing.T) {
+	assert.True(t, MatchesETag(`"abc"`, `"abc"`))
+	assert.True(t, MatchesETag(`W/"abc"`, `"abc"`))
+	assert.True(t, MatchesETag(`"xyz", "abc"`, `"abc"`))
+	assert.True(t, MatchesETag(`*`, `"abc"`))
+	assert.False(t, MatchesETag(``, `"abc"`))
+	assert.False(t, MatchesETag(`"abd"`, `"abc"`))
+}
+
+func Test_ChangesSince(t *testing.T) {
+	tasks := []models.Task{
+		{UUID: "a", Status: "pending", Entry: "20250101T000000Z", Modified: "20250101T000000Z"},
+		{UUID: "b", Status: "pending", Entry: "20250101T000000Z", Modified: "20250102T000000Z"},
+	}
+
+	initial, err := ChangesSince(tasks, "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, uuids(initial.Created))
+	assert.Empty(t, initial.Modified)
+
+	unchanged, err := ChangesSince(tasks, initial.Cursor)
+	require.NoError(t, err)
+	assert.Empty(t, unchanged.Created)
+	assert.Empty(t, unchanged.Modified)
+	assert.Empty(t, unchanged.Deleted)
+	assert.Equal(t, initial.Cursor, unchanged.Cursor)
+
+	// a change in the same second as the cursor is still reported
+	tasks[0].Modified = "20250102T000000Z"
+	tasks = append(tasks,
+		models.Task{UUID: "c", Status: "pending", Entry: "20250103T000000Z", Modified: "20250103T000000Z"},
+		models.Task{UUID: "d", Status: "deleted", Entry: "20241201T000000Z", Modified: "20250103T000000Z"},
+	)
+	changes, err := ChangesSince(tasks, unchanged.Cursor)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"c"}, uuids(changes.Created))
+	assert.Equal(t, []string{"a"}, uuids(changes.Modified))
+	assert.Equal(t, []string{"d"}, uuids(changes.Deleted))
+
+	after, err := ChangesSince(tasks, changes.Cursor)
+	require.NoError(t, err)
+	assert.Empty(t, after.Created)
+	assert.Empty(t, after.Modified)
+	assert.Empty(t, after.Deleted)
+}
+
+func Test_ChangesSince_InvalidCursor(t *testing.T) {
+	_, err := ChangesSince(nil, "%%%")
+	assert.Error(t, err)
+}
@@ -0,0 +1,203 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Period is a calendar-aware duration, as used by Taskwarrior recurrences and date arithmetic.
+// Years, months and days are applied on the calendar so that e.g. monthly steps keep the day of month.
+type Period struct {
+	Years    int
+	Months   int
+	Days     int
+	Duration time.Duration
+	Weekdays bool // step over business days only, as for recur:weekdays
+}
+
+// named periods accepted by Taskwarrior
+var namedPeriods = map[string]Period{
+	"annual":     {Years: 1},
+	"yearly":     {Years: 1},
+	"biannual":   {Years: 2},
+	"biyearly":   {Years: 2},
+	"semiannual": {Months: 6},
+	"quarterly":  {Months: 3},
+	"bimonthly":  {Months: 2},
+	"monthly":    {Months: 1},
+	"fortnight":  {Days: 14},
+	"biweekly":   {Days: 14},
+	"sennight":   {Days: 7},
+	"weekly":     {Days: 7},
+	"daily":      {Days: 1},
+	"hourly":     {Duration: time.Hour},
+	"weekdays":   {Days: 1, Weekdays: true},
+}
+
+// unit spellings accepted after a number, e.g. 3d, 2wks, 1mo
+var periodUnits = map[string]Period{
+	"s": {Duration: time.Second}, "sec": {Duration: time.Second}, "secs": {Duration: time.Second},
+	"second": {Duration: time.Second}, "seconds": {Duration: time.Second},
+	"min": {Duration: time.Minute}, "mins": {Duration: time.Minute},
+	"minute": {Duration: time.Minute}, "minutes": {Duration: time.Minute},
+	"h": {Duration: time.Hour}, "hr": {Duration: time.Hour}, "hrs": {Duration: time.Hour},
+	"hour": {Duration: time.Hour}, "hours": {Duration: time.Hour},
+	"d": {Days: 1}, "day": {Days: 1}, "days": {Days: 1},
+	"w": {Days: 7}, "wk": {Days: 7}, "wks": {Days: 7}, "week": {Days: 7}, "weeks": {Days: 7},
+	"mo": {Months: 1}, "mos": {Months: 1}, "mth": {Months: 1}, "mths": {Months: 1},
+	"month": {Months: 1}, "months": {Months: 1},
+	"q": {Months: 3}, "qtr": {Months: 3}, "qtrs": {Months: 3}, "quarter": {Months: 3}, "quarters": {Months: 3},
+	"y": {Years: 1}, "yr": {Years: 1}, "yrs": {Years: 1}, "year": {Years: 1}, "years": {Years: 1},
+}
+
+var (
+	numericPeriodPattern = regexp.MustCompile(`^(\d+)\s*([a-z]+)$`)
+	isoPeriodPattern     = regexp.MustCompile(`^p(?:(\d+)y)?(?:(\d+)m)?(?:(\d+)w)?(?:(\d+)d)?(?:t(?:(\d+)h)?(?:(\d+)m)?(?:(\d+)s)?)?$`)
+)
+
+// ParsePeriod parses a Taskwarrior duration such as "weekly", "3d", "2wks", "quarterly" or "P1M"
+func ParsePeriod(value string) (Period, error) {
+	normalized := strings.ToLower(strings.TrimSpace(value))
+	if normalized == "" {
+		return Period{}, fmt.Errorf("empty duration")
+	}
+
+	if period, ok := namedPeriods[normalized]; ok {
+		return period, nil
+	}
+	if period, ok := periodUnits[normalized]; ok {
+		return period, nil
+	}
+
+	if matches := numericPeriodPattern.FindStringSubmatch(normalized); matches != nil {
+		unit, ok := periodUnits[matches[2]]
+		if !ok {
+			return Period{}, fmt.Errorf("unknown duration unit '%s'", matches[2])
+		}
+		count, err := strconv.Atoi(matches[1])
+		if err != nil {
+			return Period{}, fmt.Errorf("invalid duration '%s': %v", value, err)
+		}
+		return unit.Times(count), nil
+	}
+
+	if normalized != "p" && normalized != "pt" {
+		if matches := isoPeriodPattern.FindStringSubmatch(normalized); matches != nil {
+			field := func(i int) int {
+				n, _ := strconv.Atoi(matches[i])
+				return n
+			}
+			return Period{
+				Years:  field(1),
+				Months: field(2),
+				Days:   field(3)*7 + field(4),
+				Duration: time.Duration(field(5))*time.Hour +
+					time.Duration(field(6))*time.Minute +
+					time.Duration(field(7))*time.Second,
+			}, nil
+		}
+	}
+
+	return Period{}, fmt.Errorf("unable to parse duration '%s'", value)
+}
+
+// ParseSignedPeriod parses a duration with an optional leading sign, such as "+3d" or
+// "-1wk", and returns the period with the direction to apply it in (1 or -1)
+func ParseSignedPeriod(value string) (Period, int, error) {
+	trimmed := strings.TrimSpace(value)
+	sign := 1
+	if strings.HasPrefix(trimmed, "+") {
+		trimmed = trimmed[1:]
+	} else if strings.HasPrefix(trimmed, "-") {
+		sign, trimmed = -1, trimmed[1:]
+	}
+	period, err := ParsePeriod(trimmed)
+	if err != nil {
+		return Period{}, 0, err
+	}
+	return period, sign, nil
+}
+
+// Times scales the period by n
+func (p Period) Times(n int) Period {
+	return Period{
+		Years:    p.Years * n,
+		Months:   p.Months * n,
+		Days:     p.Days * n,
+		Duration: p.Duration * time.Duration(n),
+		Weekdays: p.Weekdays,
+	}
+}
+
+// IsZero reports whether the period has no length
+func (p Period) IsZero() bool {
+	return p.Years == 0 && p.Months == 0 && p.Days == 0 && p.Duration == 0
+}
+
+// AddTo advances t by n periods. Month and year steps are computed from t itself and
+// clamp to the end of shorter months, so Jan 31 + 1 month is the last day of February.
+func (p Period) AddTo(t time.Time, n int) time.Time {
+	if p.Weekdays {
+		return addWeekdays(t, p.Days*n)
+	}
+
+	result := t
+	if p.Years != 0 || p.Months != 0 {
+		result = addMonthsClamped(result, (p.Years*12+p.Months)*n)
+	}
+	if p.Days != 0 {
+		result = result.AddDate(0, 0, p.Days*n)
+	}
+	return result.Add(p.Duration * time.Duration(n))
+}
+
+func addMonthsClamped(t time.Time, months int) time.Time {
+	year, month, day := t.Date()
+	firstOfTarget := time.Date(year, month+time.Month(months), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	lastDay := firstOfTarget.AddDate(0, 1, -1).Day()
+	if day > lastDay {
+		day = lastDay
+	}
+	return firstOfTarget.AddDate(0, 0, day-1)
+}
+
+func addWeekdays(t time.Time, days int) time.Time {
+	step := 1
+	if days < 0 {
+		step, days = -1, -days
+	}
+	result := t
+	for days > 0 {
+		result = result.AddDate(0, 0, step)
+		if result.Weekday() != time.Saturday && result.Weekday() != time.Sunday {
+			days--
+		}
+	}
+	return result
+}
+
+// NextRecurrences lists up to count due dates of a recurring series, starting with the
+// instance at index start (the number of instances already generated). Dates after until,
+// when set, end the series.
+func NextRecurrences(due time.Time, recur string, start, count int, until *time.Time) ([]time.Time, error) {
+	period, err := ParsePeriod(recur)
+	if err != nil {
+		return nil, err
+	}
+	if period.IsZero() {
+		return nil, fmt.Errorf("recurrence period '%s' has no length", recur)
+	}
+
+	dates := []time.Time{}
+	for i := start; len(dates) < count; i++ {
+		next := period.AddTo(due, i)
+		if until != nil && next.After(*until) {
+			break
+		}
+		dates = append(dates, next)
+	}
+	return dates, nil
+}
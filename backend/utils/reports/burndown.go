@@ -0,0 +1,64 @@
+package reports
+
+import (
+	"ccsync_backend/models"
+	"time"
+)
+
+// BurndownPoint is the state of the task list at the end of a period, or now for the
+// current period
+type BurndownPoint struct {
+	Bucket
+	Pending int `json:"pending"`
+	// Started counts the pending tasks that were active, part of Pending
+	Started int `json:"started"`
+	Done    int `json:"done"`
+}
+
+// Burndown is a series of pending and done counts, like `task burndown`
+type Burndown struct {
+	Period Period          `json:"period"`
+	Points []BurndownPoint `json:"points"`
+}
+
+// BuildBurndown replays the tasks' entry and end dates to count, at the end of each
+// bucket, the tasks that were pending, active and done. Deleted tasks count as pending
+// until they were deleted. Only the latest start of a task is known, so a task counts
+// as started from then until it ends.
+func BuildBurndown(tasks []models.Task, buckets []Bucket, period Period, now time.Time) Burndown {
+	report := Burndown{Period: period, Points: make([]BurndownPoint, len(buckets))}
+	for i, bucket := range buckets {
+		report.Points[i].Bucket = bucket
+	}
+
+	for _, task := range tasks {
+		entry, ok := parseTaskDate(task.Entry)
+		if !ok || task.Status == "recurring" {
+			continue
+		}
+		end, ended := parseTaskDate(task.End)
+		ended = ended && (task.Status == "completed" || task.Status == "deleted")
+		start, started := parseTaskDate(task.Start)
+
+		for i, bucket := range buckets {
+			at := bucket.End
+			if at.After(now) {
+				at = now
+			}
+			point := &report.Points[i]
+			switch {
+			case entry.After(at):
+			case ended && !end.After(at):
+				if task.Status == "completed" {
+					point.Done++
+				}
+			default:
+				point.Pending++
+				if started && !start.After(at) {
+					point.Started++
+				}
+			}
+		}
+	}
+	return report
+}
@@ -0,0 +1,76 @@
+package reports
+
+import (
+	"ccsync_backend/models"
+)
+
+// HistoryPoint counts what happened to tasks during a period
+type HistoryPoint struct {
+	Bucket
+	Added     int `json:"added"`
+	Completed int `json:"completed"`
+	Deleted   int `json:"deleted"`
+	// Net is the change in the number of pending tasks: added - completed - deleted
+	Net int `json:"net"`
+}
+
+// History is a series of task counts per period, like `task history`
+type History struct {
+	Period Period         `json:"period"`
+	Points []HistoryPoint `json:"points"`
+	Total  HistoryPoint   `json:"total"`
+}
+
+// BuildHistory counts the tasks added (by entry date), completed and deleted (by end
+// date) in each bucket. Recurrence templates are left out.
+func BuildHistory(tasks []models.Task, buckets []Bucket, period Period) History {
+	report := History{Period: period, Points: make([]HistoryPoint, len(buckets))}
+	for i, bucket := range buckets {
+		report.Points[i].Bucket = bucket
+	}
+	if len(buckets) > 0 {
+		report.Total.Bucket = Bucket{Start: buckets[0].Start, End: buckets[len(buckets)-1].End}
+	}
+
+	find := func(value string) *HistoryPoint {
+		date, ok := parseTaskDate(value)
+		if !ok {
+			return nil
+		}
+		for i := range report.Points {
+			if !date.Before(report.Points[i].Start) && date.Before(report.Points[i].End) {
+				return &report.Points[i]
+			}
+		}
+		return nil
+	}
+
+	for _, task := range tasks {
+		if task.Status == "recurring" {
+			continue
+		}
+		if point := find(task.Entry); point != nil {
+			point.Added++
+		}
+		if task.Status != "completed" && task.Status != "deleted" {
+			continue
+		}
+		if point := find(task.End); point != nil {
+			if task.Status == "completed" {
+				point.Completed++
+			} else {
+				point.Deleted++
+			}
+		}
+	}
+
+	for i := range report.Points {
+		point := &report.Points[i]
+		point.Net = point.Added - point.Completed - point.Deleted
+		report.Total.Added += point.Added
+		report.Total.Completed += point.Completed
+		report.Total.Deleted += point.Deleted
+		report.Total.Net += point.Net
+	}
+	return report
+}
@@ -0,0 +1,80 @@
+package reports
+
+import (
+	"ccsync_backend/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Buckets(t *testing.T) {
+	from := mustTime(t, "2025-03-05T00:00:00Z")
+	to := mustTime(t, "2025-03-17T00:00:00Z")
+
+	weeks, err := Buckets(from, to, Weekly, time.UTC)
+	require.NoError(t, err)
+	require.Len(t, weeks, 2)
+	assert.Equal(t, "2025-W10", weeks[0].Label)
+	assert.Equal(t, mustTime(t, "2025-03-03T00:00:00Z"), weeks[0].Start)
+	assert.Equal(t, mustTime(t, "2025-03-17T00:00:00Z"), weeks[1].End)
+
+	months, err := Buckets(from, to, Monthly, time.UTC)
+	require.NoError(t, err)
+	assert.Equal(t, []Bucket{{Label: "2025-03", Start: mustTime(t, "2025-03-01T00:00:00Z"), End: mustTime(t, "2025-04-01T00:00:00Z")}}, months)
+
+	_, err = Buckets(mustTime(t, "2000-01-01T00:00:00Z"), to, Daily, time.UTC)
+	assert.Error(t, err)
+
+	_, err = ParsePeriod("hourly")
+	assert.Error(t, err)
+}
+
+func Test_Buckets_DaysInLocation(t *testing.T) {
+	loc := time.FixedZone("UTC+2", 2*3600)
+	from := time.Date(2025, 3, 1, 0, 0, 0, 0, loc)
+
+	days, err := Buckets(from, from.AddDate(0, 0, 2), Daily, loc)
+	require.NoError(t, err)
+	require.Len(t, days, 2)
+	assert.Equal(t, "2025-03-01", days[0].Label)
+	assert.Equal(t, mustTime(t, "2025-02-28T22:00:00Z"), days[0].Start.UTC())
+}
+
+func historyTasks() []models.Task {
+	return []models.Task{
+		{UUID: "a", Status: "completed", Entry: "20250301T100000Z", End: "20250302T100000Z"},
+		{UUID: "b", Status: "deleted", Entry: "20250301T120000Z", End: "20250303T100000Z"},
+		{UUID: "c", Status: "pending", Entry: "20250302T100000Z", Start: "20250303T080000Z"},
+		{UUID: "d", Status: "recurring", Entry: "20250301T000000Z"},
+	}
+}
+
+func Test_BuildHistory(t *testing.T) {
+	buckets, err := Buckets(mustTime(t, "2025-03-01T00:00:00Z"), mustTime(t, "2025-03-04T00:00:00Z"), Daily, time.UTC)
+	require.NoError(t, err)
+
+	history := BuildHistory(historyTasks(), buckets, Daily)
+	require.Len(t, history.Points, 3)
+	assert.Equal(t, []int{2, 0, 0, 2}, []int{history.Points[0].Added, history.Points[0].Completed, history.Points[0].Deleted, history.Points[0].Net})
+	assert.Equal(t, []int{1, 1, 0, 0}, []int{history.Points[1].Added, history.Points[1].Completed, history.Points[1].Deleted, history.Points[1].Net})
+	assert.Equal(t, []int{0, 0, 1, -1}, []int{history.Points[2].Added, history.Points[2].Completed, history.Points[2].Deleted, history.Points[2].Net})
+	assert.Equal(t, 3, history.Total.Added)
+	assert.Equal(t, 1, history.Total.Net)
+}
+
+func Test_BuildBurndown(t *testing.T) {
+	buckets, err := Buckets(mustTime(t, "2025-03-01T00:00:00Z"), mustTime(t, "2025-03-04T00:00:00Z"), Daily, time.UTC)
+	require.NoError(t, err)
+	now := mustTime(t, "2025-03-03T09:00:00Z")
+
+	burndown := BuildBurndown(historyTasks(), buckets, Daily, now)
+	require.Len(t, burndown.Points, 3)
+
+	counts := func(point BurndownPoint) []int { return []int{point.Pending, point.Started, point.Done} }
+	assert.Equal(t, []int{2, 0, 0}, counts(burndown.Points[0]))
+	assert.Equal(t, []int{2, 0, 1}, counts(burndown.Points[1]))
+	// the current period is counted as of now: b is not deleted yet and c was started
+	assert.Equal(t, []int{2, 1, 1}, counts(burndown.Points[2]))
+}
@@ -0,0 +1,104 @@
+package reports
+
+import (
+	"ccsync_backend/utils"
+	"fmt"
+	"time"
+)
+
+// Period is the length of the buckets a series report is split into
+type Period string
+
+const (
+	Daily   Period = "daily"
+	Weekly  Period = "weekly"
+	Monthly Period = "monthly"
+	Yearly  Period = "yearly"
+)
+
+// MaxPeriods bounds the number of buckets of a series report
+const MaxPeriods = 1000
+
+// ParsePeriod reads a period name, daily when empty
+func ParsePeriod(value string) (Period, error) {
+	switch Period(value) {
+	case "":
+		return Daily, nil
+	case Daily, Weekly, Monthly, Yearly:
+		return Period(value), nil
+	}
+	return "", fmt.Errorf("Invalid period '%s': expected daily, weekly, monthly or yearly", value)
+}
+
+// Start returns the start of the period containing t, in loc. Weeks start on Monday.
+func (p Period) Start(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	switch p {
+	case Weekly:
+		monday := t.Day() - (int(t.Weekday())+6)%7
+		return time.Date(t.Year(), t.Month(), monday, 0, 0, 0, 0, loc)
+	case Monthly:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, loc)
+	case Yearly:
+		return time.Date(t.Year(), time.January, 1, 0, 0, 0, 0, loc)
+	default:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+	}
+}
+
+// Add moves a period start n periods forward, or backward when n is negative
+func (p Period) Add(start time.Time, n int) time.Time {
+	switch p {
+	case Weekly:
+		return start.AddDate(0, 0, 7*n)
+	case Monthly:
+		return start.AddDate(0, n, 0)
+	case Yearly:
+		return start.AddDate(n, 0, 0)
+	default:
+		return start.AddDate(0, 0, n)
+	}
+}
+
+// Label names the period starting at start: 2025-03-14, 2025-W11, 2025-03 or 2025
+func (p Period) Label(start time.Time) string {
+	switch p {
+	case Weekly:
+		year, week := start.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	case Monthly:
+		return start.Format("2006-01")
+	case Yearly:
+		return start.Format("2006")
+	default:
+		return start.Format("2006-01-02")
+	}
+}
+
+// Bucket is one period of a series report
+type Bucket struct {
+	Label string    `json:"label"`
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// Buckets splits [from, to) into periods, the first and last ones extended to whole
+// periods
+func Buckets(from, to time.Time, period Period, loc *time.Location) ([]Bucket, error) {
+	buckets := []Bucket{}
+	for start := period.Start(from, loc); start.Before(to); start = period.Add(start, 1) {
+		if len(buckets) == MaxPeriods {
+			return nil, fmt.Errorf("Invalid range: more than %d %s periods", MaxPeriods, period)
+		}
+		buckets = append(buckets, Bucket{Label: period.Label(start), Start: start, End: period.Add(start, 1)})
+	}
+	return buckets, nil
+}
+
+func parseTaskDate(value string) (time.Time, bool) {
+	if value == "" {
+		return time.Time{}, false
+	}
+	parsed, err := time.Parse(utils.TaskwarriorDateLayout, value)
+	return parsed, err == nil
+}
@@ -0,0 +1,105 @@
+package reports
+
+import (
+	"ccsync_backend/models"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ProjectSummary is the progress of a project, subprojects included
+type ProjectSummary struct {
+	Project         string  `json:"project"`
+	Pending         int     `json:"pending"`
+	Completed       int     `json:"completed"`
+	PercentComplete float64 `json:"percentComplete"`
+	// AverageAgeSeconds is the average age of the pending tasks
+	AverageAgeSeconds int64 `json:"averageAgeSeconds"`
+}
+
+// Summary reports the progress of every project, like `task summary`
+type Summary struct {
+	Projects []ProjectSummary `json:"projects"`
+	Total    ProjectSummary   `json:"total"`
+}
+
+// BuildSummary counts pending (including waiting) and completed tasks per project.
+// A project also counts the tasks of its subprojects, Home those of Home.Garden.
+// Deleted tasks and recurrence templates are left out, and so are projects without
+// pending tasks unless all is set.
+func BuildSummary(tasks []models.Task, now time.Time, all bool) Summary {
+	byProject := make(map[string]*projectTotals)
+	total := &projectTotals{}
+
+	for _, task := range tasks {
+		pending := task.Status == "pending" || task.Status == "waiting"
+		if !pending && task.Status != "completed" {
+			continue
+		}
+
+		var age time.Duration
+		if entry, ok := parseTaskDate(task.Entry); ok && pending {
+			age = now.Sub(entry)
+		}
+
+		for _, project := range projectAncestry(task.Project) {
+			t, ok := byProject[project]
+			if !ok {
+				t = &projectTotals{}
+				byProject[project] = t
+			}
+			t.add(pending, age)
+		}
+		total.add(pending, age)
+	}
+
+	summarize := func(project string, t *projectTotals) ProjectSummary {
+		summary := ProjectSummary{Project: project, Pending: t.pending, Completed: t.completed}
+		if count := t.pending + t.completed; count > 0 {
+			summary.PercentComplete = math.Round(float64(t.completed)*1000/float64(count)) / 10
+		}
+		if t.pending > 0 {
+			summary.AverageAgeSeconds = int64(t.age.Seconds()) / int64(t.pending)
+		}
+		return summary
+	}
+
+	report := Summary{Projects: []ProjectSummary{}, Total: summarize("", total)}
+	for project, t := range byProject {
+		if t.pending > 0 || all {
+			report.Projects = append(report.Projects, summarize(project, t))
+		}
+	}
+	sort.Slice(report.Projects, func(i, j int) bool {
+		return report.Projects[i].Project < report.Projects[j].Project
+	})
+	return report
+}
+
+type projectTotals struct {
+	pending, completed int
+	age                time.Duration
+}
+
+func (t *projectTotals) add(pending bool, age time.Duration) {
+	if pending {
+		t.pending++
+		t.age += age
+	} else {
+		t.completed++
+	}
+}
+
+// projectAncestry lists a project and its parents: Home.Garden, then Home
+func projectAncestry(project string) []string {
+	if project == "" {
+		return []string{noneKey}
+	}
+	ancestry := []string{project}
+	for i := strings.LastIndex(project, "."); i > 0; i = strings.LastIndex(project, ".") {
+		project = project[:i]
+		ancestry = append(ancestry, project)
+	}
+	return ancestry
+}
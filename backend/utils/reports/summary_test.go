@@ -0,0 +1,48 @@
+package reports
+
+import (
+	"ccsync_backend/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_BuildSummary_RollsUpSubprojects(t *testing.T) {
+	tasks := []models.Task{
+		{Project: "Home", Status: "pending", Entry: "20250301T000000Z"},
+		{Project: "Home.Garden", Status: "waiting", Entry: "20250303T000000Z"},
+		{Project: "Home.Garden", Status: "completed"},
+		{Project: "Work", Status: "completed"},
+		{Project: "Work", Status: "deleted"},
+		{Status: "pending", Entry: "20250305T000000Z"},
+		{Project: "Home", Status: "recurring"},
+	}
+	now := mustTime(t, "2025-03-05T00:00:00Z")
+
+	summary := BuildSummary(tasks, now, false)
+	assert.Equal(t, []ProjectSummary{
+		{Project: "(none)", Pending: 1},
+		{Project: "Home", Pending: 2, Completed: 1, PercentComplete: 33.3, AverageAgeSeconds: 3 * 86400},
+		{Project: "Home.Garden", Pending: 1, Completed: 1, PercentComplete: 50, AverageAgeSeconds: 2 * 86400},
+	}, summary.Projects)
+	assert.Equal(t, 3, summary.Total.Pending)
+	assert.Equal(t, 2, summary.Total.Completed)
+
+	all := BuildSummary(tasks, now, true)
+	assert.Len(t, all.Projects, 4)
+	assert.Equal(t, ProjectSummary{Project: "Work", Completed: 1, PercentComplete: 100}, all.Projects[3])
+}
+
+func Test_BuildTagCounts(t *testing.T) {
+	tasks := []models.Task{
+		{Tags: []string{"home", "next"}},
+		{Tags: []string{"next"}},
+		{Tags: []string{"errand"}},
+		{},
+	}
+	assert.Equal(t, []TagCount{
+		{Tag: "next", Count: 2},
+		{Tag: "errand", Count: 1},
+		{Tag: "home", Count: 1},
+	}, BuildTagCounts(tasks))
+}
@@ -0,0 +1,34 @@
+package reports
+
+import (
+	"ccsync_backend/models"
+	"sort"
+)
+
+// TagCount is the number of tasks carrying a tag
+type TagCount struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
+
+// BuildTagCounts counts the tasks carrying each tag, most used first, like `task tags`
+func BuildTagCounts(tasks []models.Task) []TagCount {
+	counts := make(map[string]int)
+	for _, task := range tasks {
+		for _, tag := range task.Tags {
+			counts[tag]++
+		}
+	}
+
+	report := make([]TagCount, 0, len(counts))
+	for tag, count := range counts {
+		report = append(report, TagCount{Tag: tag, Count: count})
+	}
+	sort.Slice(report, func(i, j int) bool {
+		if report[i].Count != report[j].Count {
+			return report[i].Count > report[j].Count
+		}
+		return report[i].Tag < report[j].Tag
+	})
+	return report
+}
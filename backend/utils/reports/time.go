@@ -0,0 +1,197 @@
+package reports
+
+import (
+	"ccsync_backend/models"
+	"ccsync_backend/utils"
+	"sort"
+	"time"
+)
+
+const (
+	// annotations written by Taskwarrior when journal.time is on
+	startedAnnotation = "Started task"
+	stoppedAnnotation = "Stopped task"
+
+	// noneKey groups time tracked on tasks without a project or tags
+	noneKey = "(none)"
+)
+
+// Interval is a single stretch of work on a task
+type Interval struct {
+	Start   time.Time `json:"start"`
+	End     time.Time `json:"end"`
+	Seconds int64     `json:"seconds"`
+	Active  bool      `json:"active"`
+}
+
+// TaskTime is the tracked time of one task within a report range
+type TaskTime struct {
+	UUID        string     `json:"uuid"`
+	Description string     `json:"description"`
+	Project     string     `json:"project"`
+	Tags        []string   `json:"tags"`
+	Seconds     int64      `json:"seconds"`
+	Intervals   []Interval `json:"intervals"`
+}
+
+// TimeReport aggregates tracked time by day, project and tag for a date range
+type TimeReport struct {
+	From         time.Time        `json:"from"`
+	To           time.Time        `json:"to"`
+	TotalSeconds int64            `json:"totalSeconds"`
+	ByDay        map[string]int64 `json:"byDay"`
+	ByProject    map[string]int64 `json:"byProject"`
+	ByTag        map[string]int64 `json:"byTag"`
+	Tasks        []TaskTime       `json:"tasks"`
+}
+
+// TaskIntervals rebuilds the work intervals of a task from its start/stop annotations.
+// A task that is still active contributes an open interval ending at now.
+func TaskIntervals(task models.Task, now time.Time) []Interval {
+	annotations := append([]models.Annotation(nil), task.Annotations...)
+	sort.SliceStable(annotations, func(i, j int) bool {
+		return annotations[i].Entry < annotations[j].Entry
+	})
+
+	var intervals []Interval
+	var open *time.Time
+	for _, annotation := range annotations {
+		entry, err := time.Parse(utils.TaskwarriorDateLayout, annotation.Entry)
+		if err != nil {
+			continue
+		}
+		switch annotation.Description {
+		case startedAnnotation:
+			if open == nil {
+				started := entry
+				open = &started
+			}
+		case stoppedAnnotation:
+			if open != nil {
+				intervals = append(intervals, newInterval(*open, entry, false))
+				open = nil
+			}
+		}
+	}
+
+	// Started from a client without journal.time, so only the start attribute is known
+	if open == nil && task.Start != "" {
+		if started, err := time.Parse(utils.TaskwarriorDateLayout, task.Start); err == nil {
+			if len(intervals) == 0 || intervals[len(intervals)-1].End.Before(started) {
+				open = &started
+			}
+		}
+	}
+
+	if open != nil {
+		if task.Start != "" {
+			intervals = append(intervals, newInterval(*open, now, true))
+		} else if end, err := time.Parse(utils.TaskwarriorDateLayout, task.End); err == nil {
+			intervals = append(intervals, newInterval(*open, end, false))
+		}
+	}
+
+	return intervals
+}
+
+// BuildTimeReport sums the tracked time of tasks that falls within [from, to).
+// Intervals are clipped to the range and split across days in loc.
+func BuildTimeReport(tasks []models.Task, from, to, now time.Time, loc *time.Location) TimeReport {
+	report := TimeReport{
+		From:      from,
+		To:        to,
+		ByDay:     make(map[string]int64),
+		ByProject: make(map[string]int64),
+		ByTag:     make(map[string]int64),
+		Tasks:     []TaskTime{},
+	}
+
+	for _, task := range tasks {
+		taskTime := TaskTime{
+			UUID:        task.UUID,
+			Description: task.Description,
+			Project:     task.Project,
+			Tags:        task.Tags,
+		}
+
+		for _, interval := range TaskIntervals(task, now) {
+			clipped, ok := clipInterval(interval, from, to)
+			if !ok {
+				continue
+			}
+			taskTime.Intervals = append(taskTime.Intervals, clipped)
+			taskTime.Seconds += clipped.Seconds
+
+			for day, seconds := range splitByDay(clipped, loc) {
+				report.ByDay[day] += seconds
+			}
+		}
+
+		if taskTime.Seconds == 0 {
+			continue
+		}
+
+		report.TotalSeconds += taskTime.Seconds
+		report.ByProject[keyOrNone(task.Project)] += taskTime.Seconds
+		if len(task.Tags) == 0 {
+			report.ByTag[noneKey] += taskTime.Seconds
+		}
+		for _, tag := range task.Tags {
+			report.ByTag[tag] += taskTime.Seconds
+		}
+		report.Tasks = append(report.Tasks, taskTime)
+	}
+
+	sort.Slice(report.Tasks, func(i, j int) bool {
+		return report.Tasks[i].Seconds > report.Tasks[j].Seconds
+	})
+
+	return report
+}
+
+func newInterval(start, end time.Time, active bool) Interval {
+	return Interval{
+		Start:   start,
+		End:     end,
+		Seconds: int64(end.Sub(start).Seconds()),
+		Active:  active,
+	}
+}
+
+func clipInterval(interval Interval, from, to time.Time) (Interval, bool) {
+	start, end := interval.Start, interval.End
+	if start.Before(from) {
+		start = from
+	}
+	if end.After(to) {
+		end = to
+	}
+	if !end.After(start) {
+		return Interval{}, false
+	}
+	return newInterval(start, end, interval.Active), true
+}
+
+// splitByDay distributes an interval over the calendar days it spans
+func splitByDay(interval Interval, loc *time.Location) map[string]int64 {
+	days := make(map[string]int64)
+	start := interval.Start.In(loc)
+	end := interval.End.In(loc)
+	for start.Before(end) {
+		nextDay := time.Date(start.Year(), start.Month(), start.Day()+1, 0, 0, 0, 0, loc)
+		segmentEnd := end
+		if nextDay.Before(end) {
+			segmentEnd = nextDay
+		}
+		days[start.Format("2006-01-02")] += int64(segmentEnd.Sub(start).Seconds())
+		start = segmentEnd
+	}
+	return days
+}
+
+func keyOrNone(key string) string {
+	if key == "" {
+		return noneKey
+	}
+	return key
+}
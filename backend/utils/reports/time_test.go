@@ -0,0 +1,122 @@
+package reports
+
+import (
+	"ccsync_backend/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func mustTime(t *testing.T, value string) time.Time {
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		t.Fatalf("invalid test time %s: %v", value, err)
+	}
+	return parsed
+}
+
+func Test_TaskIntervals_FromJournalAnnotations(t *testing.T) {
+	task := models.Task{
+		UUID: "a",
+		Annotations: []models.Annotation{
+			{Entry: "20250301T110000Z", Description: "Stopped task"},
+			{Entry: "20250301T090000Z", Description: "Started task"},
+			{Entry: "20250301T100000Z", Description: "an unrelated note"},
+		},
+	}
+
+	intervals := TaskIntervals(task, mustTime(t, "2025-03-02T00:00:00Z"))
+	assert.Len(t, intervals, 1)
+	assert.Equal(t, int64(2*3600), intervals[0].Seconds)
+	assert.False(t, intervals[0].Active)
+}
+
+func Test_TaskIntervals_ActiveTask(t *testing.T) {
+	task := models.Task{
+		UUID:  "a",
+		Start: "20250301T090000Z",
+		Annotations: []models.Annotation{
+			{Entry: "20250301T090000Z", Description: "Started task"},
+		},
+	}
+
+	intervals := TaskIntervals(task, mustTime(t, "2025-03-01T09:30:00Z"))
+	assert.Len(t, intervals, 1)
+	assert.Equal(t, int64(1800), intervals[0].Seconds)
+	assert.True(t, intervals[0].Active)
+}
+
+func Test_TaskIntervals_StartedWithoutJournal(t *testing.T) {
+	task := models.Task{UUID: "a", Start: "20250301T090000Z"}
+
+	intervals := TaskIntervals(task, mustTime(t, "2025-03-01T10:00:00Z"))
+	assert.Len(t, intervals, 1)
+	assert.Equal(t, int64(3600), intervals[0].Seconds)
+}
+
+func Test_TaskIntervals_CompletedWhileActive(t *testing.T) {
+	task := models.Task{
+		UUID:   "a",
+		Status: "completed",
+		End:    "20250301T100000Z",
+		Annotations: []models.Annotation{
+			{Entry: "20250301T090000Z", Description: "Started task"},
+		},
+	}
+
+	intervals := TaskIntervals(task, mustTime(t, "2025-03-05T00:00:00Z"))
+	assert.Len(t, intervals, 1)
+	assert.Equal(t, int64(3600), intervals[0].Seconds)
+}
+
+func Test_BuildTimeReport_AggregatesAndClips(t *testing.T) {
+	tasks := []models.Task{
+		{
+			UUID:    "a",
+			Project: "work",
+			Tags:    []string{"dev", "urgent"},
+			Annotations: []models.Annotation{
+				{Entry: "20250301T230000Z", Description: "Started task"},
+				{Entry: "20250302T010000Z", Description: "Stopped task"},
+			},
+		},
+		{
+			UUID: "b",
+			Annotations: []models.Annotation{
+				{Entry: "20250228T100000Z", Description: "Started task"},
+				{Entry: "20250228T110000Z", Description: "Stopped task"},
+				{Entry: "20250302T100000Z", Description: "Started task"},
+				{Entry: "20250302T103000Z", Description: "Stopped task"},
+			},
+		},
+	}
+
+	from := mustTime(t, "2025-03-01T00:00:00Z")
+	to := mustTime(t, "2025-03-03T00:00:00Z")
+	report := BuildTimeReport(tasks, from, to, to, time.UTC)
+
+	assert.Equal(t, int64(2*3600+1800), report.TotalSeconds)
+	assert.Equal(t, map[string]int64{"2025-03-01": 3600, "2025-03-02": 3600 + 1800}, report.ByDay)
+	assert.Equal(t, map[string]int64{"work": 7200, "(none)": 1800}, report.ByProject)
+	assert.Equal(t, map[string]int64{"dev": 7200, "urgent": 7200, "(none)": 1800}, report.ByTag)
+	assert.Len(t, report.Tasks, 2)
+	assert.Equal(t, "a", report.Tasks[0].UUID)
+}
+
+func Test_BuildTimeReport_SplitsDaysInLocation(t *testing.T) {
+	tasks := []models.Task{{
+		UUID: "a",
+		Annotations: []models.Annotation{
+			{Entry: "20250301T220000Z", Description: "Started task"},
+			{Entry: "20250301T230000Z", Description: "Stopped task"},
+		},
+	}}
+	loc := time.FixedZone("UTC+1", 3600)
+
+	from := mustTime(t, "2025-03-01T00:00:00Z")
+	to := mustTime(t, "2025-03-03T00:00:00Z")
+	report := BuildTimeReport(tasks, from, to, to, loc)
+
+	assert.Equal(t, map[string]int64{"2025-03-01": 3600}, report.ByDay)
+}
@@ -0,0 +1,43 @@
+package tw
+
+import (
+	"ccsync_backend/models"
+	"ccsync_backend/utils"
+	"fmt"
+	"os"
+)
+
+func AddAnnotationInTaskwarrior(email, encryptionSecret, uuid, taskuuid, description string) error {
+	if err := utils.ExecCommand("rm", "-rf", "/root/.task"); err != nil {
+		return fmt.Errorf("error deleting Taskwarrior data: %v", err)
+	}
+	tempDir, err := os.MkdirTemp("", utils.SafeTempDirPrefix("taskwarrior-", email))
+	if err != nil {
+		return fmt.Errorf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	origin := os.Getenv("CONTAINER_ORIGIN")
+	if err := SetTaskwarriorConfig(tempDir, encryptionSecret, origin, uuid); err != nil {
+		return err
+	}
+
+	if err := SyncTaskwarrior(tempDir); err != nil {
+		return err
+	}
+	RecordTaskHistory(tempDir, uuid, models.HistorySourceExternal, "sync")
+
+	// "--" keeps words like "due:tomorrow" in the text from being parsed as modifications
+	if err := utils.ExecCommandInDir(tempDir, "task", taskuuid, "annotate", "--", description); err != nil {
+		return fmt.Errorf("failed to annotate task: %v", err)
+	}
+
+	RecordTaskHistory(tempDir, uuid, models.HistorySourceCCSync, "Add Annotation")
+
+	// Sync Taskwarrior again
+	if err := SyncTaskwarrior(tempDir); err != nil {
+		return err
+	}
+
+	return nil
+}
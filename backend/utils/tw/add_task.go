@@ -27,7 +27,38 @@ func AddTaskToTaskwarrior(req models.AddTaskRequestBody, dueDate string) error {
 	if err := SyncTaskwarrior(tempDir); err != nil {
 		return err
 	}
+	RecordTaskHistory(tempDir, req.UUID, models.HistorySourceExternal, "sync")
 
+	cmdArgs, err := addTaskArgs(req, dueDate)
+	if err != nil {
+		return err
+	}
+
+	if err := utils.ExecCommandInDir(tempDir, "task", cmdArgs...); err != nil {
+		return fmt.Errorf("failed to add task: %v\n %v", err, cmdArgs)
+	}
+
+	if req.End != "" || len(req.Annotations) > 0 {
+		output, err := utils.ExecCommandForOutputInDir(tempDir, "task", "+LATEST", "_ids")
+		if err != nil {
+			return fmt.Errorf("failed to get latest task Id: %v", err)
+		}
+		if err := finishAddedTask(tempDir, strings.TrimSpace(string(output)), req); err != nil {
+			return err
+		}
+	}
+
+	RecordTaskHistory(tempDir, req.UUID, models.HistorySourceCCSync, "Add Task")
+
+	if err := SyncTaskwarrior(tempDir); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// addTaskArgs builds the arguments of the task add command for a new task
+func addTaskArgs(req models.AddTaskRequestBody, dueDate string) ([]string, error) {
 	cmdArgs := []string{"add", req.Description}
 	if req.Project != "" {
 		cmdArgs = append(cmdArgs, "project:"+req.Project)
@@ -41,7 +72,7 @@ func AddTaskToTaskwarrior(req models.AddTaskRequestBody, dueDate string) error {
 	if req.Start != "" {
 		start, err := utils.ConvertISOToTaskwarriorFormat(req.Start)
 		if err != nil {
-			return fmt.Errorf("unexpected date format error: %v", err)
+			return nil, fmt.Errorf("unexpected date format error: %v", err)
 		}
 		cmdArgs = append(cmdArgs, "start:"+start)
 	}
@@ -52,14 +83,14 @@ func AddTaskToTaskwarrior(req models.AddTaskRequestBody, dueDate string) error {
 	if req.EntryDate != "" {
 		entry, err := utils.ConvertISOToTaskwarriorFormat(req.EntryDate)
 		if err != nil {
-			return fmt.Errorf("unexpected date format error: %v", err)
+			return nil, fmt.Errorf("unexpected date format error: %v", err)
 		}
 		cmdArgs = append(cmdArgs, "entry:"+entry)
 	}
 	if req.WaitDate != "" {
 		wait, err := utils.ConvertISOToTaskwarriorFormat(req.WaitDate)
 		if err != nil {
-			return fmt.Errorf("unexpected date format error: %v", err)
+			return nil, fmt.Errorf("unexpected date format error: %v", err)
 		}
 		cmdArgs = append(cmdArgs, "wait:"+wait)
 	}
@@ -75,20 +106,12 @@ func AddTaskToTaskwarrior(req models.AddTaskRequestBody, dueDate string) error {
 		}
 	}
 
-	if err := utils.ExecCommandInDir(tempDir, "task", cmdArgs...); err != nil {
-		return fmt.Errorf("failed to add task: %v\n %v", err, cmdArgs)
-	}
-
-	var taskID string
-	if req.End != "" || len(req.Annotations) > 0 {
-		output, err := utils.ExecCommandForOutputInDir(tempDir, "task", "+LATEST", "_ids")
-		if err != nil {
-			return fmt.Errorf("failed to get latest task Id: %v", err)
-		}
-
-		taskID = strings.TrimSpace(string(output))
-	}
+	return cmdArgs, nil
+}
 
+// finishAddedTask sets what task add cannot: the end date, which completes the
+// task, and its annotations
+func finishAddedTask(tempDir, taskID string, req models.AddTaskRequestBody) error {
 	if req.End != "" {
 		end, err := utils.ConvertISOToTaskwarriorFormat(req.End)
 		if err != nil {
@@ -111,9 +134,5 @@ func AddTaskToTaskwarrior(req models.AddTaskRequestBody, dueDate string) error {
 		}
 	}
 
-	if err := SyncTaskwarrior(tempDir); err != nil {
-		return err
-	}
-
 	return nil
 }
@@ -0,0 +1,135 @@
+package tw
+
+import (
+	"ccsync_backend/models"
+	"ccsync_backend/utils"
+	"encoding/json"
+	"fmt"
+)
+
+// exportTaskJSON returns the raw export of a single task, keeping attributes
+// models.Task does not know about (UDAs, mask, ...) so it can be imported back unchanged
+func exportTaskJSON(tempDir, taskUUID string) (map[string]interface{}, error) {
+	output, err := utils.ExecCommandForOutputInDir(tempDir, "task", taskUUID, "export")
+	if err != nil {
+		return nil, fmt.Errorf("failed to export task %s: %v", taskUUID, err)
+	}
+
+	var tasks []map[string]interface{}
+	if err := json.Unmarshal(output, &tasks); err != nil {
+		return nil, fmt.Errorf("error parsing task %s: %v", taskUUID, err)
+	}
+	if len(tasks) == 0 {
+		return nil, fmt.Errorf("task %s not found", taskUUID)
+	}
+	return tasks[0], nil
+}
+
+// importTasksJSON writes raw task JSON back into the replica; existing tasks are
+// replaced as a whole, which is what lets annotations keep their original entry dates
+func importTasksJSON(tempDir string, tasks []map[string]interface{}) error {
+	input, err := json.Marshal(tasks)
+	if err != nil {
+		return fmt.Errorf("error encoding tasks for import: %v", err)
+	}
+	if err := utils.ExecCommandWithInputInDir(tempDir, input, "task", "rc.confirmation=off", "import", "-"); err != nil {
+		return fmt.Errorf("failed to import tasks: %v", err)
+	}
+	return nil
+}
+
+func rawAnnotations(task map[string]interface{}) []map[string]interface{} {
+	var annotations []map[string]interface{}
+	if list, ok := task["annotations"].([]interface{}); ok {
+		for _, item := range list {
+			if annotation, ok := item.(map[string]interface{}); ok {
+				annotations = append(annotations, annotation)
+			}
+		}
+	}
+	return annotations
+}
+
+func setRawAnnotations(task map[string]interface{}, annotations []map[string]interface{}) {
+	if len(annotations) == 0 {
+		delete(task, "annotations")
+		return
+	}
+	task["annotations"] = annotations
+}
+
+// sameEntry compares annotation timestamps given either in Taskwarrior's compact format or as ISO 8601
+func sameEntry(a, b string) bool {
+	normalizedA, errA := utils.ConvertISOToTaskwarriorFormat(a)
+	normalizedB, errB := utils.ConvertISOToTaskwarriorFormat(b)
+	if errA != nil || errB != nil {
+		return a == b
+	}
+	return normalizedA == normalizedB
+}
+
+// syncAnnotations makes the task's annotations match the requested list. Annotations that
+// are kept retain their entry timestamps; only removed ones are rewritten and only new ones added.
+func syncAnnotations(tempDir, taskUUID string, requested []models.Annotation) error {
+	task, err := exportTaskJSON(tempDir, taskUUID)
+	if err != nil {
+		return err
+	}
+	existing := rawAnnotations(task)
+	matched := make([]bool, len(existing))
+
+	var added []string
+	for _, annotation := range requested {
+		if annotation.Description == "" {
+			continue
+		}
+		index := matchAnnotation(existing, matched, annotation)
+		if index < 0 {
+			added = append(added, annotation.Description)
+			continue
+		}
+		matched[index] = true
+	}
+
+	var kept []map[string]interface{}
+	for i, annotation := range existing {
+		if matched[i] {
+			kept = append(kept, annotation)
+		}
+	}
+	if len(kept) != len(existing) {
+		setRawAnnotations(task, kept)
+		if err := importTasksJSON(tempDir, []map[string]interface{}{task}); err != nil {
+			return err
+		}
+	}
+
+	for _, description := range added {
+		if err := utils.ExecCommandInDir(tempDir, "task", taskUUID, "annotate", "--", description); err != nil {
+			return fmt.Errorf("failed to add annotation %s: %v", description, err)
+		}
+	}
+	return nil
+}
+
+// matchAnnotation finds an unmatched existing annotation with the same description,
+// preferring one whose entry also matches so duplicates are told apart
+func matchAnnotation(existing []map[string]interface{}, matched []bool, annotation models.Annotation) int {
+	fallback := -1
+	for i, candidate := range existing {
+		if matched[i] {
+			continue
+		}
+		if description, _ := candidate["description"].(string); description != annotation.Description {
+			continue
+		}
+		entry, _ := candidate["entry"].(string)
+		if annotation.Entry == "" || sameEntry(entry, annotation.Entry) {
+			return i
+		}
+		if fallback < 0 {
+			fallback = i
+		}
+	}
+	return fallback
+}
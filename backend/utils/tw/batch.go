@@ -0,0 +1,241 @@
+package tw
+
+import (
+	"ccsync_backend/models"
+	"ccsync_backend/utils"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ApplyBatchInTaskwarrior applies the operations in order to a single replica and
+// syncs once at the end. When an operation fails the replica is discarded without
+// syncing, so either every operation reaches the server or none does. The results
+// report the outcome of each operation in either case.
+func ApplyBatchInTaskwarrior(email, encryptionSecret, uuid string, operations []models.BatchOperation) ([]models.BatchOperationResult, error) {
+	results := make([]models.BatchOperationResult, len(operations))
+	for i, op := range operations {
+		results[i] = models.BatchOperationResult{Index: i, Op: op.Op, Ref: op.Ref, TaskUUID: op.TaskUUID, Status: models.BatchStatusSkipped}
+	}
+
+	if err := utils.ExecCommand("rm", "-rf", "/root/.task"); err != nil {
+		return results, fmt.Errorf("error deleting Taskwarrior data: %v", err)
+	}
+
+	tempDir, err := os.MkdirTemp("", utils.SafeTempDirPrefix("taskwarrior-", email))
+	if err != nil {
+		return results, fmt.Errorf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	origin := os.Getenv("CONTAINER_ORIGIN")
+	if err := SetTaskwarriorConfig(tempDir, encryptionSecret, origin, uuid); err != nil {
+		return results, err
+	}
+
+	if err := SyncTaskwarrior(tempDir); err != nil {
+		return results, err
+	}
+
+	tasks, err := ExportTasks(tempDir)
+	if err != nil {
+		return results, err
+	}
+	models.GetHistoryStore().RecordSnapshot(uuid, tasks, models.HistorySourceExternal, "sync")
+
+	known := make(map[string]bool, len(tasks))
+	for _, task := range tasks {
+		known[task.UUID] = true
+	}
+
+	refs := make(map[string]string)
+	for i, op := range operations {
+		taskUUID, err := applyBatchOperation(tempDir, op, refs, known)
+		if taskUUID != "" {
+			results[i].TaskUUID = taskUUID
+		}
+		if err != nil {
+			results[i].Status = models.BatchStatusFailed
+			results[i].Error = err.Error()
+			return results, discardBatch(results[:i], fmt.Errorf("operation %d (%s) failed: %v", i, op.Op, err))
+		}
+		results[i].Status = models.BatchStatusApplied
+	}
+
+	if err := checkBatchDependencies(tempDir, results); err != nil {
+		return results, discardBatch(results, err)
+	}
+
+	RecordTaskHistory(tempDir, uuid, models.HistorySourceCCSync, "Batch")
+
+	if err := SyncTaskwarrior(tempDir); err != nil {
+		return results, discardBatch(results, err)
+	}
+
+	return results, nil
+}
+
+// applyBatchOperation applies one operation to the replica and returns the UUID of
+// the task it applied to
+func applyBatchOperation(tempDir string, op models.BatchOperation, refs map[string]string, known map[string]bool) (string, error) {
+	depends := make([]string, 0, len(op.Depends))
+	for _, dep := range op.Depends {
+		resolved, err := resolveBatchRef(dep, refs)
+		if err != nil {
+			return "", err
+		}
+		depends = append(depends, resolved)
+	}
+
+	if op.Op == models.BatchOpAdd {
+		return addBatchTask(tempDir, op, depends, refs, known)
+	}
+
+	taskUUID, err := resolveBatchRef(op.TaskUUID, refs)
+	if err != nil {
+		return "", err
+	}
+	if !known[taskUUID] {
+		return taskUUID, fmt.Errorf("task %s not found", taskUUID)
+	}
+	if _, err := checkPrecondition(tempDir, taskUUID, Precondition{ExpectedModified: op.ExpectedModified}); err != nil {
+		return taskUUID, err
+	}
+	if err := utils.ValidateDependencies(depends, taskUUID); err != nil {
+		return taskUUID, err
+	}
+
+	switch op.Op {
+	case models.BatchOpEdit:
+		args := editTaskArgs(taskUUID, op.Description, op.Project, op.Start, op.Entry, op.Wait, op.End, op.Due, op.Recur, op.Tags, depends)
+		if err := utils.ExecCommandInDir(tempDir, "task", args...); err != nil {
+			return taskUUID, fmt.Errorf("failed to edit task: %v", err)
+		}
+		if err := syncAnnotations(tempDir, taskUUID, op.Annotations); err != nil {
+			return taskUUID, err
+		}
+	case models.BatchOpModify:
+		args := modifyTaskArgs(taskUUID, op.Description, op.Project, op.Priority, op.Due, op.Tags, depends)
+		if err := utils.ExecCommandInDir(tempDir, "task", args...); err != nil {
+			return taskUUID, fmt.Errorf("failed to modify task: %v", err)
+		}
+		if op.Status == "completed" {
+			err = utils.ExecCommandInDir(tempDir, "task", taskUUID, "done", "rc.confirmation=off")
+		} else if op.Status == "deleted" {
+			err = utils.ExecCommandInDir(tempDir, "task", taskUUID, "delete", "rc.confirmation=off")
+		}
+		if err != nil {
+			return taskUUID, fmt.Errorf("failed to set task status to %s: %v", op.Status, err)
+		}
+	case models.BatchOpComplete:
+		if err := utils.ExecCommandInDir(tempDir, "task", taskUUID, "done", "rc.confirmation=off"); err != nil {
+			return taskUUID, fmt.Errorf("failed to mark task as done: %v", err)
+		}
+	case models.BatchOpDelete:
+		if err := utils.ExecCommandInDir(tempDir, "task", taskUUID, "delete", "rc.confirmation=off"); err != nil {
+			return taskUUID, fmt.Errorf("failed to delete task: %v", err)
+		}
+	case models.BatchOpAnnotate:
+		if err := utils.ExecCommandInDir(tempDir, "task", taskUUID, "annotate", "--", op.Description); err != nil {
+			return taskUUID, fmt.Errorf("failed to annotate task: %v", err)
+		}
+	default:
+		return taskUUID, fmt.Errorf("unknown operation %q", op.Op)
+	}
+
+	return taskUUID, nil
+}
+
+// addBatchTask adds the task of an add operation and records its UUID under the
+// operation's ref
+func addBatchTask(tempDir string, op models.BatchOperation, depends []string, refs map[string]string, known map[string]bool) (string, error) {
+	req := models.AddTaskRequestBody{
+		Description: op.Description,
+		Project:     op.Project,
+		Priority:    op.Priority,
+		Start:       op.Start,
+		EntryDate:   op.Entry,
+		WaitDate:    op.Wait,
+		End:         op.End,
+		Recur:       op.Recur,
+		Tags:        op.Tags,
+		Annotations: op.Annotations,
+		Depends:     depends,
+	}
+	cmdArgs, err := addTaskArgs(req, op.Due)
+	if err != nil {
+		return "", err
+	}
+	if err := utils.ExecCommandInDir(tempDir, "task", cmdArgs...); err != nil {
+		return "", fmt.Errorf("failed to add task: %v", err)
+	}
+
+	output, err := utils.ExecCommandForOutputInDir(tempDir, "task", "+LATEST", "uuids")
+	if err != nil {
+		return "", fmt.Errorf("failed to get latest task UUID: %v", err)
+	}
+	taskUUID := strings.TrimSpace(string(output))
+	if taskUUID == "" {
+		return "", fmt.Errorf("failed to get latest task UUID")
+	}
+	known[taskUUID] = true
+	if op.Ref != "" {
+		refs[op.Ref] = taskUUID
+	}
+
+	return taskUUID, finishAddedTask(tempDir, taskUUID, req)
+}
+
+// resolveBatchRef maps a "$ref" to the UUID of the task added under that ref; other
+// values are returned unchanged
+func resolveBatchRef(value string, refs map[string]string) (string, error) {
+	if !models.IsBatchRef(value) {
+		return value, nil
+	}
+	taskUUID, ok := refs[strings.TrimPrefix(value, models.BatchRefPrefix)]
+	if !ok {
+		return "", fmt.Errorf("unknown task reference %s", value)
+	}
+	return taskUUID, nil
+}
+
+// checkBatchDependencies rejects a batch whose operations, taken together, leave a
+// dependency cycle among the tasks they touched
+func checkBatchDependencies(tempDir string, results []models.BatchOperationResult) error {
+	tasks, err := ExportTasks(tempDir)
+	if err != nil {
+		return err
+	}
+
+	taskDeps := models.TaskDependencies(tasks)
+	byUUID := make(map[string]utils.TaskDependency, len(taskDeps))
+	for _, task := range taskDeps {
+		byUUID[task.UUID] = task
+	}
+
+	for _, result := range results {
+		task, ok := byUUID[result.TaskUUID]
+		if !ok || task.Status != "pending" {
+			continue
+		}
+		if err := utils.ValidateCircularDependencies(task.Depends, task.UUID, taskDeps); err != nil {
+			return fmt.Errorf("operation %d (%s): %v", result.Index, result.Op, err)
+		}
+	}
+
+	return nil
+}
+
+// discardBatch drops the replica so that nothing of a failed batch is synced, and
+// marks the operations applied so far as rolled back
+func discardBatch(applied []models.BatchOperationResult, cause error) error {
+	for i := range applied {
+		if applied[i].Status == models.BatchStatusApplied {
+			applied[i].Status = models.BatchStatusRolledBack
+		}
+	}
+	if err := utils.ExecCommand("rm", "-rf", "/root/.task"); err != nil {
+		return fmt.Errorf("%v; error deleting Taskwarrior data: %v", cause, err)
+	}
+	return cause
+}
@@ -0,0 +1,99 @@
+package tw
+
+import (
+	"ccsync_backend/models"
+	"ccsync_backend/utils"
+	"fmt"
+	"strings"
+)
+
+// DependentsError reports that a task was left unchanged because open tasks depend on it
+type DependentsError struct {
+	TaskUUID   string   `json:"taskUuid"`
+	Dependents []string `json:"dependents"`
+}
+
+func (e *DependentsError) Error() string {
+	return fmt.Sprintf("task %s has open dependents: %s", e.TaskUUID, strings.Join(e.Dependents, ", "))
+}
+
+// cascader applies a cascade mode to the dependents of tasks about to be completed
+// or deleted. The tasks themselves are never treated as dependents of one another,
+// and each dependent is completed or deleted at most once.
+type cascader struct {
+	tempDir  string
+	mode     string
+	action   string // done, delete
+	graph    *utils.DependencyGraph
+	targets  map[string]bool
+	cascaded map[string]bool
+}
+
+func newCascader(tempDir, mode, action string, taskUUIDs []string) (*cascader, error) {
+	c := &cascader{
+		tempDir:  tempDir,
+		mode:     mode,
+		action:   action,
+		targets:  make(map[string]bool),
+		cascaded: make(map[string]bool),
+	}
+	if mode == models.CascadeNone {
+		return c, nil
+	}
+
+	tasks, err := ExportTasks(tempDir)
+	if err != nil {
+		return nil, err
+	}
+	c.graph = utils.NewDependencyGraph(models.TaskDependencies(tasks))
+	for _, taskuuid := range taskUUIDs {
+		c.targets[taskuuid] = true
+	}
+	return c, nil
+}
+
+// apply prepares the dependents of the task before it is completed or deleted
+func (c *cascader) apply(taskUUID string) error {
+	switch c.mode {
+	case models.CascadeNone:
+		return nil
+
+	case models.CascadeRefuse:
+		if dependents := c.outside(c.graph.Dependents(taskUUID)); len(dependents) > 0 {
+			return &DependentsError{TaskUUID: taskUUID, Dependents: dependents}
+		}
+
+	case models.CascadeStrip:
+		for _, dependent := range c.outside(c.graph.Dependents(taskUUID)) {
+			if err := utils.ExecCommandInDir(c.tempDir, "task", "rc.confirmation=off", dependent, "modify", "depends:-"+taskUUID); err != nil {
+				return fmt.Errorf("failed to remove the dependency of %s on %s: %v", dependent, taskUUID, err)
+			}
+		}
+
+	case models.CascadeDependents:
+		for _, dependent := range c.outside(c.graph.TransitiveDependents(taskUUID)) {
+			if c.cascaded[dependent] {
+				continue
+			}
+			if err := utils.ExecCommandInDir(c.tempDir, "task", "rc.confirmation=off", dependent, c.action); err != nil {
+				return fmt.Errorf("failed to %s dependent %s: %v", c.action, dependent, err)
+			}
+			c.cascaded[dependent] = true
+		}
+
+	default:
+		return fmt.Errorf("unknown cascade mode '%s'", c.mode)
+	}
+	return nil
+}
+
+// outside drops the tasks that are completed or deleted themselves
+func (c *cascader) outside(uuids []string) []string {
+	kept := []string{}
+	for _, uuid := range uuids {
+		if !c.targets[uuid] {
+			kept = append(kept, uuid)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,64 @@
+package tw
+
+import (
+	"ccsync_backend/models"
+	"ccsync_backend/utils"
+	"fmt"
+	"os"
+)
+
+// removes a single annotation, identified by its entry timestamp (and description when
+// several share the same second), by re-importing the task without it
+func DeleteAnnotationInTaskwarrior(email, encryptionSecret, uuid, taskuuid, entry, description string) error {
+	if err := utils.ExecCommand("rm", "-rf", "/root/.task"); err != nil {
+		return fmt.Errorf("error deleting Taskwarrior data: %v", err)
+	}
+	tempDir, err := os.MkdirTemp("", utils.SafeTempDirPrefix("taskwarrior-", email))
+	if err != nil {
+		return fmt.Errorf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	origin := os.Getenv("CONTAINER_ORIGIN")
+	if err := SetTaskwarriorConfig(tempDir, encryptionSecret, origin, uuid); err != nil {
+		return err
+	}
+
+	if err := SyncTaskwarrior(tempDir); err != nil {
+		return err
+	}
+	RecordTaskHistory(tempDir, uuid, models.HistorySourceExternal, "sync")
+
+	task, err := exportTaskJSON(tempDir, taskuuid)
+	if err != nil {
+		return err
+	}
+
+	annotations := rawAnnotations(task)
+	removed := -1
+	for i, annotation := range annotations {
+		annotationEntry, _ := annotation["entry"].(string)
+		annotationDescription, _ := annotation["description"].(string)
+		if sameEntry(annotationEntry, entry) && (description == "" || annotationDescription == description) {
+			removed = i
+			break
+		}
+	}
+	if removed < 0 {
+		return fmt.Errorf("annotation %s not found on task %s", entry, taskuuid)
+	}
+
+	setRawAnnotations(task, append(annotations[:removed], annotations[removed+1:]...))
+	if err := importTasksJSON(tempDir, []map[string]interface{}{task}); err != nil {
+		return err
+	}
+
+	RecordTaskHistory(tempDir, uuid, models.HistorySourceCCSync, "Delete Annotation")
+
+	// Sync Taskwarrior again
+	if err := SyncTaskwarrior(tempDir); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -1,12 +1,13 @@
 package tw
 
 import (
+	"ccsync_backend/models"
 	"ccsync_backend/utils"
 	"fmt"
 	"os"
 )
 
-func DeleteTaskInTaskwarrior(email, encryptionSecret, uuid, taskuuid string) error {
+func DeleteTaskInTaskwarrior(email, encryptionSecret, uuid, taskuuid, cascade string, precondition Precondition) error {
 	if err := utils.ExecCommand("rm", "-rf", "/root/.task"); err != nil {
 		return fmt.Errorf("error deleting Taskwarrior data: %v", err)
 	}
@@ -24,11 +25,31 @@ func DeleteTaskInTaskwarrior(email, encryptionSecret, uuid, taskuuid string) err
 	if err := SyncTaskwarrior(tempDir); err != nil {
 		return err
 	}
+	RecordTaskHistory(tempDir, uuid, models.HistorySourceExternal, "sync")
+
+	merge, err := checkPrecondition(tempDir, taskuuid, precondition)
+	if err != nil {
+		return err
+	}
+
+	dependents, err := newCascader(tempDir, cascade, "delete", []string{taskuuid})
+	if err != nil {
+		return err
+	}
+	if err := dependents.apply(taskuuid); err != nil {
+		return err
+	}
+
+	if merge != nil {
+		return applyMerge(tempDir, uuid, taskuuid, "Delete Task", precondition, *merge)
+	}
 
 	if err := utils.ExecCommandInDir(tempDir, "task", taskuuid, "delete", "rc.confirmation=off"); err != nil {
 		return fmt.Errorf("failed to mark task as deleted: %v", err)
 	}
 
+	RecordTaskHistory(tempDir, uuid, models.HistorySourceCCSync, "Delete Task")
+
 	// Sync Taskwarrior again
 	if err := SyncTaskwarrior(tempDir); err != nil {
 		return err
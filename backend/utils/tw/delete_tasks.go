@@ -1,12 +1,13 @@
 package tw
 
 import (
+	"ccsync_backend/models"
 	"ccsync_backend/utils"
 	"fmt"
 	"os"
 )
 
-func DeleteTasksInTaskwarrior(email, encryptionSecret, uuid string, taskUUIDs []string) (map[string]string, error) {
+func DeleteTasksInTaskwarrior(email, encryptionSecret, uuid string, taskUUIDs []string, cascade string) (map[string]string, error) {
 	failedTasks := make(map[string]string)
 
 	if err := utils.ExecCommand("rm", "-rf", "/root/.task"); err != nil {
@@ -28,14 +29,26 @@ func DeleteTasksInTaskwarrior(email, encryptionSecret, uuid string, taskUUIDs []
 	if err := SyncTaskwarrior(tempDir); err != nil {
 		return nil, err
 	}
+	RecordTaskHistory(tempDir, uuid, models.HistorySourceExternal, "sync")
+
+	dependents, err := newCascader(tempDir, cascade, "delete", taskUUIDs)
+	if err != nil {
+		return nil, err
+	}
 
 	for _, taskuuid := range taskUUIDs {
+		if err := dependents.apply(taskuuid); err != nil {
+			failedTasks[taskuuid] = err.Error()
+			continue
+		}
 		if err := utils.ExecCommandInDir(tempDir, "task", taskuuid, "delete", "rc.confirmation=off"); err != nil {
 			failedTasks[taskuuid] = err.Error()
 			continue
 		}
 	}
 
+	RecordTaskHistory(tempDir, uuid, models.HistorySourceCCSync, "Bulk Delete Task")
+
 	// Sync Taskwarrior again
 	if err := SyncTaskwarrior(tempDir); err != nil {
 		return failedTasks, err
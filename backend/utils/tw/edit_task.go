@@ -3,7 +3,6 @@ package tw
 import (
 	"ccsync_backend/models"
 	"ccsync_backend/utils"
-	"encoding/json"
 	"fmt"
 	"os"
 	"strings"
@@ -13,6 +12,7 @@ func EditTaskInTaskwarrior(
 	uuid, taskUUID, email, encryptionSecret, description, project, start, entry, wait, end, due, recur string,
 	tags, depends []string,
 	annotations []models.Annotation,
+	precondition Precondition,
 ) error {
 	tempDir, err := os.MkdirTemp("", utils.SafeTempDirPrefix("taskwarrior-", email))
 	if err != nil {
@@ -28,7 +28,36 @@ func EditTaskInTaskwarrior(
 	if err := SyncTaskwarrior(tempDir); err != nil {
 		return err
 	}
+	RecordTaskHistory(tempDir, uuid, models.HistorySourceExternal, "sync")
 
+	merge, err := checkPrecondition(tempDir, taskUUID, precondition)
+	if err != nil {
+		return err
+	}
+	if merge != nil {
+		return applyMerge(tempDir, uuid, taskUUID, "Edit Task", precondition, *merge)
+	}
+
+	modifyArgs := editTaskArgs(taskUUID, description, project, start, entry, wait, end, due, recur, tags, depends)
+	if err := utils.ExecCommandInDir(tempDir, "task", modifyArgs...); err != nil {
+		return fmt.Errorf("failed to edit task: %v", err)
+	}
+
+	if err := syncAnnotations(tempDir, taskUUID, annotations); err != nil {
+		return err
+	}
+
+	RecordTaskHistory(tempDir, uuid, models.HistorySourceCCSync, "Edit Task")
+
+	if err := SyncTaskwarrior(tempDir); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// editTaskArgs builds the arguments of the task modify command applying an edit
+func editTaskArgs(taskUUID, description, project, start, entry, wait, end, due, recur string, tags, depends []string) []string {
 	modifyArgs := []string{taskUUID, "modify"}
 
 	if description != "" {
@@ -40,8 +69,7 @@ func EditTaskInTaskwarrior(
 	}
 
 	if wait != "" {
-		formattedWait := wait + "T00:00:00"
-		modifyArgs = append(modifyArgs, "wait:"+formattedWait)
+		modifyArgs = append(modifyArgs, "wait:"+wait)
 	}
 
 	if start != "" {
@@ -60,8 +88,7 @@ func EditTaskInTaskwarrior(
 	modifyArgs = append(modifyArgs, "depends:"+dependsStr)
 
 	if due != "" {
-		formattedDue := due + "T00:00:00"
-		modifyArgs = append(modifyArgs, "due:"+formattedDue)
+		modifyArgs = append(modifyArgs, "due:"+due)
 	}
 
 	if recur != "" {
@@ -78,39 +105,5 @@ func EditTaskInTaskwarrior(
 		}
 	}
 
-	if err := utils.ExecCommand("task", modifyArgs...); err != nil {
-		return fmt.Errorf("failed to edit task: %v", err)
-	}
-
-	if len(annotations) >= 0 {
-		output, err := utils.ExecCommandForOutputInDir(tempDir, "task", taskUUID, "export")
-		if err == nil {
-			var tasks []map[string]interface{}
-			if err := json.Unmarshal(output, &tasks); err == nil && len(tasks) > 0 {
-				if existingAnnotations, ok := tasks[0]["annotations"].([]interface{}); ok {
-					for _, ann := range existingAnnotations {
-						if annMap, ok := ann.(map[string]interface{}); ok {
-							if desc, ok := annMap["description"].(string); ok {
-								utils.ExecCommand("task", taskUUID, "denotate", desc)
-							}
-						}
-					}
-				}
-			}
-		}
-
-		for _, annotation := range annotations {
-			if annotation.Description != "" {
-				if err := utils.ExecCommand("task", taskUUID, "annotate", annotation.Description); err != nil {
-					return fmt.Errorf("failed to add annotation %s: %v", annotation.Description, err)
-				}
-			}
-		}
-	}
-
-	if err := SyncTaskwarrior(tempDir); err != nil {
-		return err
-	}
-
-	return nil
+	return modifyArgs
 }
@@ -32,5 +32,6 @@ func FetchTasksFromTaskwarrior(email, encryptionSecret, origin, UUID string) ([]
 	if err != nil {
 		return nil, err
 	}
+	models.GetHistoryStore().RecordSnapshot(UUID, tasks, models.HistorySourceExternal, "sync")
 	return tasks, nil
 }
@@ -0,0 +1,16 @@
+package tw
+
+import (
+	"ccsync_backend/models"
+	"ccsync_backend/utils"
+)
+
+// record the replica's current state in the task history journal
+func RecordTaskHistory(tempDir, uuid, source, operation string) {
+	tasks, err := ExportTasks(tempDir)
+	if err != nil {
+		utils.Logger.Warnf("Failed to record task history for %s: %v", operation, err)
+		return
+	}
+	models.GetHistoryStore().RecordSnapshot(uuid, tasks, source, operation)
+}
@@ -0,0 +1,73 @@
+package tw
+
+import (
+	"ccsync_backend/models"
+	"ccsync_backend/utils"
+	"ccsync_backend/utils/importer"
+	"fmt"
+	"os"
+
+	"github.com/google/uuid"
+)
+
+// ImportTasksInTaskwarrior imports the tasks in a single Taskwarrior session with a
+// single sync. Duplicates are detected again against the freshly synced tasks, since
+// they may have changed since a preview, and skipped. Tasks without a UUID get one.
+func ImportTasksInTaskwarrior(email, encryptionSecret, userUUID string, tasks []models.ImportedTask) (models.ImportResult, error) {
+	result := models.ImportResult{Imported: []string{}, Duplicates: []models.ImportedTask{}}
+
+	if err := utils.ExecCommand("rm", "-rf", "/root/.task"); err != nil {
+		return result, fmt.Errorf("error deleting Taskwarrior data: %v", err)
+	}
+	tempDir, err := os.MkdirTemp("", utils.SafeTempDirPrefix("taskwarrior-", email))
+	if err != nil {
+		return result, fmt.Errorf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	origin := os.Getenv("CONTAINER_ORIGIN")
+	if err := SetTaskwarriorConfig(tempDir, encryptionSecret, origin, userUUID); err != nil {
+		return result, err
+	}
+
+	if err := SyncTaskwarrior(tempDir); err != nil {
+		return result, err
+	}
+
+	existing, err := ExportTasks(tempDir)
+	if err != nil {
+		return result, err
+	}
+	models.GetHistoryStore().RecordSnapshot(userUUID, existing, models.HistorySourceExternal, "sync")
+
+	importer.MarkDuplicates(tasks, existing)
+
+	var raw []map[string]interface{}
+	for _, task := range tasks {
+		if task.Duplicate != "" {
+			result.Duplicates = append(result.Duplicates, task)
+			continue
+		}
+		if task.UUID() == "" {
+			task.Task["uuid"] = uuid.NewString()
+		}
+		raw = append(raw, task.Task)
+		result.Imported = append(result.Imported, task.UUID())
+	}
+	if len(raw) == 0 {
+		return result, nil
+	}
+
+	if err := importTasksJSON(tempDir, raw); err != nil {
+		result.Imported = []string{}
+		return result, err
+	}
+
+	RecordTaskHistory(tempDir, userUUID, models.HistorySourceCCSync, "Import")
+
+	if err := SyncTaskwarrior(tempDir); err != nil {
+		return result, err
+	}
+
+	return result, nil
+}
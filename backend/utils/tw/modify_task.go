@@ -1,13 +1,14 @@
 package tw
 
 import (
+	"ccsync_backend/models"
 	"ccsync_backend/utils"
 	"fmt"
 	"os"
 	"strings"
 )
 
-func ModifyTaskInTaskwarrior(uuid, description, project, priority, status, due, email, encryptionSecret, taskID string, tags []string, depends []string) error {
+func ModifyTaskInTaskwarrior(uuid, description, project, priority, status, due, email, encryptionSecret, taskID string, tags []string, depends []string, precondition Precondition) error {
 	if err := utils.ExecCommand("rm", "-rf", "/root/.task"); err != nil {
 		return fmt.Errorf("error deleting Taskwarrior data: %v", err)
 	}
@@ -25,69 +26,27 @@ func ModifyTaskInTaskwarrior(uuid, description, project, priority, status, due,
 	if err := SyncTaskwarrior(tempDir); err != nil {
 		return err
 	}
+	RecordTaskHistory(tempDir, uuid, models.HistorySourceExternal, "sync")
 
-	escapedDescription := fmt.Sprintf(`description:"%s"`, strings.ReplaceAll(description, `"`, `\"`))
-
-	if err := utils.ExecCommand("task", taskID, "modify", escapedDescription); err != nil {
-		return fmt.Errorf("failed to edit task: %v", err)
-	}
-
-	escapedProject := fmt.Sprintf(`project:%s`, strings.ReplaceAll(project, `"`, `\"`))
-	if err := utils.ExecCommand("task", taskID, "modify", escapedProject); err != nil {
-		return fmt.Errorf("failed to edit task project: %v", err)
-	}
-
-	escapedPriority := fmt.Sprintf(`priority:%s`, strings.ReplaceAll(priority, `"`, `\"`))
-	if err := utils.ExecCommand("task", taskID, "modify", escapedPriority); err != nil {
-		return fmt.Errorf("failed to edit task priority: %v", err)
+	merge, err := checkPrecondition(tempDir, taskID, precondition)
+	if err != nil {
+		return err
 	}
-
-	escapedDue := fmt.Sprintf(`due:%s`, strings.ReplaceAll(due, `"`, `\"`))
-	if err := utils.ExecCommand("task", taskID, "modify", escapedDue); err != nil {
-		return fmt.Errorf("failed to edit task due: %v", err)
+	if merge != nil {
+		return applyMerge(tempDir, uuid, taskID, "Modify Task", precondition, *merge)
 	}
 
-	// Handle dependencies - always set to ensure clearing works
-	if err := utils.ExecCommand("task", taskID, "modify", "depends:"); err != nil {
-		return fmt.Errorf("failed to clear dependencies: %v", err)
-	}
-	if len(depends) > 0 {
-		dependsStr := strings.Join(depends, ",")
-		if err := utils.ExecCommand("task", taskID, "modify", "depends:"+dependsStr); err != nil {
-			return fmt.Errorf("failed to set dependencies %s: %v", dependsStr, err)
-		}
+	if err := utils.ExecCommandInDir(tempDir, "task", modifyTaskArgs(taskID, description, project, priority, due, tags, depends)...); err != nil {
+		return fmt.Errorf("failed to edit task: %v", err)
 	}
 
-	// escapedStatus := fmt.Sprintf(`status:%s`, strings.ReplaceAll(status, `"`, `\"`))
 	if status == "completed" {
-		utils.ExecCommand("task", taskID, "done", "rc.confirmation=off")
+		utils.ExecCommandInDir(tempDir, "task", taskID, "done", "rc.confirmation=off")
 	} else if status == "deleted" {
-		utils.ExecCommand("task", taskID, "delete", "rc.confirmation=off")
+		utils.ExecCommandInDir(tempDir, "task", taskID, "delete", "rc.confirmation=off")
 	}
 
-	// Handle tags
-	if len(tags) > 0 {
-		for _, tag := range tags {
-			if strings.HasPrefix(tag, "+") {
-				// Add tag
-				tagValue := strings.TrimPrefix(tag, "+")
-				if err := utils.ExecCommand("task", taskID, "modify", "+"+tagValue); err != nil {
-					return fmt.Errorf("failed to add tag %s: %v", tagValue, err)
-				}
-			} else if strings.HasPrefix(tag, "-") {
-				// Remove tag
-				tagValue := strings.TrimPrefix(tag, "-")
-				if err := utils.ExecCommand("task", taskID, "modify", "-"+tagValue); err != nil {
-					return fmt.Errorf("failed to remove tag %s: %v", tagValue, err)
-				}
-			} else {
-				// Add tag without prefix
-				if err := utils.ExecCommand("task", taskID, "modify", "+"+tag); err != nil {
-					return fmt.Errorf("failed to add tag %s: %v", tag, err)
-				}
-			}
-		}
-	}
+	RecordTaskHistory(tempDir, uuid, models.HistorySourceCCSync, "Modify Task")
 
 	if err := SyncTaskwarrior(tempDir); err != nil {
 		return err
@@ -95,3 +54,27 @@ func ModifyTaskInTaskwarrior(uuid, description, project, priority, status, due,
 
 	return nil
 }
+
+// modifyTaskArgs builds the task modify command setting every field of a
+// modification. Empty values clear the field; dependencies are always replaced
+// and tags are +tag / -tag operations, a bare tag being added.
+func modifyTaskArgs(taskID, description, project, priority, due string, tags, depends []string) []string {
+	args := []string{
+		taskID, "modify",
+		fmt.Sprintf(`description:"%s"`, strings.ReplaceAll(description, `"`, `\"`)),
+		fmt.Sprintf(`project:%s`, strings.ReplaceAll(project, `"`, `\"`)),
+		fmt.Sprintf(`priority:%s`, strings.ReplaceAll(priority, `"`, `\"`)),
+		fmt.Sprintf(`due:%s`, strings.ReplaceAll(due, `"`, `\"`)),
+		"depends:" + strings.Join(depends, ","),
+	}
+
+	for _, tag := range tags {
+		if strings.HasPrefix(tag, "+") || strings.HasPrefix(tag, "-") {
+			args = append(args, tag)
+		} else {
+			args = append(args, "+"+tag)
+		}
+	}
+
+	return args
+}
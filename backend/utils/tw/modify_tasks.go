@@ -0,0 +1,127 @@
+package tw
+
+import (
+	"ccsync_backend/models"
+	"ccsync_backend/utils"
+	"ccsync_backend/utils/filter"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// ModifyTasksInTaskwarrior applies the patch to the given tasks, or to the tasks
+// matching the filter, with days computed in loc, when no UUIDs are given, and syncs once. Tasks the patch could
+// not be applied to are listed with the reason in the result's failure map. Unless
+// the filter names a status, it only selects pending and waiting tasks, so that closed
+// tasks and recurring templates are not rewritten along with them.
+func ModifyTasksInTaskwarrior(email, encryptionSecret, uuid string, taskUUIDs []string, match *filter.Filter, loc *time.Location, patch models.BulkTaskPatch) (models.BulkModifyResult, error) {
+	result := models.BulkModifyResult{Modified: []string{}, Failed: make(map[string]string)}
+
+	if err := utils.ExecCommand("rm", "-rf", "/root/.task"); err != nil {
+		return result, fmt.Errorf("error deleting Taskwarrior data: %v", err)
+	}
+
+	tempDir, err := os.MkdirTemp("", utils.SafeTempDirPrefix("taskwarrior-", email))
+	if err != nil {
+		return result, fmt.Errorf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	origin := os.Getenv("CONTAINER_ORIGIN")
+	if err := SetTaskwarriorConfig(tempDir, encryptionSecret, origin, uuid); err != nil {
+		return result, err
+	}
+
+	if err := SyncTaskwarrior(tempDir); err != nil {
+		return result, err
+	}
+
+	tasks, err := ExportTasks(tempDir)
+	if err != nil {
+		return result, err
+	}
+	models.GetHistoryStore().RecordSnapshot(uuid, tasks, models.HistorySourceExternal, "sync")
+
+	byUUID := make(map[string]models.Task, len(tasks))
+	for _, task := range tasks {
+		byUUID[task.UUID] = task
+	}
+
+	if match != nil && len(taskUUIDs) == 0 {
+		for _, task := range match.Apply(tasks, time.Now(), loc) {
+			if match.NamesStatus() || task.Status == "pending" || task.Status == "waiting" {
+				taskUUIDs = append(taskUUIDs, task.UUID)
+			}
+		}
+	}
+
+	for _, taskUUID := range taskUUIDs {
+		task, ok := byUUID[taskUUID]
+		if !ok {
+			result.Failed[taskUUID] = "task not found"
+			continue
+		}
+		args, err := bulkPatchArgs(task, patch)
+		if err != nil {
+			result.Failed[taskUUID] = err.Error()
+			continue
+		}
+		if err := utils.ExecCommandInDir(tempDir, "task", append([]string{taskUUID, "modify"}, args...)...); err != nil {
+			result.Failed[taskUUID] = err.Error()
+			continue
+		}
+		result.Modified = append(result.Modified, taskUUID)
+	}
+
+	RecordTaskHistory(tempDir, uuid, models.HistorySourceCCSync, "Bulk Modify Task")
+
+	// Sync Taskwarrior again
+	if err := SyncTaskwarrior(tempDir); err != nil {
+		return result, err
+	}
+
+	return result, nil
+}
+
+// bulkPatchArgs builds the modifications applying the patch to the task. A due
+// shift moves the task's own due date, so tasks without one keep none.
+func bulkPatchArgs(task models.Task, patch models.BulkTaskPatch) ([]string, error) {
+	args := []string{}
+	if patch.Project != nil {
+		args = append(args, "project:"+*patch.Project)
+	}
+	if patch.Priority != nil {
+		args = append(args, "priority:"+*patch.Priority)
+	}
+	for _, tag := range patch.AddTags {
+		if tag != "" {
+			args = append(args, "+"+strings.ReplaceAll(tag, " ", "_"))
+		}
+	}
+	for _, tag := range patch.RemoveTags {
+		if tag != "" {
+			args = append(args, "-"+strings.ReplaceAll(tag, " ", "_"))
+		}
+	}
+
+	if patch.DueShift != "" && task.Due != "" {
+		period, sign, err := utils.ParseSignedPeriod(patch.DueShift)
+		if err != nil {
+			return nil, err
+		}
+		due, err := time.Parse(utils.TaskwarriorDateLayout, task.Due)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse due date '%s': %v", task.Due, err)
+		}
+		args = append(args, "due:"+period.AddTo(due, sign).UTC().Format(utils.TaskwarriorDateLayout))
+	}
+
+	if len(args) == 0 {
+		if patch.DueShift != "" {
+			return nil, fmt.Errorf("task has no due date to shift")
+		}
+		return nil, fmt.Errorf("nothing to change")
+	}
+	return args, nil
+}
@@ -0,0 +1,205 @@
+package tw
+
+import (
+	"ccsync_backend/models"
+	"ccsync_backend/utils"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Precondition guards a mutation against changes the client has not seen
+type Precondition struct {
+	// ExpectedModified is the task's modified timestamp as last read by the client.
+	// Empty skips the check.
+	ExpectedModified string
+
+	// Base and Proposed are the task as the client read it and as the client wants it.
+	// When given, a task changed since is merged field by field instead of rejected.
+	Base     *models.Task
+	Proposed *models.Task
+
+	// OnMerge, if set, receives the outcome of a successful merge
+	OnMerge func(models.MergeResult)
+}
+
+// ConflictError reports that a task changed after the client read it. Requested is
+// filled in by the caller with the change that was refused.
+type ConflictError struct {
+	TaskUUID         string      `json:"taskUuid"`
+	ExpectedModified string      `json:"expectedModified"`
+	Current          models.Task `json:"current"`
+	Requested        interface{} `json:"requested,omitempty"`
+	Fields           []string    `json:"fields,omitempty"` // fields both sides changed, when a merge was attempted
+}
+
+func (e *ConflictError) Error() string {
+	if len(e.Fields) > 0 {
+		return fmt.Sprintf("task %s has conflicting changes to %s", e.TaskUUID, strings.Join(e.Fields, ", "))
+	}
+	return fmt.Sprintf("task %s was modified at %s, after the expected %s", e.TaskUUID, e.Current.Modified, e.ExpectedModified)
+}
+
+// checkPrecondition runs after the pre-mutation sync, so the current task reflects
+// changes pushed by other clients in the meantime. It returns a merge result when
+// the task changed but the client's edit could be merged with those changes; the
+// caller then applies the merge instead of its usual mutation.
+func checkPrecondition(tempDir, taskUUID string, precondition Precondition) (*models.MergeResult, error) {
+	if precondition.ExpectedModified == "" {
+		return nil, nil
+	}
+
+	current, err := exportTask(tempDir, taskUUID)
+	if err != nil {
+		return nil, err
+	}
+	if sameTimestamp(current.Modified, precondition.ExpectedModified) {
+		return nil, nil
+	}
+
+	conflict := &ConflictError{
+		TaskUUID:         taskUUID,
+		ExpectedModified: precondition.ExpectedModified,
+		Current:          *current,
+	}
+	if precondition.Base == nil || precondition.Proposed == nil {
+		return nil, conflict
+	}
+
+	merge := models.MergeTasks(*precondition.Base, *current, *precondition.Proposed)
+	if len(merge.Conflicts) > 0 {
+		conflict.Fields = merge.Conflicts
+		return nil, conflict
+	}
+	return &merge, nil
+}
+
+// applyMerge writes the fields the client changed onto the current task,
+// leaving the fields changed by other clients untouched
+func applyMerge(tempDir, uuid, taskUUID, operation string, precondition Precondition, merge models.MergeResult) error {
+	tasks, err := ExportTasks(tempDir)
+	if err != nil {
+		return err
+	}
+	var current models.Task
+	for _, task := range tasks {
+		if task.UUID == taskUUID {
+			current = task
+			break
+		}
+	}
+	proposed := *precondition.Proposed
+
+	modifyArgs := []string{taskUUID, "modify"}
+	status := ""
+	for _, field := range merge.Applied {
+		switch field {
+		case "description":
+			modifyArgs = append(modifyArgs, fmt.Sprintf(`description:"%s"`, strings.ReplaceAll(proposed.Description, `"`, `\"`)))
+		case "project":
+			modifyArgs = append(modifyArgs, "project:"+proposed.Project)
+		case "priority":
+			modifyArgs = append(modifyArgs, "priority:"+proposed.Priority)
+		case "due":
+			modifyArgs = append(modifyArgs, "due:"+proposed.Due)
+		case "wait":
+			modifyArgs = append(modifyArgs, "wait:"+proposed.Wait)
+		case "start":
+			modifyArgs = append(modifyArgs, "start:"+proposed.Start)
+		case "end":
+			modifyArgs = append(modifyArgs, "end:"+proposed.End)
+		case "recur":
+			modifyArgs = append(modifyArgs, "recur:"+proposed.Recur)
+		case "depends":
+			modifyArgs = append(modifyArgs, "depends:"+strings.Join(proposed.Depends, ","))
+		case "tags":
+			modifyArgs = append(modifyArgs, tagChanges(current.Tags, proposed.Tags)...)
+		case "status":
+			status = proposed.Status
+		}
+	}
+
+	if len(modifyArgs) > 2 {
+		if err := utils.ExecCommandInDir(tempDir, "task", modifyArgs...); err != nil {
+			return fmt.Errorf("failed to apply merged changes: %v", err)
+		}
+	}
+	for _, field := range merge.Applied {
+		if field == "annotations" {
+			if err := syncAnnotations(tempDir, taskUUID, proposed.Annotations); err != nil {
+				return err
+			}
+		}
+	}
+	switch status {
+	case "completed":
+		err = utils.ExecCommandInDir(tempDir, "task", taskUUID, "done", "rc.confirmation=off")
+	case "deleted":
+		err = utils.ExecCommandInDir(tempDir, "task", taskUUID, "delete", "rc.confirmation=off")
+	case "":
+	default:
+		err = utils.ExecCommandInDir(tempDir, "task", taskUUID, "modify", "status:"+status)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to apply merged status: %v", err)
+	}
+
+	RecordTaskHistory(tempDir, uuid, models.HistorySourceCCSync, operation+" (merged)")
+
+	if err := SyncTaskwarrior(tempDir); err != nil {
+		return err
+	}
+
+	if precondition.OnMerge != nil {
+		precondition.OnMerge(merge)
+	}
+	return nil
+}
+
+// tagChanges lists the +tag / -tag arguments turning one tag set into another
+func tagChanges(from, to []string) []string {
+	have := make(map[string]bool, len(from))
+	for _, tag := range from {
+		have[tag] = true
+	}
+	want := make(map[string]bool, len(to))
+	for _, tag := range to {
+		want[tag] = true
+	}
+
+	var changes []string
+	for tag := range want {
+		if !have[tag] {
+			changes = append(changes, "+"+tag)
+		}
+	}
+	for tag := range have {
+		if !want[tag] {
+			changes = append(changes, "-"+tag)
+		}
+	}
+	sort.Strings(changes)
+	return changes
+}
+
+// sameTimestamp compares timestamps given in Taskwarrior's compact form or as ISO 8601.
+// An If-Match header value may arrive quoted, like an ETag.
+func sameTimestamp(a, b string) bool {
+	parse := func(value string) (time.Time, bool) {
+		value = strings.Trim(strings.TrimPrefix(strings.TrimSpace(value), "W/"), `"`)
+		for _, layout := range []string{utils.TaskwarriorDateLayout, time.RFC3339, "2006-01-02T15:04:05.000Z"} {
+			if parsed, err := time.Parse(layout, value); err == nil {
+				return parsed, true
+			}
+		}
+		return time.Time{}, false
+	}
+
+	timeA, okA := parse(a)
+	timeB, okB := parse(b)
+	if !okA || !okB {
+		return a == b
+	}
+	return timeA.Equal(timeB)
+}
@@ -0,0 +1,91 @@
+package tw
+
+import (
+	"ccsync_backend/models"
+	"ccsync_backend/utils"
+	"fmt"
+	"os"
+	"time"
+)
+
+// PurgeTasksInTaskwarrior permanently removes deleted tasks: the given ones, or when
+// none are given every deleted task whose deletion is older than the cutoff. Tasks
+// that are not deleted are never purged and are reported in the failure map.
+func PurgeTasksInTaskwarrior(email, encryptionSecret, uuid string, taskUUIDs []string, deletedBefore time.Time) (models.PurgeResult, error) {
+	result := models.PurgeResult{Purged: []string{}, Failed: make(map[string]string)}
+
+	if err := utils.ExecCommand("rm", "-rf", "/root/.task"); err != nil {
+		return result, fmt.Errorf("error deleting Taskwarrior data: %v", err)
+	}
+	tempDir, err := os.MkdirTemp("", utils.SafeTempDirPrefix("taskwarrior-", email))
+	if err != nil {
+		return result, fmt.Errorf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	origin := os.Getenv("CONTAINER_ORIGIN")
+	if err := SetTaskwarriorConfig(tempDir, encryptionSecret, origin, uuid); err != nil {
+		return result, err
+	}
+
+	if err := SyncTaskwarrior(tempDir); err != nil {
+		return result, err
+	}
+
+	tasks, err := ExportTasks(tempDir)
+	if err != nil {
+		return result, err
+	}
+	models.GetHistoryStore().RecordSnapshot(uuid, tasks, models.HistorySourceExternal, "sync")
+
+	if len(taskUUIDs) == 0 {
+		taskUUIDs = DeletedBefore(tasks, deletedBefore)
+	}
+
+	statuses := make(map[string]string, len(tasks))
+	for _, task := range tasks {
+		statuses[task.UUID] = task.Status
+	}
+
+	for _, taskuuid := range taskUUIDs {
+		status, ok := statuses[taskuuid]
+		if !ok {
+			result.Failed[taskuuid] = "task not found"
+			continue
+		}
+		if status != "deleted" {
+			result.Failed[taskuuid] = fmt.Sprintf("task is %s, only deleted tasks can be purged", status)
+			continue
+		}
+		if err := utils.ExecCommandInDir(tempDir, "task", "rc.confirmation=off", taskuuid, "purge"); err != nil {
+			result.Failed[taskuuid] = err.Error()
+			continue
+		}
+		result.Purged = append(result.Purged, taskuuid)
+	}
+
+	RecordTaskHistory(tempDir, uuid, models.HistorySourceCCSync, "Purge Tasks")
+
+	// Sync Taskwarrior again
+	if err := SyncTaskwarrior(tempDir); err != nil {
+		return result, err
+	}
+
+	return result, nil
+}
+
+// DeletedBefore lists the deleted tasks whose deletion time, their end date, is
+// before the cutoff. Deleted tasks without an end date are left out.
+func DeletedBefore(tasks []models.Task, cutoff time.Time) []string {
+	uuids := []string{}
+	for _, task := range tasks {
+		if task.Status != "deleted" {
+			continue
+		}
+		end, err := time.Parse(utils.TaskwarriorDateLayout, task.End)
+		if err == nil && end.Before(cutoff) {
+			uuids = append(uuids, task.UUID)
+		}
+	}
+	return uuids
+}
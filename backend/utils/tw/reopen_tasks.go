@@ -0,0 +1,117 @@
+package tw
+
+import (
+	"ccsync_backend/models"
+	"ccsync_backend/utils"
+	"fmt"
+	"os"
+)
+
+// ReopenTaskInTaskwarrior moves a completed task back to pending
+func ReopenTaskInTaskwarrior(email, encryptionSecret, uuid, taskuuid string) error {
+	failedTasks, err := ReopenTasksInTaskwarrior(email, encryptionSecret, uuid, []string{taskuuid})
+	if err != nil {
+		return err
+	}
+	if reason, failed := failedTasks[taskuuid]; failed {
+		return fmt.Errorf("failed to reopen task %s: %s", taskuuid, reason)
+	}
+	return nil
+}
+
+// ReopenTasksInTaskwarrior moves completed tasks back to pending, clearing their end
+// date. A task whose dependencies would form a cycle among the pending tasks once it
+// is reopened is left completed and reported in the failure map.
+func ReopenTasksInTaskwarrior(email, encryptionSecret, uuid string, taskUUIDs []string) (map[string]string, error) {
+	failedTasks := make(map[string]string)
+
+	if err := utils.ExecCommand("rm", "-rf", "/root/.task"); err != nil {
+		return nil, fmt.Errorf("error deleting Taskwarrior data: %v", err)
+	}
+
+	tempDir, err := os.MkdirTemp("", utils.SafeTempDirPrefix("taskwarrior-", email))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	origin := os.Getenv("CONTAINER_ORIGIN")
+	if err := SetTaskwarriorConfig(tempDir, encryptionSecret, origin, uuid); err != nil {
+		return nil, err
+	}
+
+	if err := SyncTaskwarrior(tempDir); err != nil {
+		return nil, err
+	}
+
+	tasks, err := ExportTasks(tempDir)
+	if err != nil {
+		return nil, err
+	}
+	models.GetHistoryStore().RecordSnapshot(uuid, tasks, models.HistorySourceExternal, "sync")
+
+	for taskuuid, reason := range reopenConflicts(tasks, taskUUIDs) {
+		failedTasks[taskuuid] = reason
+	}
+
+	for _, taskuuid := range taskUUIDs {
+		if _, failed := failedTasks[taskuuid]; failed {
+			continue
+		}
+		if err := setPending(tempDir, taskuuid); err != nil {
+			failedTasks[taskuuid] = err.Error()
+		}
+	}
+
+	RecordTaskHistory(tempDir, uuid, models.HistorySourceCCSync, "Reopen Task")
+
+	// Sync Taskwarrior again
+	if err := SyncTaskwarrior(tempDir); err != nil {
+		return failedTasks, err
+	}
+
+	return failedTasks, nil
+}
+
+// reopenConflicts finds the tasks that cannot be reopened: unknown tasks, tasks that
+// are not completed, and tasks whose dependencies would close a cycle once they and
+// the other reopened tasks are pending again
+func reopenConflicts(tasks []models.Task, taskUUIDs []string) map[string]string {
+	conflicts := make(map[string]string)
+	byUUID := make(map[string]models.Task, len(tasks))
+	for _, task := range tasks {
+		byUUID[task.UUID] = task
+	}
+
+	reopening := make(map[string]bool)
+	for _, taskuuid := range taskUUIDs {
+		task, ok := byUUID[taskuuid]
+		switch {
+		case !ok:
+			conflicts[taskuuid] = "task not found"
+		case task.Status != "completed":
+			conflicts[taskuuid] = fmt.Sprintf("task is %s, only completed tasks can be reopened", task.Status)
+		default:
+			reopening[taskuuid] = true
+		}
+	}
+
+	taskDeps := models.TaskDependencies(tasks)
+	for i := range taskDeps {
+		if reopening[taskDeps[i].UUID] {
+			taskDeps[i].Status = "pending"
+		}
+	}
+
+	for _, taskuuid := range taskUUIDs {
+		if !reopening[taskuuid] {
+			continue
+		}
+		task := byUUID[taskuuid]
+		if err := utils.ValidateCircularDependencies(task.Depends, task.UUID, taskDeps); err != nil {
+			conflicts[taskuuid] = err.Error()
+		}
+	}
+
+	return conflicts
+}
@@ -0,0 +1,71 @@
+package tw
+
+import (
+	"ccsync_backend/models"
+	"ccsync_backend/utils"
+	"fmt"
+	"os"
+)
+
+// RestoreTaskInTaskwarrior brings a deleted task back to pending, clearing the end
+// date set when it was deleted
+func RestoreTaskInTaskwarrior(email, encryptionSecret, uuid, taskuuid string) error {
+	if err := utils.ExecCommand("rm", "-rf", "/root/.task"); err != nil {
+		return fmt.Errorf("error deleting Taskwarrior data: %v", err)
+	}
+	tempDir, err := os.MkdirTemp("", utils.SafeTempDirPrefix("taskwarrior-", email))
+	if err != nil {
+		return fmt.Errorf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	origin := os.Getenv("CONTAINER_ORIGIN")
+	if err := SetTaskwarriorConfig(tempDir, encryptionSecret, origin, uuid); err != nil {
+		return err
+	}
+
+	if err := SyncTaskwarrior(tempDir); err != nil {
+		return err
+	}
+	RecordTaskHistory(tempDir, uuid, models.HistorySourceExternal, "sync")
+
+	task, err := exportTask(tempDir, taskuuid)
+	if err != nil {
+		return err
+	}
+	if task.Status != "deleted" {
+		return fmt.Errorf("task %s is %s, only deleted tasks can be restored", taskuuid, task.Status)
+	}
+
+	if err := setPending(tempDir, taskuuid); err != nil {
+		return fmt.Errorf("failed to restore task: %v", err)
+	}
+
+	RecordTaskHistory(tempDir, uuid, models.HistorySourceCCSync, "Restore Task")
+
+	// Sync Taskwarrior again
+	if err := SyncTaskwarrior(tempDir); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// exportTask returns the task with the given UUID from the replica
+func exportTask(tempDir, taskUUID string) (*models.Task, error) {
+	tasks, err := ExportTasks(tempDir)
+	if err != nil {
+		return nil, err
+	}
+	for i := range tasks {
+		if tasks[i].UUID == taskUUID {
+			return &tasks[i], nil
+		}
+	}
+	return nil, fmt.Errorf("task %s not found", taskUUID)
+}
+
+// setPending moves a completed or deleted task back to pending and clears its end date
+func setPending(tempDir, taskUUID string) error {
+	return utils.ExecCommandInDir(tempDir, "task", "rc.confirmation=off", taskUUID, "modify", "status:pending", "end:")
+}
@@ -0,0 +1,149 @@
+package tw
+
+import (
+	"ccsync_backend/models"
+	"ccsync_backend/utils"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// SaveTodoInTaskwarrior creates or updates the task with the given UUID from a to-do
+// saved by a CalDAV client. Attributes a to-do does not carry, such as the project or
+// dependencies, are left untouched. With createOnly, an existing task is not replaced.
+func SaveTodoInTaskwarrior(email, encryptionSecret, uuid, taskUUID string, todo models.TodoFields, createOnly bool, precondition Precondition) error {
+	if err := utils.ExecCommand("rm", "-rf", "/root/.task"); err != nil {
+		return fmt.Errorf("error deleting Taskwarrior data: %v", err)
+	}
+	tempDir, err := os.MkdirTemp("", utils.SafeTempDirPrefix("taskwarrior-", email))
+	if err != nil {
+		return fmt.Errorf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	origin := os.Getenv("CONTAINER_ORIGIN")
+	if err := SetTaskwarriorConfig(tempDir, encryptionSecret, origin, uuid); err != nil {
+		return err
+	}
+
+	if err := SyncTaskwarrior(tempDir); err != nil {
+		return err
+	}
+
+	tasks, err := ExportTasks(tempDir)
+	if err != nil {
+		return err
+	}
+	models.GetHistoryStore().RecordSnapshot(uuid, tasks, models.HistorySourceExternal, "sync")
+
+	var current *models.Task
+	for i := range tasks {
+		if tasks[i].UUID == taskUUID {
+			current = &tasks[i]
+			break
+		}
+	}
+
+	switch {
+	case current == nil && precondition.ExpectedModified != "":
+		return fmt.Errorf("task %s not found", taskUUID)
+	case current == nil:
+		if current, err = importTodo(tempDir, taskUUID, todo); err != nil {
+			return err
+		}
+	case createOnly:
+		return fmt.Errorf("task %s already exists", taskUUID)
+	default:
+		if _, err := checkPrecondition(tempDir, taskUUID, precondition); err != nil {
+			return err
+		}
+		modifyArgs := append([]string{"rc.confirmation=off", taskUUID, "modify",
+			fmt.Sprintf(`description:"%s"`, strings.ReplaceAll(todo.Description, `"`, `\"`)),
+			"due:" + todo.Due,
+			"priority:" + todo.Priority,
+		}, tagChanges(current.Tags, todo.Tags)...)
+		if err := utils.ExecCommandInDir(tempDir, "task", modifyArgs...); err != nil {
+			return fmt.Errorf("failed to modify task: %v", err)
+		}
+	}
+
+	for _, args := range todoStatusArgs(*current, todo) {
+		if err := utils.ExecCommandInDir(tempDir, "task", append([]string{"rc.confirmation=off", taskUUID}, args...)...); err != nil {
+			return fmt.Errorf("failed to update task status: %v", err)
+		}
+	}
+
+	RecordTaskHistory(tempDir, uuid, models.HistorySourceCCSync, "Save To-do")
+
+	if err := SyncTaskwarrior(tempDir); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// importTodo creates a pending task with the given UUID, which task add cannot set
+func importTodo(tempDir, taskUUID string, todo models.TodoFields) (*models.Task, error) {
+	task := models.Task{
+		UUID:        taskUUID,
+		Description: todo.Description,
+		Status:      "pending",
+		Entry:       time.Now().UTC().Format(utils.TaskwarriorDateLayout),
+		Due:         todo.Due,
+		Priority:    todo.Priority,
+		Tags:        todo.Tags,
+	}
+
+	attributes := map[string]interface{}{
+		"uuid":        task.UUID,
+		"description": task.Description,
+		"status":      task.Status,
+		"entry":       task.Entry,
+	}
+	if task.Due != "" {
+		attributes["due"] = task.Due
+	}
+	if task.Priority != "" {
+		attributes["priority"] = task.Priority
+	}
+	if len(task.Tags) > 0 {
+		attributes["tags"] = task.Tags
+	}
+	if err := importTasksJSON(tempDir, []map[string]interface{}{attributes}); err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// todoStatusArgs lists the commands moving a task to the status of the to-do
+func todoStatusArgs(current models.Task, todo models.TodoFields) [][]string {
+	var commands [][]string
+	closed := current.Status == "completed" || current.Status == "deleted"
+
+	switch todo.Status {
+	case "completed":
+		if current.Status != "completed" {
+			done := []string{"done"}
+			if todo.End != "" {
+				done = append(done, "end:"+todo.End)
+			}
+			commands = append(commands, done)
+		}
+	case "deleted":
+		if current.Status != "deleted" {
+			commands = append(commands, []string{"delete"})
+		}
+	default:
+		if closed {
+			commands = append(commands, []string{"modify", "status:pending", "end:"})
+		}
+		started := current.Start != "" && !closed
+		if todo.Started && !started {
+			commands = append(commands, []string{"start"})
+		} else if !todo.Started && started {
+			commands = append(commands, []string{"stop"})
+		}
+	}
+	return commands
+}
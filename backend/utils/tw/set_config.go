@@ -1,12 +1,24 @@
 package tw
 
 import (
+	"ccsync_backend/models"
 	"ccsync_backend/utils"
 	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 )
 
-// logic to set tw config on backend
+// logic to set tw config on backend. The config is written to a taskrc of the replica's
+// own, starting with the rc settings the user saved, so that replicas of different users
+// never see each other's settings.
 func SetTaskwarriorConfig(tempDir, encryptionSecret, origin, UUID string) error {
+	taskrc := replicaTaskrc(models.GetSettingsStore().Get(UUID).Taskrc)
+	if err := os.WriteFile(filepath.Join(tempDir, utils.ReplicaTaskrc), []byte(taskrc), 0600); err != nil {
+		return fmt.Errorf("error writing Taskwarrior config (%v)", err)
+	}
+
 	configCmds := [][]string{
 		{"task", "config", "sync.encryption_secret", encryptionSecret, "rc.confirmation=off"},
 		{"task", "config", "sync.server.origin", origin, "rc.confirmation=off"},
@@ -18,5 +30,22 @@ func SetTaskwarriorConfig(tempDir, encryptionSecret, origin, UUID string) error
 			return fmt.Errorf("error setting Taskwarrior config (%v)", err)
 		}
 	}
+
 	return nil
 }
+
+// replicaTaskrc writes the user's rc settings as taskrc lines, in key order. Values
+// were validated when saved and hold no line breaks.
+func replicaTaskrc(settings map[string]string) string {
+	keys := make([]string, 0, len(settings))
+	for key := range settings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, key := range keys {
+		b.WriteString(key + "=" + settings[key] + "\n")
+	}
+	return b.String()
+}
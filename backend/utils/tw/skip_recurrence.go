@@ -0,0 +1,68 @@
+package tw
+
+import (
+	"ccsync_backend/models"
+	"ccsync_backend/utils"
+	"fmt"
+	"os"
+)
+
+// deletes the next pending instance of a recurring series and returns its UUID.
+// Deleting a single instance marks it in the template's mask, so it is not generated again.
+func SkipRecurrenceInTaskwarrior(email, encryptionSecret, uuid, templateUUID string) (string, error) {
+	if err := utils.ExecCommand("rm", "-rf", "/root/.task"); err != nil {
+		return "", fmt.Errorf("error deleting Taskwarrior data: %v", err)
+	}
+	tempDir, err := os.MkdirTemp("", utils.SafeTempDirPrefix("taskwarrior-", email))
+	if err != nil {
+		return "", fmt.Errorf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	origin := os.Getenv("CONTAINER_ORIGIN")
+	if err := SetTaskwarriorConfig(tempDir, encryptionSecret, origin, uuid); err != nil {
+		return "", err
+	}
+
+	if err := SyncTaskwarrior(tempDir); err != nil {
+		return "", err
+	}
+
+	tasks, err := ExportTasks(tempDir)
+	if err != nil {
+		return "", err
+	}
+	models.GetHistoryStore().RecordSnapshot(uuid, tasks, models.HistorySourceExternal, "sync")
+
+	next := NextRecurrenceInstance(tasks, templateUUID)
+	if next == nil {
+		return "", fmt.Errorf("no upcoming instance of %s to skip", templateUUID)
+	}
+
+	if err := utils.ExecCommandInDir(tempDir, "task", "rc.confirmation=off", "rc.recurrence.confirmation=no", next.UUID, "delete"); err != nil {
+		return "", fmt.Errorf("failed to skip instance %s: %v", next.UUID, err)
+	}
+
+	RecordTaskHistory(tempDir, uuid, models.HistorySourceCCSync, "Skip Recurrence")
+
+	if err := SyncTaskwarrior(tempDir); err != nil {
+		return "", err
+	}
+
+	return next.UUID, nil
+}
+
+// NextRecurrenceInstance returns the pending instance of a template with the earliest due date
+func NextRecurrenceInstance(tasks []models.Task, templateUUID string) *models.Task {
+	var next *models.Task
+	for i := range tasks {
+		task := &tasks[i]
+		if task.Parent != templateUUID || (task.Status != "pending" && task.Status != "waiting") {
+			continue
+		}
+		if next == nil || task.Due < next.Due {
+			next = task
+		}
+	}
+	return next
+}
@@ -0,0 +1,46 @@
+package tw
+
+import (
+	"ccsync_backend/models"
+	"ccsync_backend/utils"
+	"fmt"
+	"os"
+)
+
+// StartTaskInTaskwarrior starts work on a task, making it active until it is stopped,
+// and syncs
+func StartTaskInTaskwarrior(email, encryptionSecret, uuid, taskuuid string) error {
+	if err := utils.ExecCommand("rm", "-rf", "/root/.task"); err != nil {
+		return fmt.Errorf("error deleting Taskwarrior data: %v", err)
+	}
+	tempDir, err := os.MkdirTemp("", utils.SafeTempDirPrefix("taskwarrior-", email))
+	if err != nil {
+		return fmt.Errorf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	origin := os.Getenv("CONTAINER_ORIGIN")
+	if err := SetTaskwarriorConfig(tempDir, encryptionSecret, origin, uuid); err != nil {
+		return err
+	}
+
+	if err := SyncTaskwarrior(tempDir); err != nil {
+		return err
+	}
+	RecordTaskHistory(tempDir, uuid, models.HistorySourceExternal, "sync")
+
+	// journal.time makes Taskwarrior annotate every start and stop, which is what the
+	// time reports are built from
+	if err := utils.ExecCommandInDir(tempDir, "task", taskuuid, "start", "rc.journal.time=on", "rc.confirmation=off"); err != nil {
+		return fmt.Errorf("failed to start task: %v", err)
+	}
+
+	RecordTaskHistory(tempDir, uuid, models.HistorySourceCCSync, "Start Task")
+
+	// Sync Taskwarrior again
+	if err := SyncTaskwarrior(tempDir); err != nil {
+		return err
+	}
+
+	return nil
+}
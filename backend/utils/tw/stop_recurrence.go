@@ -0,0 +1,46 @@
+package tw
+
+import (
+	"ccsync_backend/models"
+	"ccsync_backend/utils"
+	"fmt"
+	"os"
+)
+
+// ends a recurring series by deleting its template, optionally together with the instances still pending
+func StopRecurrenceInTaskwarrior(email, encryptionSecret, uuid, templateUUID string, deletePending bool) error {
+	if err := utils.ExecCommand("rm", "-rf", "/root/.task"); err != nil {
+		return fmt.Errorf("error deleting Taskwarrior data: %v", err)
+	}
+	tempDir, err := os.MkdirTemp("", utils.SafeTempDirPrefix("taskwarrior-", email))
+	if err != nil {
+		return fmt.Errorf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	origin := os.Getenv("CONTAINER_ORIGIN")
+	if err := SetTaskwarriorConfig(tempDir, encryptionSecret, origin, uuid); err != nil {
+		return err
+	}
+
+	if err := SyncTaskwarrior(tempDir); err != nil {
+		return err
+	}
+	RecordTaskHistory(tempDir, uuid, models.HistorySourceExternal, "sync")
+
+	propagate := "rc.recurrence.confirmation=no"
+	if deletePending {
+		propagate = "rc.recurrence.confirmation=yes"
+	}
+	if err := utils.ExecCommandInDir(tempDir, "task", "rc.confirmation=off", propagate, templateUUID, "delete"); err != nil {
+		return fmt.Errorf("failed to stop recurrence: %v", err)
+	}
+
+	RecordTaskHistory(tempDir, uuid, models.HistorySourceCCSync, "Stop Recurrence")
+
+	if err := SyncTaskwarrior(tempDir); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -2,12 +2,18 @@ package tw
 
 import (
 	"ccsync_backend/models"
+	"ccsync_backend/utils"
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestSetTaskwarriorConfig(t *testing.T) {
-	err := SetTaskwarriorConfig("./", "encryption_secret", "container_origin", "client_id")
+	err := SetTaskwarriorConfig(t.TempDir(), "encryption_secret", "container_origin", "client_id")
 	if err != nil {
 		t.Errorf("SetTaskwarriorConfig() failed: %v", err)
 	} else {
@@ -25,7 +31,7 @@ func TestSyncTaskwarrior(t *testing.T) {
 }
 
 func TestEditTaskInATaskwarrior(t *testing.T) {
-	err := EditTaskInTaskwarrior("uuid", "taskuuid", "email", "encryptionSecret", "description", "project", "2025-11-29T18:30:00.000Z", "2025-11-29T18:30:00.000Z", "2025-11-29T18:30:00.000Z", "2025-11-30T18:30:00.000Z", "2025-12-01T18:30:00.000Z", "weekly", []string{}, []string{}, []models.Annotation{{Description: "test annotation"}})
+	err := EditTaskInTaskwarrior("uuid", "taskuuid", "email", "encryptionSecret", "description", "project", "2025-11-29T18:30:00.000Z", "2025-11-29T18:30:00.000Z", "2025-11-29T18:30:00.000Z", "2025-11-30T18:30:00.000Z", "2025-12-01T18:30:00.000Z", "weekly", []string{}, []string{}, []models.Annotation{{Description: "test annotation"}}, Precondition{})
 	if err != nil {
 		t.Errorf("EditTaskInTaskwarrior() failed: %v", err)
 	} else {
@@ -118,7 +124,7 @@ func TestAddTaskToTaskwarriorWithEntryDate(t *testing.T) {
 }
 
 func TestCompleteTaskInTaskwarrior(t *testing.T) {
-	err := CompleteTaskInTaskwarrior("email", "encryptionSecret", "client_id", "taskuuid")
+	err := CompleteTaskInTaskwarrior("email", "encryptionSecret", "client_id", "taskuuid", "", Precondition{})
 	if err != nil {
 		t.Errorf("CompleteTaskInTaskwarrior failed: %v", err)
 	} else {
@@ -202,7 +208,7 @@ func TestAddTaskToTaskwarriorWithWaitDateWithTags(t *testing.T) {
 }
 
 func TestEditTaskWithTagAddition(t *testing.T) {
-	err := EditTaskInTaskwarrior("uuid", "taskuuid", "email", "encryptionSecret", "description", "project", "2025-11-29T18:30:00.000Z", "2025-11-29T18:30:00.000Z", "2025-11-29T18:30:00.000Z", "2025-11-30T18:30:00.000Z", "2025-12-01T18:30:00.000Z", "daily", []string{"+urgent", "+important"}, []string{}, []models.Annotation{})
+	err := EditTaskInTaskwarrior("uuid", "taskuuid", "email", "encryptionSecret", "description", "project", "2025-11-29T18:30:00.000Z", "2025-11-29T18:30:00.000Z", "2025-11-29T18:30:00.000Z", "2025-11-30T18:30:00.000Z", "2025-12-01T18:30:00.000Z", "daily", []string{"+urgent", "+important"}, []string{}, []models.Annotation{}, Precondition{})
 	if err != nil {
 		t.Errorf("EditTaskInTaskwarrior with tag addition failed: %v", err)
 	} else {
@@ -211,7 +217,7 @@ func TestEditTaskWithTagAddition(t *testing.T) {
 }
 
 func TestEditTaskWithTagRemoval(t *testing.T) {
-	err := EditTaskInTaskwarrior("uuid", "taskuuid", "email", "encryptionSecret", "description", "project", "2025-11-29T18:30:00.000Z", "2025-11-29T18:30:00.000Z", "2025-11-29T18:30:00.000Z", "2025-11-30T18:30:00.000Z", "2025-12-01T18:30:00.000Z", "monthly", []string{"-work", "-lowpriority"}, []string{}, []models.Annotation{})
+	err := EditTaskInTaskwarrior("uuid", "taskuuid", "email", "encryptionSecret", "description", "project", "2025-11-29T18:30:00.000Z", "2025-11-29T18:30:00.000Z", "2025-11-29T18:30:00.000Z", "2025-11-30T18:30:00.000Z", "2025-12-01T18:30:00.000Z", "monthly", []string{"-work", "-lowpriority"}, []string{}, []models.Annotation{}, Precondition{})
 	if err != nil {
 		t.Errorf("EditTaskInTaskwarrior with tag removal failed: %v", err)
 	} else {
@@ -220,7 +226,7 @@ func TestEditTaskWithTagRemoval(t *testing.T) {
 }
 
 func TestEditTaskWithMixedTagOperations(t *testing.T) {
-	err := EditTaskInTaskwarrior("uuid", "taskuuid", "email", "encryptionSecret", "description", "project", "2025-11-29T18:30:00.000Z", "2025-11-29T18:30:00.000Z", "2025-11-29T18:30:00.000Z", "2025-11-30T18:30:00.000Z", "2025-12-01T18:30:00.000Z", "yearly", []string{"+urgent", "-work", "normal"}, []string{}, []models.Annotation{})
+	err := EditTaskInTaskwarrior("uuid", "taskuuid", "email", "encryptionSecret", "description", "project", "2025-11-29T18:30:00.000Z", "2025-11-29T18:30:00.000Z", "2025-11-29T18:30:00.000Z", "2025-11-30T18:30:00.000Z", "2025-12-01T18:30:00.000Z", "yearly", []string{"+urgent", "-work", "normal"}, []string{}, []models.Annotation{}, Precondition{})
 	if err != nil {
 		t.Errorf("EditTaskInTaskwarrior with mixed tag operations failed: %v", err)
 	} else {
@@ -229,7 +235,7 @@ func TestEditTaskWithMixedTagOperations(t *testing.T) {
 }
 
 func TestModifyTaskWithTags(t *testing.T) {
-	err := ModifyTaskInTaskwarrior("uuid", "description", "project", "H", "pending", "2025-03-03", "email", "encryptionSecret", "taskuuid", []string{"+urgent", "-work", "normal"}, []string{})
+	err := ModifyTaskInTaskwarrior("uuid", "description", "project", "H", "pending", "2025-03-03", "email", "encryptionSecret", "taskuuid", []string{"+urgent", "-work", "normal"}, []string{}, Precondition{})
 	if err != nil {
 		t.Errorf("ModifyTaskInTaskwarrior with tags failed: %v", err)
 	} else {
@@ -257,6 +263,7 @@ func TestRemoveDependencyFromMultipleDependencies(t *testing.T) {
 		taskID,
 		[]string{},
 		initialDeps,
+		Precondition{},
 	)
 	if err != nil {
 		t.Fatalf("failed to set initial dependencies: %v", err)
@@ -274,6 +281,7 @@ func TestRemoveDependencyFromMultipleDependencies(t *testing.T) {
 		taskID,
 		[]string{},
 		updatedDeps,
+		Precondition{},
 	)
 	if err != nil {
 		t.Fatalf("failed to update dependencies: %v", err)
@@ -307,3 +315,270 @@ func TestRemoveDependencyFromMultipleDependencies(t *testing.T) {
 		t.Fatalf("task %s not found in export", taskID)
 	}
 }
+
+func TestAddAnnotationInTaskwarrior(t *testing.T) {
+	err := AddAnnotationInTaskwarrior("email", "encryptionSecret", "uuid", "taskuuid", "call back on monday")
+	if err != nil {
+		t.Errorf("AddAnnotationInTaskwarrior failed: %v", err)
+	} else {
+		fmt.Println("Add annotation passed")
+	}
+}
+
+func TestDeleteAnnotationInTaskwarrior(t *testing.T) {
+	err := DeleteAnnotationInTaskwarrior("email", "encryptionSecret", "uuid", "taskuuid", "20250301T120000Z", "")
+	if err != nil {
+		t.Errorf("DeleteAnnotationInTaskwarrior failed: %v", err)
+	} else {
+		fmt.Println("Delete annotation passed")
+	}
+}
+
+func TestCompleteTaskWithStalePrecondition(t *testing.T) {
+	err := CompleteTaskInTaskwarrior("email", "encryptionSecret", "client_id", "taskuuid", "", Precondition{ExpectedModified: "20200101T000000Z"})
+	if err == nil {
+		t.Errorf("CompleteTaskInTaskwarrior with a stale precondition should fail")
+	} else {
+		fmt.Println("Complete task with stale precondition passed")
+	}
+}
+
+func TestSameTimestamp(t *testing.T) {
+	if !sameTimestamp("20250301T120000Z", `"2025-03-01T12:00:00Z"`) {
+		t.Errorf("expected compact and ISO timestamps to match")
+	}
+	if !sameTimestamp("20250301T120000Z", "2025-03-01T12:00:00.000Z") {
+		t.Errorf("expected millisecond ISO timestamp to match")
+	}
+	if sameTimestamp("20250301T120000Z", "20250301T120001Z") {
+		t.Errorf("expected different timestamps not to match")
+	}
+}
+
+func TestTagChanges(t *testing.T) {
+	changes := tagChanges([]string{"work", "someday"}, []string{"work", "urgent"})
+	expected := []string{"+urgent", "-someday"}
+	if len(changes) != len(expected) || changes[0] != expected[0] || changes[1] != expected[1] {
+		t.Errorf("tagChanges() = %v, want %v", changes, expected)
+	}
+}
+
+func TestApplyBatchInTaskwarrior(t *testing.T) {
+	operations := []models.BatchOperation{
+		{Op: models.BatchOpAdd, Ref: "setup", Description: "set up the project"},
+		{Op: models.BatchOpAdd, Description: "write the docs", Depends: []string{"$setup"}},
+		{Op: models.BatchOpAnnotate, TaskUUID: "$setup", Description: "started on monday"},
+	}
+	results, err := ApplyBatchInTaskwarrior("email", "encryptionSecret", "client_id", operations)
+	if err != nil {
+		t.Errorf("ApplyBatchInTaskwarrior failed: %v (%v)", err, results)
+	} else {
+		fmt.Println("Apply batch passed")
+	}
+}
+
+func TestResolveBatchRef(t *testing.T) {
+	refs := map[string]string{"setup": "a1b2c3d4-0000-0000-0000-000000000000"}
+	if got, err := resolveBatchRef("$setup", refs); err != nil || got != refs["setup"] {
+		t.Errorf("resolveBatchRef($setup) = %q, %v", got, err)
+	}
+	if got, err := resolveBatchRef("e5f6", refs); err != nil || got != "e5f6" {
+		t.Errorf("resolveBatchRef(e5f6) = %q, %v", got, err)
+	}
+	if _, err := resolveBatchRef("$missing", refs); err == nil {
+		t.Errorf("expected an unknown ref to fail")
+	}
+}
+
+func TestModifyTaskArgs(t *testing.T) {
+	args := modifyTaskArgs("t1", `say "hi"`, "home", "H", "", []string{"urgent", "-someday"}, []string{"a", "b"})
+	expected := []string{"t1", "modify", `description:"say \"hi\""`, "project:home", "priority:H", "due:", "depends:a,b", "+urgent", "-someday"}
+	if strings.Join(args, " ") != strings.Join(expected, " ") {
+		t.Errorf("modifyTaskArgs() = %v, want %v", args, expected)
+	}
+}
+
+func TestBulkPatchArgs(t *testing.T) {
+	project := "sprint-12"
+	task := models.Task{UUID: "t1", Due: "20250131T090000Z"}
+	args, err := bulkPatchArgs(task, models.BulkTaskPatch{Project: &project, AddTags: []string{"review me"}, RemoveTags: []string{"someday"}, DueShift: "+1mo"})
+	expected := []string{"project:sprint-12", "+review_me", "-someday", "due:20250228T090000Z"}
+	if err != nil || strings.Join(args, " ") != strings.Join(expected, " ") {
+		t.Errorf("bulkPatchArgs() = %v, %v, want %v", args, err, expected)
+	}
+
+	if _, err := bulkPatchArgs(models.Task{UUID: "t2"}, models.BulkTaskPatch{DueShift: "-2d"}); err == nil {
+		t.Errorf("expected shifting a task without a due date to fail")
+	}
+}
+
+func TestDeletedBefore(t *testing.T) {
+	tasks := []models.Task{
+		{UUID: "old", Status: "deleted", End: "20250101T000000Z"},
+		{UUID: "recent", Status: "deleted", End: "20250601T000000Z"},
+		{UUID: "no-end", Status: "deleted"},
+		{UUID: "done", Status: "completed", End: "20250101T000000Z"},
+	}
+	cutoff := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	if got := DeletedBefore(tasks, cutoff); len(got) != 1 || got[0] != "old" {
+		t.Errorf("DeletedBefore() = %v, want [old]", got)
+	}
+}
+
+func TestReopenConflicts(t *testing.T) {
+	tasks := []models.Task{
+		{UUID: "a", Status: "completed", Depends: []string{"b"}},
+		{UUID: "b", Status: "pending", Depends: []string{"a"}},
+		{UUID: "c", Status: "completed", Depends: []string{"d"}},
+		{UUID: "d", Status: "completed"},
+		{UUID: "e", Status: "pending"},
+	}
+	conflicts := reopenConflicts(tasks, []string{"a", "c", "e", "x"})
+
+	if _, ok := conflicts["a"]; !ok {
+		t.Errorf("expected reopening a to close a cycle with b")
+	}
+	if _, ok := conflicts["c"]; ok {
+		t.Errorf("expected c to be reopenable, got %v", conflicts["c"])
+	}
+	if conflicts["e"] == "" || conflicts["x"] != "task not found" {
+		t.Errorf("expected pending and unknown tasks to be refused, got %v", conflicts)
+	}
+}
+
+func TestCascaderRefuse(t *testing.T) {
+	c := &cascader{
+		mode: models.CascadeRefuse,
+		graph: utils.NewDependencyGraph([]utils.TaskDependency{
+			{UUID: "a", Status: "pending"},
+			{UUID: "b", Status: "pending", Depends: []string{"a"}},
+			{UUID: "c", Status: "pending", Depends: []string{"a"}},
+		}),
+		targets: map[string]bool{"a": true, "b": true},
+	}
+
+	err := c.apply("a")
+	var refused *DependentsError
+	if !errors.As(err, &refused) {
+		t.Fatalf("apply() error = %v, want a DependentsError", err)
+	}
+	if len(refused.Dependents) != 1 || refused.Dependents[0] != "c" {
+		t.Errorf("Dependents = %v, want [c]: b is completed along with a", refused.Dependents)
+	}
+	if err := c.apply("c"); err != nil {
+		t.Errorf("apply() on a task without dependents = %v, want nil", err)
+	}
+}
+
+func TestReplicaTaskrc(t *testing.T) {
+	taskrc := replicaTaskrc(map[string]string{"weekstart": "monday", "uda.estimate.type": "numeric"})
+	if taskrc != "uda.estimate.type=numeric\nweekstart=monday\n" {
+		t.Errorf("replicaTaskrc() = %q", taskrc)
+	}
+	if taskrc := replicaTaskrc(nil); taskrc != "" {
+		t.Errorf("replicaTaskrc(nil) = %q, want empty", taskrc)
+	}
+}
+
+// fakeTask stands in for the task binary: exports return a single task and every other
+// command is logged along with the content of the taskrc it was given
+const fakeTask = `#!/bin/sh
+case " $* " in
+*" export "*) echo '[{"uuid":"t1","description":"x","status":"pending"}]' ;;
+*) echo "$(tr '\n' ' ' < "$TASKRC")| $*" >> "$TASK_LOG" ;;
+esac
+`
+
+func TestEditAndModifyUseReplicaTaskrc(t *testing.T) {
+	bin := t.TempDir()
+	if err := os.WriteFile(filepath.Join(bin, "task"), []byte(fakeTask), 0755); err != nil {
+		t.Fatal(err)
+	}
+	log := filepath.Join(t.TempDir(), "task.log")
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv("TASK_LOG", log)
+	t.Setenv("TASKRC", "")
+	models.GetSettingsStore().Update("taskrc-user", func(s *models.UserSettings) {
+		s.Taskrc = map[string]string{"weekstart": "monday"}
+	})
+
+	if err := EditTaskInTaskwarrior("taskrc-user", "t1", "email", "secret", "edited", "", "", "", "", "", "", "", nil, nil, nil, Precondition{}); err != nil {
+		t.Fatalf("EditTaskInTaskwarrior() failed: %v", err)
+	}
+	if err := ModifyTaskInTaskwarrior("taskrc-user", "modified", "", "", "completed", "", "email", "secret", "t1", nil, nil, Precondition{}); err != nil {
+		t.Fatalf("ModifyTaskInTaskwarrior() failed: %v", err)
+	}
+
+	output, err := os.ReadFile(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	commands := 0
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		taskrc, args, _ := strings.Cut(line, "| ")
+		if !strings.Contains(args, " modify ") && !strings.HasSuffix(args, " done rc.confirmation=off") {
+			continue
+		}
+		commands++
+		if !strings.Contains(taskrc, "weekstart=monday") {
+			t.Errorf("task %s ran without the replica's taskrc (got %q)", args, taskrc)
+		}
+	}
+	if commands != 3 {
+		t.Errorf("expected the edit, the modification and the completion to be logged, got %d commands:\n%s", commands, output)
+	}
+}
+
+func TestTodoStatusArgs(t *testing.T) {
+	pending := models.Task{Status: "pending"}
+	completed := models.Task{Status: "completed", Start: "20250313T100000Z"}
+	started := models.Task{Status: "pending", Start: "20250313T100000Z"}
+
+	cases := []struct {
+		current  models.Task
+		todo     models.TodoFields
+		expected string
+	}{
+		{pending, models.TodoFields{Status: "completed", End: "20250314T100000Z"}, "[[done end:20250314T100000Z]]"},
+		{pending, models.TodoFields{Status: "deleted"}, "[[delete]]"},
+		{pending, models.TodoFields{Status: "pending", Started: true}, "[[start]]"},
+		{pending, models.TodoFields{Status: "pending"}, "[]"},
+		{completed, models.TodoFields{Status: "pending"}, "[[modify status:pending end:]]"},
+		{completed, models.TodoFields{Status: "completed"}, "[]"},
+		{started, models.TodoFields{Status: "pending"}, "[[stop]]"},
+	}
+	for _, c := range cases {
+		if got := fmt.Sprint(todoStatusArgs(c.current, c.todo)); got != c.expected {
+			t.Errorf("todoStatusArgs(%v, %v) = %s, want %s", c.current.Status, c.todo, got, c.expected)
+		}
+	}
+}
+
+func TestApplyMergeQuotesDescription(t *testing.T) {
+	bin := t.TempDir()
+	if err := os.WriteFile(filepath.Join(bin, "task"), []byte(fakeTask), 0755); err != nil {
+		t.Fatal(err)
+	}
+	log := filepath.Join(t.TempDir(), "task.log")
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv("TASK_LOG", log)
+	taskrc := filepath.Join(t.TempDir(), ".taskrc")
+	if err := os.WriteFile(taskrc, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TASKRC", taskrc)
+
+	precondition := Precondition{Proposed: &models.Task{Description: `say "hi" project:x`}}
+	merge := models.MergeResult{Applied: []string{"description"}}
+	if err := applyMerge(t.TempDir(), "uuid", "t1", "modify", precondition, merge); err != nil {
+		t.Fatalf("applyMerge() failed: %v", err)
+	}
+
+	output, err := os.ReadFile(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `t1 modify description:"say \"hi\" project:x"`; !strings.Contains(string(output), want) {
+		t.Errorf("applyMerge() ran %q, want %q", output, want)
+	}
+}
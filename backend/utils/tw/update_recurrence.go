@@ -0,0 +1,50 @@
+package tw
+
+import (
+	"ccsync_backend/models"
+	"ccsync_backend/utils"
+	"fmt"
+	"os"
+)
+
+// modifies the recurrence template; recurrence.confirmation=yes carries the change over to every pending instance
+func UpdateRecurrenceInTaskwarrior(email, encryptionSecret, uuid, templateUUID, recur string, until *string) error {
+	if err := utils.ExecCommand("rm", "-rf", "/root/.task"); err != nil {
+		return fmt.Errorf("error deleting Taskwarrior data: %v", err)
+	}
+	tempDir, err := os.MkdirTemp("", utils.SafeTempDirPrefix("taskwarrior-", email))
+	if err != nil {
+		return fmt.Errorf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	origin := os.Getenv("CONTAINER_ORIGIN")
+	if err := SetTaskwarriorConfig(tempDir, encryptionSecret, origin, uuid); err != nil {
+		return err
+	}
+
+	if err := SyncTaskwarrior(tempDir); err != nil {
+		return err
+	}
+	RecordTaskHistory(tempDir, uuid, models.HistorySourceExternal, "sync")
+
+	modifyArgs := []string{"rc.confirmation=off", "rc.recurrence.confirmation=yes", templateUUID, "modify"}
+	if recur != "" {
+		modifyArgs = append(modifyArgs, "recur:"+recur)
+	}
+	if until != nil {
+		modifyArgs = append(modifyArgs, "until:"+*until)
+	}
+
+	if err := utils.ExecCommandInDir(tempDir, "task", modifyArgs...); err != nil {
+		return fmt.Errorf("failed to update recurrence: %v", err)
+	}
+
+	RecordTaskHistory(tempDir, uuid, models.HistorySourceCCSync, "Update Recurrence")
+
+	if err := SyncTaskwarrior(tempDir); err != nil {
+		return err
+	}
+
+	return nil
+}
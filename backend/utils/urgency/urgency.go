@@ -0,0 +1,272 @@
+package urgency
+
+import (
+	"ccsync_backend/models"
+	"ccsync_backend/utils"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Coefficients weigh the terms of the urgency formula, named as in .taskrc without the
+// urgency. prefix and .coefficient suffix: "due", "blocking", "user.tag.next",
+// "user.project.Home", "uda.priority.H", "uda.estimate", ...
+type Coefficients map[string]float64
+
+// maxAge is the age in days at which the age term reaches its full weight (urgency.age.max)
+const maxAge = 365.0
+
+// Defaults returns the coefficients Taskwarrior ships with
+func Defaults() Coefficients {
+	return Coefficients{
+		"user.tag.next":  15.0,
+		"due":            12.0,
+		"blocking":       8.0,
+		"uda.priority.H": 6.0,
+		"uda.priority.M": 3.9,
+		"uda.priority.L": 1.8,
+		"scheduled":      5.0,
+		"active":         4.0,
+		"age":            2.0,
+		"annotations":    1.0,
+		"tags":           1.0,
+		"project":        1.0,
+		"blocked":        -5.0,
+		"waiting":        -3.0,
+	}
+}
+
+// builtinTerms are the coefficients of the task's own attributes
+var builtinTerms = []string{"due", "blocking", "scheduled", "active", "age", "annotations", "tags", "project", "blocked", "waiting"}
+
+var (
+	userTermPattern = regexp.MustCompile(`^user\.(tag|project)\.\S+$`)
+	udaTermPattern  = regexp.MustCompile(`^uda\.[A-Za-z][A-Za-z0-9_]*(\.\S+)?$`)
+)
+
+// Validate checks that every coefficient names a term of the urgency formula and is a
+// finite number
+func Validate(coefficients map[string]float64) error {
+	for name, value := range coefficients {
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			return fmt.Errorf("coefficient '%s' must be a finite number", name)
+		}
+		if !isBuiltinTerm(name) && !userTermPattern.MatchString(name) && !udaTermPattern.MatchString(name) {
+			return fmt.Errorf("unknown urgency coefficient '%s'", name)
+		}
+	}
+	return nil
+}
+
+func isBuiltinTerm(name string) bool {
+	for _, term := range builtinTerms {
+		if term == name {
+			return true
+		}
+	}
+	return false
+}
+
+// WithOverrides returns the default coefficients with the overrides applied
+func WithOverrides(overrides map[string]float64) Coefficients {
+	coefficients := Defaults()
+	for name, value := range overrides {
+		coefficients[name] = value
+	}
+	return coefficients
+}
+
+// Term is one contribution to a task's urgency: the coefficient times a factor between
+// 0 and 1 saying how much the term applies, e.g. 0.8 for a task with one tag
+type Term struct {
+	Name         string  `json:"name"`
+	Factor       float64 `json:"factor"`
+	Coefficient  float64 `json:"coefficient"`
+	Contribution float64 `json:"contribution"`
+}
+
+// Breakdown explains the urgency of a task term by term
+type Breakdown struct {
+	UUID    string  `json:"uuid"`
+	Urgency float64 `json:"urgency"`
+	Terms   []Term  `json:"terms"`
+}
+
+// Calculator computes urgencies the way Taskwarrior does, over a set of tasks so that
+// blocked and blocking tasks can be told apart
+type Calculator struct {
+	coefficients Coefficients
+	graph        *utils.DependencyGraph
+	now          time.Time
+}
+
+// NewCalculator prepares the urgencies of tasks with the given coefficients, at now.
+// The tasks are the user's whole list, which tells which of them are blocked or
+// blocking.
+func NewCalculator(tasks []models.Task, coefficients Coefficients, now time.Time) *Calculator {
+	return &Calculator{
+		coefficients: coefficients,
+		graph:        utils.NewDependencyGraph(models.TaskDependencies(tasks)),
+		now:          now,
+	}
+}
+
+// Apply sets the urgency of every task with the calculator
+func Apply(tasks []models.Task, coefficients Coefficients, now time.Time) {
+	calculator := NewCalculator(tasks, coefficients, now)
+	for i := range tasks {
+		tasks[i].Urgency = float32(calculator.Explain(tasks[i]).Urgency)
+	}
+}
+
+// Explain computes the urgency of the task, listing the terms that contribute to it
+// in decreasing order of weight
+func (c *Calculator) Explain(task models.Task) Breakdown {
+	breakdown := Breakdown{UUID: task.UUID, Terms: []Term{}}
+	add := func(name string, factor float64) {
+		coefficient, ok := c.coefficients[name]
+		if !ok || coefficient == 0 || factor == 0 {
+			return
+		}
+		breakdown.Terms = append(breakdown.Terms, Term{
+			Name:         name,
+			Factor:       factor,
+			Coefficient:  coefficient,
+			Contribution: coefficient * factor,
+		})
+	}
+
+	add("due", c.dueFactor(task))
+	add("blocking", boolFactor(c.graph.Blocking(task.UUID)))
+	add("blocked", boolFactor(c.graph.Blocked(task.UUID)))
+	add("scheduled", boolFactor(c.before(task.Scheduled)))
+	add("active", boolFactor(task.Start != ""))
+	add("age", c.ageFactor(task))
+	add("annotations", countFactor(len(task.Annotations)))
+	add("tags", countFactor(len(task.Tags)))
+	add("project", boolFactor(task.Project != ""))
+	add("waiting", boolFactor(task.Status == "waiting" || (task.Status == "pending" && c.after(task.Wait))))
+
+	for name := range c.coefficients {
+		switch {
+		case strings.HasPrefix(name, "user.tag."):
+			add(name, boolFactor(hasTag(task, strings.TrimPrefix(name, "user.tag."))))
+		case strings.HasPrefix(name, "user.project."):
+			project := strings.TrimPrefix(name, "user.project.")
+			add(name, boolFactor(task.Project != "" && strings.HasPrefix(task.Project, project)))
+		case strings.HasPrefix(name, "uda."):
+			add(name, boolFactor(matchesUDA(task, strings.TrimPrefix(name, "uda."))))
+		}
+	}
+
+	sort.SliceStable(breakdown.Terms, func(i, j int) bool {
+		if a, b := math.Abs(breakdown.Terms[i].Contribution), math.Abs(breakdown.Terms[j].Contribution); a != b {
+			return a > b
+		}
+		return breakdown.Terms[i].Name < breakdown.Terms[j].Name
+	})
+	for _, term := range breakdown.Terms {
+		breakdown.Urgency += term.Contribution
+	}
+	breakdown.Urgency = math.Round(breakdown.Urgency*1e4) / 1e4
+	return breakdown
+}
+
+// dueFactor grows linearly from 0.2 two weeks before the due date to 1 a week after it
+func (c *Calculator) dueFactor(task models.Task) float64 {
+	due, ok := parseDate(task.Due)
+	if !ok {
+		return 0
+	}
+	daysOverdue := c.now.Sub(due).Hours() / 24
+	switch {
+	case daysOverdue >= 7:
+		return 1
+	case daysOverdue >= -14:
+		return (daysOverdue+14)*0.8/21 + 0.2
+	default:
+		return 0.2
+	}
+}
+
+// ageFactor grows linearly with the age of the task up to maxAge days
+func (c *Calculator) ageFactor(task models.Task) float64 {
+	entry, ok := parseDate(task.Entry)
+	if !ok {
+		return 0
+	}
+	age := c.now.Sub(entry).Hours() / 24
+	if age >= maxAge {
+		return 1
+	}
+	return math.Max(age, 0) / maxAge
+}
+
+func (c *Calculator) before(value string) bool {
+	parsed, ok := parseDate(value)
+	return ok && parsed.Before(c.now)
+}
+
+func (c *Calculator) after(value string) bool {
+	parsed, ok := parseDate(value)
+	return ok && parsed.After(c.now)
+}
+
+// countFactor weighs tags and annotations: 0.8 for one, 0.9 for two, 1 for more
+func countFactor(count int) float64 {
+	switch {
+	case count == 0:
+		return 0
+	case count == 1:
+		return 0.8
+	case count == 2:
+		return 0.9
+	default:
+		return 1
+	}
+}
+
+func boolFactor(value bool) float64 {
+	if value {
+		return 1
+	}
+	return 0
+}
+
+func hasTag(task models.Task, tag string) bool {
+	for _, t := range task.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesUDA reports whether the task has a value for the attribute ("estimate"), or
+// the given value ("priority.H")
+func matchesUDA(task models.Task, term string) bool {
+	name, value, withValue := strings.Cut(term, ".")
+
+	var actual string
+	if name == "priority" {
+		actual = task.Priority
+	} else if raw, ok := task.UDAs[name]; ok && raw != nil {
+		actual = fmt.Sprint(raw)
+	}
+
+	if withValue {
+		return actual == value
+	}
+	return actual != ""
+}
+
+func parseDate(value string) (time.Time, bool) {
+	if value == "" {
+		return time.Time{}, false
+	}
+	parsed, err := time.Parse(utils.TaskwarriorDateLayout, value)
+	return parsed, err == nil
+}
@@ -0,0 +1,110 @@
+package urgency
+
+import (
+	"ccsync_backend/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testNow = time.Date(2025, 3, 15, 12, 0, 0, 0, time.UTC)
+
+func contributions(breakdown Breakdown) map[string]float64 {
+	terms := make(map[string]float64)
+	for _, term := range breakdown.Terms {
+		terms[term.Name] = term.Contribution
+	}
+	return terms
+}
+
+func Test_Explain_Defaults(t *testing.T) {
+	tasks := []models.Task{
+		{
+			UUID:     "a",
+			Status:   "pending",
+			Due:      "20250301T120000Z", // two weeks overdue
+			Entry:    "20240315T120000Z", // a year old
+			Priority: "H",
+			Tags:     []string{"next", "home"},
+			Project:  "Home",
+			Start:    "20250314T090000Z",
+		},
+		{UUID: "b", Status: "pending", Depends: []string{"a"}, Entry: "20250315T120000Z"},
+	}
+	calculator := NewCalculator(tasks, Defaults(), testNow)
+
+	breakdown := calculator.Explain(tasks[0])
+	assert.Equal(t, map[string]float64{
+		"user.tag.next":  15,
+		"due":            12,
+		"blocking":       8,
+		"uda.priority.H": 6,
+		"active":         4,
+		"age":            2,
+		"tags":           0.9,
+		"project":        1,
+	}, contributions(breakdown))
+	assert.InDelta(t, 48.9, breakdown.Urgency, 1e-9)
+	assert.Equal(t, "user.tag.next", breakdown.Terms[0].Name, "terms are ordered by weight")
+
+	assert.Equal(t, map[string]float64{"blocked": -5}, contributions(calculator.Explain(tasks[1])))
+}
+
+func Test_Explain_DueAndWaiting(t *testing.T) {
+	tests := []struct {
+		due      string
+		expected float64
+	}{
+		{"20250408T120000Z", 0.2 * 12},              // more than two weeks ahead
+		{"20250322T120000Z", (7*0.8/21 + 0.2) * 12}, // a week ahead
+		{"20250315T120000Z", (14*0.8/21 + 0.2) * 12},
+	}
+	for _, tt := range tests {
+		breakdown := NewCalculator(nil, Defaults(), testNow).Explain(models.Task{Status: "pending", Due: tt.due})
+		assert.InDelta(t, tt.expected, breakdown.Urgency, 1e-4, tt.due)
+	}
+
+	waiting := models.Task{Status: "pending", Wait: "20250320T000000Z", Scheduled: "20250310T000000Z"}
+	assert.Equal(t, map[string]float64{"waiting": -3, "scheduled": 5}, contributions(NewCalculator(nil, Defaults(), testNow).Explain(waiting)))
+}
+
+func Test_Explain_Overrides(t *testing.T) {
+	coefficients := WithOverrides(map[string]float64{
+		"user.tag.next":        0,
+		"user.project.Work":    4,
+		"uda.estimate":         0.5,
+		"uda.priority.H":       10,
+		"uda.complexity.large": 2,
+	})
+	task := models.Task{
+		Status:   "pending",
+		Tags:     []string{"next"},
+		Project:  "Work.Reports",
+		Priority: "H",
+		UDAs:     map[string]interface{}{"estimate": 3.0, "complexity": "large"},
+	}
+
+	assert.Equal(t, map[string]float64{
+		"tags":                 0.8,
+		"project":              1,
+		"user.project.Work":    4,
+		"uda.estimate":         0.5,
+		"uda.priority.H":       10,
+		"uda.complexity.large": 2,
+	}, contributions(NewCalculator(nil, coefficients, testNow).Explain(task)))
+}
+
+func Test_Apply(t *testing.T) {
+	tasks := []models.Task{{UUID: "a", Status: "pending", Project: "Home"}}
+	Apply(tasks, WithOverrides(map[string]float64{"project": 2.5}), testNow)
+	assert.Equal(t, float32(2.5), tasks[0].Urgency)
+}
+
+func Test_Validate(t *testing.T) {
+	assert.NoError(t, Validate(map[string]float64{
+		"due": 10, "user.tag.urgent": 5, "user.project.Home.Garden": 1, "uda.priority.H": 7, "uda.estimate": 1,
+	}))
+	assert.ErrorContains(t, Validate(map[string]float64{"dues": 1}), "unknown urgency coefficient 'dues'")
+	assert.ErrorContains(t, Validate(map[string]float64{"user.tag.": 1}), "unknown urgency coefficient")
+}
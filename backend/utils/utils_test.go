@@ -5,6 +5,7 @@ import (
 	"encoding/hex"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
@@ -124,6 +125,61 @@ func Test_detectCycle_ComplexCycle(t *testing.T) { // A -> B -> C -> A
 	hasCycle := detectCycle(graph, "A")
 	assert.True(t, hasCycle, "Should detect complex cycle A -> B -> C -> A")
 }
+
+func Test_DependencyGraph(t *testing.T) { // D -> C -> B -> A, D -> A, C -> X (deleted), B -> Y (unknown)
+	graph := NewDependencyGraph([]TaskDependency{
+		{UUID: "A", Status: "pending"},
+		{UUID: "B", Status: "pending", Depends: []string{"A", "Y"}},
+		{UUID: "C", Status: "waiting", Depends: []string{"B", "X", "E"}},
+		{UUID: "D", Status: "pending", Depends: []string{"C", "A"}},
+		{UUID: "E", Status: "completed"},
+		{UUID: "X", Status: "deleted"},
+	})
+
+	assert.False(t, graph.Blocked("A"))
+	assert.True(t, graph.Blocking("A"))
+	assert.True(t, graph.Blocked("D"))
+	assert.False(t, graph.Blocking("D"))
+	assert.False(t, graph.Blocking("E"), "closed tasks block nothing")
+
+	assert.Equal(t, []string{"A", "B", "C", "D"}, graph.CriticalPath("D"))
+	assert.Equal(t, []string{"A"}, graph.CriticalPath("A"))
+
+	assert.Equal(t, []DanglingDependency{
+		{Task: "B", DependsOn: "Y", Reason: "unknown"},
+		{Task: "C", DependsOn: "X", Reason: "deleted"},
+	}, graph.Dangling())
+
+	assert.False(t, graph.HasCycleFrom("D"))
+	graph.SetDepends("A", []string{"D"})
+	assert.True(t, graph.HasCycleFrom("D"))
+	assert.True(t, graph.Blocked("A"))
+}
+
+func Test_DependencyGraph_Dependents(t *testing.T) { // C -> B -> A, D -> A, E (completed) -> A
+	graph := NewDependencyGraph([]TaskDependency{
+		{UUID: "A", Status: "pending"},
+		{UUID: "B", Status: "pending", Depends: []string{"A"}},
+		{UUID: "C", Status: "waiting", Depends: []string{"B"}},
+		{UUID: "D", Status: "pending", Depends: []string{"A"}},
+		{UUID: "E", Status: "completed", Depends: []string{"A"}},
+	})
+
+	assert.Equal(t, []string{"B", "D"}, graph.Dependents("A"))
+	assert.Equal(t, []string{"B", "D", "C"}, graph.TransitiveDependents("A"))
+	assert.Empty(t, graph.TransitiveDependents("C"))
+}
+
+func Test_ValidateCircularDependencies(t *testing.T) {
+	existing := []TaskDependency{
+		{UUID: "A", Status: "pending", Depends: []string{"B"}},
+		{UUID: "B", Status: "pending"},
+		{UUID: "C", Status: "completed", Depends: []string{"B"}},
+	}
+	assert.Error(t, ValidateCircularDependencies([]string{"A"}, "B", existing))
+	assert.NoError(t, ValidateCircularDependencies([]string{"C"}, "B", existing), "completed tasks do not take part in cycles")
+	assert.NoError(t, ValidateCircularDependencies(nil, "B", existing))
+}
 func TestConvertISOToTaskwarriorFormat(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -134,19 +190,25 @@ func TestConvertISOToTaskwarriorFormat(t *testing.T) {
 		{
 			name:     "ISO datetime with milliseconds (frontend format)",
 			input:    "2025-12-27T14:30:00.000Z",
-			expected: "2025-12-27T14:30:00",
+			expected: "20251227T143000Z",
 			hasError: false,
 		},
 		{
 			name:     "ISO datetime at midnight (explicit datetime)",
 			input:    "2025-12-27T00:00:00.000Z",
-			expected: "2025-12-27T00:00:00",
+			expected: "20251227T000000Z",
+			hasError: false,
+		},
+		{
+			name:     "ISO datetime with offset is converted to UTC",
+			input:    "2025-12-27T15:30:00+01:00",
+			expected: "20251227T143000Z",
 			hasError: false,
 		},
 		{
 			name:     "Date only format",
 			input:    "2025-12-27",
-			expected: "2025-12-27",
+			expected: "20251227T000000Z",
 			hasError: false,
 		},
 		{
@@ -164,19 +226,19 @@ func TestConvertISOToTaskwarriorFormat(t *testing.T) {
 		{
 			name:     "Compact ISO datetime format (Taskwarrior export)",
 			input:    "20260128T000000Z",
-			expected: "2026-01-28T00:00:00",
+			expected: "20260128T000000Z",
 			hasError: false,
 		},
 		{
 			name:     "Compact ISO datetime format with time",
 			input:    "20260128T143000Z",
-			expected: "2026-01-28T14:30:00",
+			expected: "20260128T143000Z",
 			hasError: false,
 		},
 		{
 			name:     "Compact date only format",
 			input:    "20260128",
-			expected: "2026-01-28",
+			expected: "20260128T000000Z",
 			hasError: false,
 		},
 	}
@@ -194,3 +256,203 @@ func TestConvertISOToTaskwarriorFormat(t *testing.T) {
 		})
 	}
 }
+
+func Test_ParseDateExpression(t *testing.T) {
+	now := time.Date(2025, 3, 15, 10, 0, 0, 0, time.UTC) // a Saturday
+	tests := []struct {
+		value    string
+		expected time.Time
+		wholeDay bool
+	}{
+		{"tomorrow", time.Date(2025, 3, 16, 0, 0, 0, 0, time.UTC), true},
+		{"tomorrow 9am", time.Date(2025, 3, 16, 9, 0, 0, 0, time.UTC), false},
+		{"Tomorrow at 5:30 pm", time.Date(2025, 3, 16, 17, 30, 0, 0, time.UTC), false},
+		{"noon", time.Date(2025, 3, 15, 12, 0, 0, 0, time.UTC), false},
+		{"17:45", time.Date(2025, 3, 15, 17, 45, 0, 0, time.UTC), false},
+		{"next monday", time.Date(2025, 3, 17, 0, 0, 0, 0, time.UTC), true},
+		{"saturday", time.Date(2025, 3, 22, 0, 0, 0, 0, time.UTC), true},
+		{"friday 12am", time.Date(2025, 3, 21, 0, 0, 0, 0, time.UTC), false},
+		{"eow", time.Date(2025, 3, 16, 23, 59, 59, 0, time.UTC), false},
+		{"sonw", time.Date(2025, 3, 17, 0, 0, 0, 0, time.UTC), true},
+		{"eopm", time.Date(2025, 2, 28, 23, 59, 59, 0, time.UTC), false},
+		{"eoww", time.Date(2025, 3, 14, 23, 59, 59, 0, time.UTC), false},
+		{"next month", time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC), true},
+		{"march", time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), true},
+		{"june", time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC), true},
+		{"15th", time.Date(2025, 4, 15, 0, 0, 0, 0, time.UTC), true},
+		{"31st", time.Date(2025, 3, 31, 0, 0, 0, 0, time.UTC), true},
+		{"easter", time.Date(2025, 4, 20, 0, 0, 0, 0, time.UTC), true},
+		{"goodfriday", time.Date(2025, 4, 18, 0, 0, 0, 0, time.UTC), true},
+		{"someday", time.Date(9999, 12, 30, 0, 0, 0, 0, time.UTC), true},
+		{"+3d", time.Date(2025, 3, 18, 10, 0, 0, 0, time.UTC), false},
+		{"in 2 weeks", time.Date(2025, 3, 29, 10, 0, 0, 0, time.UTC), false},
+		{"3 days ago", time.Date(2025, 3, 12, 10, 0, 0, 0, time.UTC), false},
+		{"2025-04-01 9am", time.Date(2025, 4, 1, 9, 0, 0, 0, time.UTC), false},
+		{"20250401T083000Z", time.Date(2025, 4, 1, 8, 30, 0, 0, time.UTC), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			resolved, wholeDay, err := ParseDateExpression(tt.value, now)
+			assert.NoError(t, err)
+			assert.True(t, tt.expected.Equal(resolved), "expected %v, got %v", tt.expected, resolved)
+			assert.Equal(t, tt.wholeDay, wholeDay)
+		})
+	}
+
+	for _, invalid := range []string{"", "whenever", "tomorrow 25:00", "now 9am", "32nd", "13pm", "3"} {
+		_, _, err := ParseDateExpression(invalid, now)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func Test_ParseDateExpression_Timezone(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	assert.NoError(t, err)
+	now := time.Date(2025, 3, 15, 23, 30, 0, 0, time.UTC).In(berlin) // already the 16th in Berlin
+
+	resolved, _, err := ParseDateExpression("tomorrow 9am", now)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2025, 3, 17, 8, 0, 0, 0, time.UTC), resolved.UTC())
+
+	// a literal Z is UTC whatever the location
+	resolved, _, err = ParseDateExpression("2025-04-01T08:30:00Z", now)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2025, 4, 1, 8, 30, 0, 0, time.UTC), resolved.UTC())
+}
+
+func Test_NormalizeDateInput_AcrossDST(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		now      time.Time
+		value    string
+		expected string
+	}{
+		// clocks go forward on 2025-03-30 at 02:00 CET
+		{"day before the change", time.Date(2025, 3, 29, 12, 0, 0, 0, berlin), "2025-03-29", "20250328T230000Z"},
+		{"day of the change", time.Date(2025, 3, 29, 12, 0, 0, 0, berlin), "2025-03-31", "20250330T220000Z"},
+		{"time of day after the change", time.Date(2025, 3, 29, 12, 0, 0, 0, berlin), "tomorrow 9am", "20250330T070000Z"},
+		{"duration keeps the wall clock", time.Date(2025, 3, 29, 12, 0, 0, 0, berlin), "+1d", "20250330T100000Z"},
+		{"skipped hour", time.Date(2025, 3, 29, 12, 0, 0, 0, berlin), "2025-03-30 2:30", "20250330T013000Z"},
+		// clocks go back on 2025-10-26 at 03:00 CEST
+		{"end of day before the change", time.Date(2025, 10, 25, 12, 0, 0, 0, berlin), "eod", "20251025T215959Z"},
+		{"end of the long day", time.Date(2025, 10, 26, 12, 0, 0, 0, berlin), "eod", "20251026T225959Z"},
+		{"time of day after the change", time.Date(2025, 10, 25, 12, 0, 0, 0, berlin), "tomorrow 9am", "20251026T080000Z"},
+		{"explicit UTC is kept", time.Date(2025, 10, 25, 12, 0, 0, 0, berlin), "2025-10-26T01:30:00Z", "20251026T013000Z"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			normalized, err := NormalizeDateInput(tt.value, tt.now)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, normalized)
+		})
+	}
+}
+
+func Test_NormalizeDateInput(t *testing.T) {
+	now := time.Date(2025, 3, 15, 10, 0, 0, 0, time.UTC)
+
+	normalized, err := NormalizeDateInput("2025-12-27T14:30:00.000Z", now)
+	assert.NoError(t, err)
+	assert.Equal(t, "20251227T143000Z", normalized)
+
+	normalized, err = NormalizeDateInput("eod", now)
+	assert.NoError(t, err)
+	assert.Equal(t, "20250315T235959Z", normalized)
+
+	_, err = NormalizeDateInput("whenever", now)
+	assert.Error(t, err)
+}
+
+func Test_ParsePeriod(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected Period
+	}{
+		{"daily", Period{Days: 1}},
+		{"weekly", Period{Days: 7}},
+		{"biweekly", Period{Days: 14}},
+		{"monthly", Period{Months: 1}},
+		{"quarterly", Period{Months: 3}},
+		{"yearly", Period{Years: 1}},
+		{"weekdays", Period{Days: 1, Weekdays: true}},
+		{"3d", Period{Days: 3}},
+		{"2wks", Period{Days: 14}},
+		{"6mo", Period{Months: 6}},
+		{"2 hours", Period{Duration: 2 * time.Hour}},
+		{"P1M", Period{Months: 1}},
+		{"PT30M", Period{Duration: 30 * time.Minute}},
+		{"P1W2D", Period{Days: 9}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			period, err := ParsePeriod(tt.input)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, period)
+		})
+	}
+}
+
+func Test_ParsePeriod_Invalid(t *testing.T) {
+	for _, input := range []string{"", "often", "3x", "P", "-"} {
+		_, err := ParsePeriod(input)
+		assert.Error(t, err, input)
+	}
+}
+
+func Test_ParseSignedPeriod(t *testing.T) {
+	period, sign, err := ParseSignedPeriod("-2wks")
+	assert.NoError(t, err)
+	assert.Equal(t, Period{Days: 14}, period)
+	assert.Equal(t, -1, sign)
+
+	period, sign, err = ParseSignedPeriod("+3d")
+	assert.NoError(t, err)
+	assert.Equal(t, Period{Days: 3}, period)
+	assert.Equal(t, 1, sign)
+
+	_, sign, err = ParseSignedPeriod("monthly")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, sign)
+
+	_, _, err = ParseSignedPeriod("-")
+	assert.Error(t, err)
+}
+
+func Test_Period_AddTo_ClampsMonthEnd(t *testing.T) {
+	jan31 := time.Date(2025, time.January, 31, 9, 0, 0, 0, time.UTC)
+	monthly := Period{Months: 1}
+
+	assert.Equal(t, time.Date(2025, time.February, 28, 9, 0, 0, 0, time.UTC), monthly.AddTo(jan31, 1))
+	assert.Equal(t, time.Date(2025, time.March, 31, 9, 0, 0, 0, time.UTC), monthly.AddTo(jan31, 2))
+	assert.Equal(t, time.Date(2025, time.February, 28, 9, 0, 0, 0, time.UTC), Period{Years: 1}.AddTo(time.Date(2024, time.February, 29, 9, 0, 0, 0, time.UTC), 1))
+}
+
+func Test_Period_AddTo_Weekdays(t *testing.T) {
+	friday := time.Date(2025, time.March, 7, 0, 0, 0, 0, time.UTC)
+	weekdays := Period{Days: 1, Weekdays: true}
+
+	assert.Equal(t, time.Monday, weekdays.AddTo(friday, 1).Weekday())
+	assert.Equal(t, time.Date(2025, time.March, 14, 0, 0, 0, 0, time.UTC), weekdays.AddTo(friday, 5))
+}
+
+func Test_NextRecurrences(t *testing.T) {
+	due := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	until := time.Date(2025, time.January, 29, 0, 0, 0, 0, time.UTC)
+
+	dates, err := NextRecurrences(due, "weekly", 2, 5, &until)
+	assert.NoError(t, err)
+	assert.Equal(t, []time.Time{
+		time.Date(2025, time.January, 15, 0, 0, 0, 0, time.UTC),
+		time.Date(2025, time.January, 22, 0, 0, 0, 0, time.UTC),
+		time.Date(2025, time.January, 29, 0, 0, 0, 0, time.UTC),
+	}, dates)
+
+	_, err = NextRecurrences(due, "sometimes", 0, 5, nil)
+	assert.Error(t, err)
+}
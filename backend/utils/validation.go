@@ -31,16 +31,10 @@ func ValidateCircularDependencies(depends []string, currentTaskUUID string, exis
 		return nil
 	}
 
-	dependencyGraph := make(map[string][]string)
-	for _, task := range existingTasks {
-		if task.Status == "pending" {
-			dependencyGraph[task.UUID] = task.Depends
-		}
-	}
-
-	dependencyGraph[currentTaskUUID] = depends
+	graph := NewDependencyGraph(existingTasks)
+	graph.SetDepends(currentTaskUUID, depends)
 
-	if hasCycle := detectCycle(dependencyGraph, currentTaskUUID); hasCycle {
+	if hasCycle := graph.HasCycleFrom(currentTaskUUID); hasCycle {
 		return fmt.Errorf("circular dependency detected: adding these dependencies would create a cycle")
 	}
 